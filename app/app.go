@@ -4,12 +4,14 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/xiaowumin-mark/AMLX/config"
 	"github.com/xiaowumin-mark/AMLX/database"
 	"github.com/xiaowumin-mark/AMLX/handler"
 	"github.com/xiaowumin-mark/AMLX/logx"
 	"github.com/xiaowumin-mark/AMLX/router"
+	"github.com/xiaowumin-mark/AMLX/search"
 	"github.com/xiaowumin-mark/AMLX/service"
 	"github.com/xiaowumin-mark/AMLX/store"
 	"gorm.io/gorm"
@@ -19,6 +21,15 @@ type App struct {
 	Config *config.Config
 	DB     *gorm.DB
 	Router http.Handler
+	Search service.SearchService
+	Sync   service.SyncService
+	PR     service.PullRequestService
+	CDN    service.CDNNodeService
+	Stats  service.StatsService
+	Songs  service.SongService
+	Usage  service.UsageService
+
+	index *search.Index
 }
 
 func New(cfg *config.Config) (*App, error) {
@@ -30,25 +41,67 @@ func New(cfg *config.Config) (*App, error) {
 		return nil, err
 	}
 
-	userStore := store.NewUserStore(db)                     // 创建用户store
-	roleStore := store.NewRoleStore(db)                     // 创建角色store
-	permissionStore := store.NewPermissionStore(db)         // 创建权限store
-	rolePermissionStore := store.NewRolePermissionStore(db) // 创建角色权限store
-	refreshTokenStore := store.NewRefreshTokenStore(db)     // 创建刷新令牌store
+	userStore := store.NewUserStore(db)                       // 创建用户store
+	roleStore := store.NewRoleStore(db)                       // 创建角色store
+	permissionStore := store.NewPermissionStore(db)           // 创建权限store
+	rolePermissionStore := store.NewRolePermissionStore(db)   // 创建角色权限store
+	refreshTokenStore := store.NewRefreshTokenStore(db)       // 创建刷新令牌store
+	draftStore := store.NewDraftStore(db)                     // 创建稿件store
+	transitionStore := store.NewDraftTransitionStore(db)      // 创建稿件流转记录store
+	reviewStore := store.NewReviewStore(db)                   // 创建审核记录store
+	versionStore := store.NewVersionStore(db)                 // 创建歌词版本store
+	rollbackStore := store.NewRollbackStore(db)               // 创建阶段回退store
+	syncRecordStore := store.NewSyncRecordStore(db)           // 创建仓库同步记录store
+	publishStore := store.NewPublishStore(db)                 // 创建CDN发布store
+	cdnNodeStore := store.NewCDNNodeStore(db)                 // 创建CDN节点store
+	lyricRequestStore := store.NewLyricRequestStore(db)       // 创建求歌词请求store
+	contributorStatStore := store.NewContributorStatStore(db) // 创建贡献统计store
+	songStore := store.NewSongStore(db)                       // 创建歌曲store
+	artistStore := store.NewArtistStore(db)                   // 创建艺术家store
+	albumStore := store.NewAlbumStore(db)                     // 创建专辑store
+	usageStore := store.NewUsageStore(db)                     // 创建使用统计store
+	timingReportStore := store.NewTimingReportStore(db)       // 创建时间轴反馈store
+	transactor := store.NewTransactor(db)                     // 创建事务管理器
+
+	index, err := search.Open(cfg.Search.IndexPath) // 打开搜索索引
+	if err != nil {
+		return nil, err
+	}
 
 	userService := service.NewUserService(userStore, cfg.Auth.BcryptCost) // 创建用户服务
 	jwtManager, err := service.NewJWTManager(cfg.Auth)                    // 创建JWT管理器
 	if err != nil {
 		return nil, err
 	}
-	authService := service.NewAuthService(cfg.Auth, userStore, refreshTokenStore, jwtManager)          // 创建认证服务
-	permissionService := service.NewPermissionService(roleStore, permissionStore, rolePermissionStore) // 创建权限服务
+	authService := service.NewAuthService(cfg.Auth, userStore, refreshTokenStore, jwtManager)                                                                                                                                                                                     // 创建认证服务
+	permissionService := service.NewPermissionService(cfg.Auth, transactor, userStore, roleStore, permissionStore, rolePermissionStore)                                                                                                                                           // 创建权限服务
+	statsService := service.NewStatsService(transactor, contributorStatStore, userStore, draftStore, transitionStore, versionStore)                                                                                                                                               // 创建贡献统计服务
+	songService := service.NewSongService(transactor, songStore, artistStore, albumStore, draftStore, versionStore)                                                                                                                                                               // 创建歌曲目录服务
+	searchService := service.NewSearchService(index, draftStore, versionStore)                                                                                                                                                                                                    // 创建搜索服务
+	draftService := service.NewDraftService(transactor, draftStore, transitionStore, versionStore, lyricRequestStore, songService, statsService.RecordTransition, searchService.IndexDraft)                                                                                       // 创建稿件服务，修改或删除后更新搜索索引
+	lyricRequestService := service.NewLyricRequestService(transactor, lyricRequestStore, draftService)                                                                                                                                                                            // 创建求歌词服务
+	pullRequestService := service.NewPullRequestService(cfg.Git, draftStore, versionStore)                                                                                                                                                                                        // 创建PR服务
+	cdnNodeService := service.NewCDNNodeService(cfg.CDN, transactor, cdnNodeStore, publishStore)                                                                                                                                                                                  // 创建CDN节点服务
+	publishService := service.NewPublishService(cfg.CDN, transactor, draftStore, versionStore, publishStore, cdnNodeService)                                                                                                                                                      // 创建CDN发布服务
+	reviewService := service.NewReviewService(transactor, draftStore, transitionStore, reviewStore, versionStore, statsService.RecordTransition, songService.LinkPublished, searchService.IndexDraft, pullRequestService.Open, publishService.Publish, lyricRequestService.Close) // 创建审核服务，通过后关联歌曲、更新搜索索引、创建PR、发布到CDN并关闭求歌词请求
+	rollbackService := service.NewRollbackService(transactor, draftStore, transitionStore, versionStore, rollbackStore, searchService.IndexDraft)                                                                                                                                 // 创建阶段回退服务，回退后更新搜索索引
+	syncService, err := service.NewSyncService(cfg.Sync, transactor, draftStore, versionStore, transitionStore, syncRecordStore, songService.LinkPublished, searchService.IndexDraft, publishService.Publish, lyricRequestService.Close)                                          // 创建仓库同步服务，导入后关联歌曲、更新搜索索引、发布到CDN并关闭求歌词请求
+	if err != nil {
+		return nil, err
+	}
+	versionService := service.NewVersionService(draftStore, versionStore)                                                                    // 创建版本服务
+	usageService := service.NewUsageService(cfg.Usage, transactor, usageStore, songStore)                                                    // 创建使用统计服务
+	timingReportService := service.NewTimingReportService(cfg.Timing, transactor, timingReportStore, draftStore, versionStore, draftService) // 创建时间轴反馈服务，达到阈值时开启修正稿件
+	lookupService := service.NewLookupService(songService, draftStore, versionStore, usageService.RecordMiss)                                // 创建公开歌词查询服务，未找到时记录使用统计
 
 	if cfg.Auth.BootstrapAdminRoleValue() { // 如果允许注册，则创建管理员角色
 		adminRoleID, err := permissionService.EnsureAdminRole(context.Background()) // 确保管理员角色
 		if err != nil {
 			return nil, err
 		}
+		if err := permissionService.EnsureRolePermission(context.Background(), adminRoleID, "review", "Review lyrics drafts"); err != nil { // 管理员默认拥有审核权限
+			return nil, err
+		}
 		if cfg.Auth.AllowRegisterValue() { // 如果允许注册，则设置默认角色为管理员角色
 			count, err := userStore.Count(context.Background()) // 获取用户数量
 			if err != nil {
@@ -60,11 +113,43 @@ func New(cfg *config.Config) (*App, error) {
 		}
 	}
 
-	userHandler := handler.NewUserHandler(userService)                   // 创建用户处理器
-	authHandler := handler.NewAuthHandler(authService, userService)      // 创建认证处理器
-	permissionHandler := handler.NewPermissionHandler(permissionService) // 创建权限处理器
+	userHandler := handler.NewUserHandler(userService)                               // 创建用户处理器
+	authHandler := handler.NewAuthHandler(authService, userService)                  // 创建认证处理器
+	permissionHandler := handler.NewPermissionHandler(permissionService)             // 创建权限处理器
+	draftHandler := handler.NewDraftHandler(draftService)                            // 创建稿件处理器
+	reviewHandler := handler.NewReviewHandler(reviewService)                         // 创建审核处理器
+	rollbackHandler := handler.NewRollbackHandler(rollbackService)                   // 创建阶段回退处理器
+	versionHandler := handler.NewVersionHandler(versionService)                      // 创建版本处理器
+	searchHandler := handler.NewSearchHandler(searchService)                         // 创建搜索处理器
+	syncHandler := handler.NewSyncHandler(syncService)                               // 创建仓库同步处理器
+	pullRequestHandler := handler.NewPullRequestHandler(pullRequestService)          // 创建PR处理器
+	cdnHandler := handler.NewCDNHandler(cdnNodeService, publishService)              // 创建CDN控制处理器
+	lyricRequestHandler := handler.NewLyricRequestHandler(lyricRequestService)       // 创建求歌词处理器
+	statsHandler := handler.NewStatsHandler(statsService)                            // 创建贡献统计处理器
+	songHandler := handler.NewSongHandler(songService)                               // 创建歌曲目录处理器
+	lookupHandler := handler.NewLookupHandler(lookupService, cfg.Lookup.CacheMaxAge) // 创建公开歌词查询处理器
+	usageHandler := handler.NewUsageHandler(usageService)                            // 创建使用统计处理器
+	timingReportHandler := handler.NewTimingReportHandler(timingReportService)       // 创建时间轴反馈处理器
 
-	engine := router.New(cfg, userHandler, authHandler, permissionHandler, authService, permissionService) // 创建路由
+	engine := router.New(cfg, router.Handlers{ // 创建路由
+		User:        userHandler,
+		Auth:        authHandler,
+		Permission:  permissionHandler,
+		Draft:       draftHandler,
+		Review:      reviewHandler,
+		Rollback:    rollbackHandler,
+		Version:     versionHandler,
+		Search:      searchHandler,
+		Sync:        syncHandler,
+		PullRequest: pullRequestHandler,
+		CDN:         cdnHandler,
+		Request:     lyricRequestHandler,
+		Stats:       statsHandler,
+		Song:        songHandler,
+		Lookup:      lookupHandler,
+		Usage:       usageHandler,
+		Timing:      timingReportHandler,
+	}, authService, permissionService, cdnNodeService)
 
 	logx.L().Info("mysql connected and migrated")
 
@@ -72,10 +157,42 @@ func New(cfg *config.Config) (*App, error) {
 		Config: cfg,
 		DB:     db,
 		Router: engine,
+		Search: searchService,
+		Sync:   syncService,
+		PR:     pullRequestService,
+		CDN:    cdnNodeService,
+		Stats:  statsService,
+		Songs:  songService,
+		Usage:  usageService,
+		index:  index,
 	}, nil
 }
 
+// 释放索引等资源
+func (a *App) Close() error {
+	return a.index.Close()
+}
+
 func (a *App) Run() error { // 启动服务
+	if a.index.Created() { // 索引是新建的，后台从数据库重建
+		go func() {
+			count, err := a.Search.Rebuild(context.Background())
+			if err != nil {
+				logx.L().Error("rebuild search index failed", "error", err)
+				return
+			}
+			logx.L().Info("search index rebuilt", "drafts", count)
+		}()
+	}
+	if a.Config.Git.Provider != "" && a.Config.Git.PollInterval > 0 { // 定时轮询未合并的 PR
+		go a.pollPullRequests(a.Config.Git.PollInterval)
+	}
+	if a.Config.CDN.Token != "" && a.Config.CDN.MonitorInterval > 0 { // 定时巡检 CDN 节点状态
+		go a.monitorCDNNodes(a.Config.CDN.MonitorInterval)
+	}
+	if a.Config.Usage.AggregateInterval > 0 { // 定时汇总使用事件
+		go a.aggregateUsage(a.Config.Usage.AggregateInterval)
+	}
 	addr := fmt.Sprintf(":%d", a.Config.Server.Port)
 	srv := &http.Server{
 		Addr:         addr,
@@ -86,3 +203,42 @@ func (a *App) Run() error { // 启动服务
 	}
 	return srv.ListenAndServe()
 }
+
+func (a *App) pollPullRequests(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		changed, err := a.PR.Refresh(context.Background())
+		if err != nil {
+			logx.L().Warn("refresh pull requests failed", "error", err)
+			continue
+		}
+		if changed > 0 {
+			logx.L().Info("pull requests refreshed", "changed", changed)
+		}
+	}
+}
+
+func (a *App) monitorCDNNodes(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if _, err := a.CDN.Check(context.Background()); err != nil {
+			logx.L().Warn("check cdn nodes failed", "error", err)
+		}
+	}
+}
+
+func (a *App) aggregateUsage(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		count, err := a.Usage.Aggregate(context.Background())
+		if err != nil {
+			logx.L().Warn("aggregate usage events failed", "error", err)
+		}
+		if count > 0 {
+			logx.L().Debug("usage events aggregated", "events", count)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package cdn
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 节点侧使用的控制面客户端
+type Client struct {
+	BaseURL string // 如 https://amlx.example.com/api/v1/cdn
+	Token   string
+	HTTP    *http.Client
+}
+
+func NewClient(baseURL, token string) *Client {
+	return &Client{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		Token:   token,
+		HTTP:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// 控制面返回的错误
+type StatusError struct {
+	Status int
+	Body   string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("cdn: status %d: %s", e.Status, e.Body)
+}
+
+func (c *Client) Register(ctx context.Context, req RegisterRequest) (*Registration, error) {
+	var resp Registration
+	if err := c.doJSON(ctx, http.MethodPost, "/nodes", c.Token, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// 以节点凭据发送心跳
+func (c *Client) Heartbeat(ctx context.Context, nodeID, secret string, req HeartbeatRequest) (*HeartbeatResponse, error) {
+	var resp HeartbeatResponse
+	if err := c.doJSON(ctx, http.MethodPost, "/nodes/"+url.PathEscape(nodeID)+"/heartbeat", secret, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// 拉取 ID 大于 after 的清单
+func (c *Client) Manifests(ctx context.Context, after uint, limit int) (*ManifestPage, error) {
+	query := url.Values{}
+	query.Set("after", strconv.FormatUint(uint64(after), 10))
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	var page ManifestPage
+	if err := c.doJSON(ctx, http.MethodGet, "/manifests?"+query.Encode(), c.Token, nil, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// 下载对象并校验哈希
+func (c *Client) Object(ctx context.Context, hash string) ([]byte, error) {
+	resp, err := c.do(ctx, http.MethodGet, "/objects/"+url.PathEscape(hash), c.Token, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if Hash(data) != hash {
+		return nil, fmt.Errorf("%w: %s", ErrHashMismatch, hash)
+	}
+	return data, nil
+}
+
+func (c *Client) doJSON(ctx context.Context, method, path, token string, body, out any) error {
+	resp, err := c.do(ctx, method, path, token, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (c *Client) do(ctx context.Context, method, path, token string, body any) (*http.Response, error) {
+	resp, err := send(ctx, c.HTTP, method, c.BaseURL+path, token, body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+		return nil, &StatusError{Status: resp.StatusCode, Body: strings.TrimSpace(string(data))}
+	}
+	return resp, nil
+}
+
+// 控制面向节点推送清单
+func Push(ctx context.Context, client *http.Client, endpoint, token string, manifest *Manifest) error {
+	resp, err := send(ctx, client, http.MethodPost, strings.TrimRight(endpoint, "/")+"/manifests", token, manifest)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+		return &StatusError{Status: resp.StatusCode, Body: strings.TrimSpace(string(data))}
+	}
+	return nil
+}
+
+func send(ctx context.Context, client *http.Client, method, target, token string, body any) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return client.Do(req)
+}
+
+// 不经网络直接调用 handler 的 http.Client，用于进程内集成测试
+func InProcess(handler http.Handler) *http.Client {
+	return &http.Client{Transport: handlerTransport{handler}}
+}
+
+type handlerTransport struct {
+	handler http.Handler
+}
+
+func (t handlerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rec := httptest.NewRecorder()
+	t.handler.ServeHTTP(rec, req)
+	resp := rec.Result()
+	resp.Request = req
+	return resp, nil
+}
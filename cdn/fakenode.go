@@ -0,0 +1,190 @@
+package cdn
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// 进程内的 CDN 节点，用于集成测试：接收推送或拉取清单，下载并校验对象后缓存在内存中
+type FakeNode struct {
+	Name    string
+	Region  string
+	Version string
+
+	client *Client
+	mu     sync.Mutex
+	node   *Node
+	secret string
+	cursor uint
+	errors uint                  // 上次心跳以来的错误数
+	songs  map[SongRef]*Manifest // 每首歌的最新清单
+	object map[string][]byte
+}
+
+func NewFakeNode(name, region string, client *Client) *FakeNode {
+	return &FakeNode{
+		Name:    name,
+		Region:  region,
+		Version: "fake",
+		client:  client,
+		songs:   map[SongRef]*Manifest{},
+		object:  map[string][]byte{},
+	}
+}
+
+// 向控制面注册，endpoint 为空时只能拉取
+func (n *FakeNode) Register(ctx context.Context, endpoint string) (*Node, error) {
+	reg, err := n.client.Register(ctx, RegisterRequest{Name: n.Name, Region: n.Region, Endpoint: endpoint})
+	if err != nil {
+		return nil, err
+	}
+	n.mu.Lock()
+	n.node, n.secret = &reg.Node, reg.Secret
+	n.mu.Unlock()
+	return &reg.Node, nil
+}
+
+// 以注册得到的凭据上报心跳，成功后清零错误数
+func (n *FakeNode) Heartbeat(ctx context.Context) (*HeartbeatResponse, error) {
+	n.mu.Lock()
+	if n.node == nil {
+		n.mu.Unlock()
+		return nil, errors.New("cdn: fake node is not registered")
+	}
+	nodeID, secret := n.node.ID, n.secret
+	req := HeartbeatRequest{Version: n.Version, Cursor: n.cursor, Errors: n.errors}
+	n.mu.Unlock()
+
+	resp, err := n.client.Heartbeat(ctx, nodeID, secret, req)
+	if err != nil {
+		return nil, err
+	}
+	n.mu.Lock()
+	n.errors -= req.Errors
+	n.mu.Unlock()
+	return resp, nil
+}
+
+// 从游标处拉取全部新清单，返回处理的数量
+func (n *FakeNode) Sync(ctx context.Context) (int, error) {
+	count := 0
+	for {
+		page, err := n.client.Manifests(ctx, n.Cursor(), 0)
+		if err != nil {
+			return count, err
+		}
+		for i := range page.Manifests {
+			if err := n.Apply(ctx, &page.Manifests[i]); err != nil {
+				return count, err
+			}
+			count++
+		}
+		if len(page.Manifests) == 0 {
+			return count, nil
+		}
+	}
+}
+
+// 下载清单引用的全部对象，成功后才生效
+func (n *FakeNode) Apply(ctx context.Context, manifest *Manifest) error {
+	fetched := map[string][]byte{}
+	objects := append([]Object{}, manifest.Formats...)
+	for _, diff := range manifest.Diffs {
+		objects = append(objects, diff.Object)
+	}
+	for _, object := range objects {
+		if _, ok := n.Object(object.Hash); ok {
+			continue
+		}
+		data, err := n.client.Object(ctx, object.Hash)
+		if err != nil {
+			n.mu.Lock()
+			n.errors++
+			n.mu.Unlock()
+			return err
+		}
+		fetched[object.Hash] = data
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for hash, data := range fetched {
+		n.object[hash] = data
+	}
+	for _, song := range manifest.Songs {
+		if current, ok := n.songs[song]; !ok || current.ID < manifest.ID {
+			n.songs[song] = manifest
+		}
+	}
+	n.cursor = max(n.cursor, manifest.ID)
+	return nil
+}
+
+// 接收控制面推送：POST /manifests
+func (n *FakeNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || strings.TrimRight(r.URL.Path, "/") != "/manifests" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Header.Get("Authorization") != "Bearer "+n.client.Token {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var manifest Manifest
+	if err := json.NewDecoder(r.Body).Decode(&manifest); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := n.Apply(r.Context(), &manifest); err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// 注册得到的节点信息，未注册时为 nil
+func (n *FakeNode) Node() *Node {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.node
+}
+
+func (n *FakeNode) Cursor() uint {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.cursor
+}
+
+// 歌曲的最新清单
+func (n *FakeNode) Lookup(platform, id string) (*Manifest, bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	manifest, ok := n.songs[SongRef{Platform: platform, ID: id}]
+	return manifest, ok
+}
+
+// 已缓存的对象
+func (n *FakeNode) Object(hash string) ([]byte, bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	data, ok := n.object[hash]
+	return data, ok
+}
+
+// 歌曲最新版本指定格式的内容
+func (n *FakeNode) Lyrics(platform, id, format string) ([]byte, bool) {
+	manifest, ok := n.Lookup(platform, id)
+	if !ok {
+		return nil, false
+	}
+	for _, object := range manifest.Formats {
+		if object.Format == format {
+			return n.Object(object.Hash)
+		}
+	}
+	return nil, false
+}
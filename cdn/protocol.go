@@ -0,0 +1,120 @@
+// Package cdn 定义 AMLX 控制面与 AMLX-CDN 节点之间的控制协议。
+//
+// 节点向控制面注册后，通过两种方式获得发布清单（Manifest）：
+// 控制面在发布时推送到节点的 Endpoint，或节点按游标拉取。清单只携带对象的哈希，
+// 对象内容按哈希单独下载，节点可据此校验并去重。
+//
+// 控制面接口（均需 Authorization: Bearer <token>）：
+//
+//	POST /api/v1/cdn/nodes                    注册节点，返回节点凭据
+//	GET  /api/v1/cdn/manifests?after=&limit=  拉取 ID 大于 after 的清单
+//	GET  /api/v1/cdn/objects/{hash}           下载对象
+//
+// 心跳使用注册时下发的节点凭据（Authorization: Bearer <secret>）：
+//
+//	POST /api/v1/cdn/nodes/{id}/heartbeat     上报版本、游标与错误数
+//
+// 节点接口：
+//
+//	POST {endpoint}/manifests                 接收推送的清单，Bearer 为控制面 token
+package cdn
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+// 分发格式
+const (
+	FormatBinary = "binary" // Binary Pack
+	FormatTTML   = "ttml"   // 规范化 TTML
+	FormatDiff   = "diff"   // 旧版本到新版本的结构化补丁
+)
+
+// 各格式对象的 Content-Type
+var ContentTypes = map[string]string{
+	FormatBinary: "application/vnd.amlx.pack",
+	FormatTTML:   "application/ttml+xml",
+	FormatDiff:   "application/vnd.amlx.patch+json",
+}
+
+var ErrHashMismatch = errors.New("cdn: object hash mismatch")
+
+type RegisterRequest struct {
+	Name     string `json:"name"`
+	Region   string `json:"region"`
+	Endpoint string `json:"endpoint"` // 接收推送的地址，为空时节点只拉取
+}
+
+type Node struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	Region       string    `json:"region"`
+	Endpoint     string    `json:"endpoint"`
+	RegisteredAt time.Time `json:"registered_at"`
+}
+
+// 注册结果，Secret 只在注册时返回，重新注册会轮换
+type Registration struct {
+	Node   Node   `json:"node"`
+	Secret string `json:"secret"`
+}
+
+type HeartbeatRequest struct {
+	Version string `json:"version"` // 节点软件版本
+	Cursor  uint   `json:"cursor"`  // 已应用的最新清单 ID
+	Errors  uint   `json:"errors"`  // 上次心跳以来的错误数
+}
+
+type HeartbeatResponse struct {
+	Status           string `json:"status"` // HEALTHY / LAGGING
+	LatestManifestID uint   `json:"latest_manifest_id"`
+	Lag              uint   `json:"lag"`
+}
+
+// 歌曲在某个平台上的 ID
+type SongRef struct {
+	Platform string `json:"platform"` // ncm / qq / spotify / apple / isrc
+	ID       string `json:"id"`
+}
+
+// 按哈希寻址的对象
+type Object struct {
+	Format string `json:"format"`
+	Hash   string `json:"hash"` // 内容的 SHA-256
+	Size   int64  `json:"size"`
+}
+
+// 补丁链中的一环：应用 Object 后，FromHash 对应的版本变为 ToHash
+type Diff struct {
+	FromHash string `json:"from_hash"`
+	ToHash   string `json:"to_hash"`
+	Object   Object `json:"object"`
+}
+
+// 一次发布。同一首歌以 ID 最大的清单为准
+type Manifest struct {
+	ID          uint      `json:"id"` // 递增，作为拉取游标
+	DraftID     uint      `json:"draft_id"`
+	VersionID   uint      `json:"version_id"`
+	VersionHash string    `json:"version_hash"` // 规范化 TTML 的 SHA-256
+	Title       string    `json:"title"`
+	Artists     []string  `json:"artists"`
+	Songs       []SongRef `json:"songs"`
+	Formats     []Object  `json:"formats"`
+	Diffs       []Diff    `json:"diffs"` // 从旧到新排列，最后一环的 ToHash 为 VersionHash
+	PublishedAt time.Time `json:"published_at"`
+}
+
+type ManifestPage struct {
+	Manifests []Manifest `json:"manifests"`
+	Cursor    uint       `json:"cursor"` // 下次拉取的 after，无新清单时不变
+}
+
+// 对象哈希
+func Hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -16,6 +16,13 @@ type Config struct {
 	Server ServerConfig `yaml:"server"`
 	Log    LogConfig    `yaml:"log"`
 	Auth   AuthConfig   `yaml:"auth"`
+	Search SearchConfig `yaml:"search"`
+	Sync   SyncConfig   `yaml:"sync"`
+	Git    GitConfig    `yaml:"git"`
+	CDN    CDNConfig    `yaml:"cdn"`
+	Lookup LookupConfig `yaml:"lookup"`
+	Usage  UsageConfig  `yaml:"usage"`
+	Timing TimingConfig `yaml:"timing_report"`
 }
 
 type MySQLConfig struct {
@@ -66,6 +73,62 @@ type AuthConfig struct {
 	BootstrapAdminRole *bool         `yaml:"bootstrap_admin_role"`
 }
 
+type SearchConfig struct {
+	IndexPath string `yaml:"index_path"` // Bleve 索引目录
+}
+
+// amll-ttml-db 同步，RepoPath 为空时不启用
+type SyncConfig struct {
+	RepoPath      string `yaml:"repo_path"`      // 本地克隆目录
+	BaseBranch    string `yaml:"base_branch"`    // 导入读取、导出分支起点
+	Branch        string `yaml:"branch"`         // 导出提交所在分支
+	ImportUserID  uint   `yaml:"import_user_id"` // 导入稿件的所有者
+	ContributorID string `yaml:"contributor_id"` // 导出文件名中的投稿人 ID
+	AuthorName    string `yaml:"author_name"`
+	AuthorEmail   string `yaml:"author_email"`
+}
+
+// 发布到 GitHub 时创建 PR，Provider 为空时不启用
+type GitConfig struct {
+	Provider      string        `yaml:"provider"` // github
+	APIURL        string        `yaml:"api_url"`
+	Owner         string        `yaml:"owner"`
+	Repo          string        `yaml:"repo"`
+	BaseBranch    string        `yaml:"base_branch"` // PR 的目标分支
+	Token         string        `yaml:"token"`
+	WebhookSecret string        `yaml:"webhook_secret"` // 为空时拒绝 webhook
+	PollInterval  time.Duration `yaml:"poll_interval"`  // 轮询未合并 PR 的间隔，负数不轮询
+	ContributorID string        `yaml:"contributor_id"` // 文件名中的投稿人 ID
+}
+
+// AMLX-CDN 控制协议，Token 为空时不启用
+type CDNConfig struct {
+	Token       string        `yaml:"token"`        // 节点访问控制接口、控制面推送时使用
+	Formats     []string      `yaml:"formats"`      // 分发格式：binary / ttml / diff
+	DiffDepth   int           `yaml:"diff_depth"`   // 清单中补丁链的最大长度
+	PushTimeout time.Duration `yaml:"push_timeout"` // 向单个节点推送的超时
+
+	StaleAfter      time.Duration `yaml:"stale_after"`      // 超过该时长无心跳视为失联
+	MaxLag          uint          `yaml:"max_lag"`          // 落后最新清单超过该数量视为落后
+	MonitorInterval time.Duration `yaml:"monitor_interval"` // 后台巡检节点状态的间隔，负数不巡检
+}
+
+// 公开歌词查询接口
+type LookupConfig struct {
+	CacheMaxAge time.Duration `yaml:"cache_max_age"` // Cache-Control 的 max-age
+}
+
+// 歌词使用统计
+type UsageConfig struct {
+	MaxBatch          int           `yaml:"max_batch"`          // 单次上报的最大事件数
+	AggregateInterval time.Duration `yaml:"aggregate_interval"` // 后台汇总的间隔，负数不汇总
+}
+
+// 听众时间轴反馈
+type TimingConfig struct {
+	Threshold int `yaml:"threshold"` // 反馈的不同用户数达到该值时开启修正稿件，负数不开启
+}
+
 // 加载配置
 func Load(path string) (*Config, error) {
 	if path == "" {
@@ -211,6 +274,82 @@ func applyDefaults(cfg *Config) {
 		value := true
 		cfg.Auth.BootstrapAdminRole = &value
 	}
+
+	if cfg.Search.IndexPath == "" {
+		cfg.Search.IndexPath = "data/search.bleve"
+	}
+
+	if cfg.Sync.BaseBranch == "" {
+		cfg.Sync.BaseBranch = "main"
+	}
+	if cfg.Sync.Branch == "" {
+		cfg.Sync.Branch = "amlx-sync"
+	}
+	if cfg.Sync.ImportUserID == 0 {
+		cfg.Sync.ImportUserID = 1
+	}
+	if cfg.Sync.ContributorID == "" {
+		cfg.Sync.ContributorID = "0"
+	}
+	if cfg.Sync.AuthorName == "" {
+		cfg.Sync.AuthorName = "AMLX"
+	}
+	if cfg.Sync.AuthorEmail == "" {
+		cfg.Sync.AuthorEmail = "amlx@localhost"
+	}
+
+	if cfg.Git.APIURL == "" {
+		cfg.Git.APIURL = "https://api.github.com"
+	}
+	if cfg.Git.Owner == "" {
+		cfg.Git.Owner = "Steve-xmh"
+	}
+	if cfg.Git.Repo == "" {
+		cfg.Git.Repo = "amll-ttml-db"
+	}
+	if cfg.Git.BaseBranch == "" {
+		cfg.Git.BaseBranch = "main"
+	}
+	if cfg.Git.PollInterval == 0 {
+		cfg.Git.PollInterval = 10 * time.Minute
+	}
+	if cfg.Git.ContributorID == "" {
+		cfg.Git.ContributorID = cfg.Sync.ContributorID
+	}
+
+	if len(cfg.CDN.Formats) == 0 {
+		cfg.CDN.Formats = []string{"binary", "ttml", "diff"}
+	}
+	if cfg.CDN.DiffDepth == 0 {
+		cfg.CDN.DiffDepth = 3
+	}
+	if cfg.CDN.PushTimeout == 0 {
+		cfg.CDN.PushTimeout = 5 * time.Second
+	}
+	if cfg.CDN.StaleAfter == 0 {
+		cfg.CDN.StaleAfter = 2 * time.Minute
+	}
+	if cfg.CDN.MaxLag == 0 {
+		cfg.CDN.MaxLag = 20
+	}
+	if cfg.CDN.MonitorInterval == 0 {
+		cfg.CDN.MonitorInterval = 30 * time.Second
+	}
+
+	if cfg.Lookup.CacheMaxAge == 0 {
+		cfg.Lookup.CacheMaxAge = 5 * time.Minute
+	}
+
+	if cfg.Usage.MaxBatch == 0 {
+		cfg.Usage.MaxBatch = 500
+	}
+	if cfg.Usage.AggregateInterval == 0 {
+		cfg.Usage.AggregateInterval = time.Minute
+	}
+
+	if cfg.Timing.Threshold == 0 {
+		cfg.Timing.Threshold = 5
+	}
 }
 
 // 验证配置
@@ -238,5 +377,24 @@ func validate(cfg *Config) error {
 	if strings.TrimSpace(cfg.Auth.JWTSecret) == "" {
 		return errors.New("auth.jwt_secret is required")
 	}
+	switch cfg.Git.Provider {
+	case "":
+	case "github":
+		if cfg.Git.Token == "" {
+			return errors.New("git.token is required for the github provider")
+		}
+	default:
+		return errors.New("git.provider must be github")
+	}
+	for _, format := range cfg.CDN.Formats {
+		switch format {
+		case "binary", "ttml", "diff":
+		default:
+			return fmt.Errorf("cdn.formats: unknown format %q", format)
+		}
+	}
+	if cfg.CDN.DiffDepth < 0 {
+		return errors.New("cdn.diff_depth must not be negative")
+	}
 	return nil
 }
@@ -47,6 +47,30 @@ func AutoMigrate(db *gorm.DB) error {
 		&model.Permissions{},
 		&model.RolePermissions{},
 		&model.RefreshTokens{},
+		&model.LyricsDraft{},
+		&model.LyricsReview{},
+		&model.StageRollback{},
+		&model.LyricsVersion{},
+		&model.DraftTransition{},
+		&model.LyricsSyncRecord{},
+		&model.PublishManifest{},
+		&model.PublishManifestSong{},
+		&model.PublishObject{},
+		&model.CDNNode{},
+		&model.CDNNodeEvent{},
+		&model.LyricRequest{},
+		&model.LyricRequestVote{},
+		&model.ContributorStat{},
+		&model.Song{},
+		&model.Artist{},
+		&model.Album{},
+		&model.SongArtist{},
+		&model.SongExternalID{},
+		&model.UsageEvent{},
+		&model.UsageRollup{},
+		&model.UsageCursor{},
+		&model.TimingReport{},
+		&model.VersionTimingStat{},
 	)
 }
 
@@ -0,0 +1,192 @@
+package gitprovider
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const DefaultAPIURL = "https://api.github.com"
+
+// GitHub REST API 实现
+type GitHub struct {
+	APIURL string
+	Owner  string
+	Repo   string
+	Base   string // PR 的目标分支
+	Token  string
+	Client *http.Client
+}
+
+func NewGitHub(apiURL, owner, repo, base, token string) *GitHub {
+	if apiURL == "" {
+		apiURL = DefaultAPIURL
+	}
+	return &GitHub{
+		APIURL: strings.TrimRight(apiURL, "/"),
+		Owner:  owner,
+		Repo:   repo,
+		Base:   base,
+		Token:  token,
+		Client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+type githubRef struct {
+	Object struct {
+		SHA string `json:"sha"`
+	} `json:"object"`
+}
+
+type githubContent struct {
+	SHA string `json:"sha"`
+}
+
+type githubPull struct {
+	Number  int    `json:"number"`
+	HTMLURL string `json:"html_url"`
+	State   string `json:"state"`
+	Merged  bool   `json:"merged"`
+}
+
+// 创建分支、逐个提交文件并打开 PR。分支或 PR 已存在时沿用，重试是安全的
+func (g *GitHub) OpenPullRequest(ctx context.Context, req NewPullRequest) (*PullRequest, error) {
+	var base githubRef
+	if err := g.do(ctx, http.MethodGet, g.repoPath("git/ref/heads/"+g.Base), nil, &base); err != nil {
+		return nil, fmt.Errorf("resolve base branch: %w", err)
+	}
+	err := g.do(ctx, http.MethodPost, g.repoPath("git/refs"), map[string]string{
+		"ref": "refs/heads/" + req.Branch,
+		"sha": base.Object.SHA,
+	}, nil)
+	if err != nil && !isStatus(err, http.StatusUnprocessableEntity) { // 422：分支已存在
+		return nil, fmt.Errorf("create branch: %w", err)
+	}
+
+	for _, file := range req.Files {
+		body := map[string]string{
+			"message": req.Title,
+			"content": base64.StdEncoding.EncodeToString(file.Content),
+			"branch":  req.Branch,
+		}
+		var existing githubContent
+		err := g.do(ctx, http.MethodGet, g.repoPath("contents/"+escapePath(file.Path))+"?ref="+url.QueryEscape(req.Branch), nil, &existing)
+		switch {
+		case err == nil:
+			body["sha"] = existing.SHA // 覆盖已有文件需要带上原 sha
+		case !errors.Is(err, ErrNotFound):
+			return nil, fmt.Errorf("read %s: %w", file.Path, err)
+		}
+		if err := g.do(ctx, http.MethodPut, g.repoPath("contents/"+escapePath(file.Path)), body, nil); err != nil {
+			return nil, fmt.Errorf("write %s: %w", file.Path, err)
+		}
+	}
+
+	var pull githubPull
+	err = g.do(ctx, http.MethodPost, g.repoPath("pulls"), map[string]string{
+		"title": req.Title,
+		"body":  req.Body,
+		"head":  req.Branch,
+		"base":  g.Base,
+	}, &pull)
+	if isStatus(err, http.StatusUnprocessableEntity) { // 同一分支已有打开的 PR；已关闭的 PR 不沿用
+		var pulls []githubPull
+		query := "?state=open&head=" + url.QueryEscape(g.Owner+":"+req.Branch)
+		if err := g.do(ctx, http.MethodGet, g.repoPath("pulls")+query, nil, &pulls); err != nil {
+			return nil, err
+		}
+		if len(pulls) == 0 {
+			return nil, fmt.Errorf("create pull request: %w", err)
+		}
+		pull, err = pulls[0], nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("create pull request: %w", err)
+	}
+	return toPullRequest(pull), nil
+}
+
+func (g *GitHub) GetPullRequest(ctx context.Context, number int) (*PullRequest, error) {
+	var pull githubPull
+	if err := g.do(ctx, http.MethodGet, g.repoPath(fmt.Sprintf("pulls/%d", number)), nil, &pull); err != nil {
+		return nil, err
+	}
+	return toPullRequest(pull), nil
+}
+
+func (g *GitHub) repoPath(path string) string {
+	return fmt.Sprintf("%s/repos/%s/%s/%s", g.APIURL, url.PathEscape(g.Owner), url.PathEscape(g.Repo), path)
+}
+
+func (g *GitHub) do(ctx context.Context, method, target string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if g.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+g.Token)
+	}
+
+	resp, err := g.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var msg struct {
+			Message string `json:"message"`
+		}
+		json.Unmarshal(data, &msg)
+		return &APIError{Status: resp.StatusCode, Message: msg.Message}
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
+
+func isStatus(err error, status int) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Status == status
+}
+
+// 逐段转义路径，保留分隔符
+func escapePath(path string) string {
+	parts := strings.Split(path, "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	return strings.Join(parts, "/")
+}
+
+func toPullRequest(pull githubPull) *PullRequest {
+	return &PullRequest{Number: pull.Number, URL: pull.HTMLURL, State: stateOf(pull.State, pull.Merged)}
+}
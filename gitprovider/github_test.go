@@ -0,0 +1,142 @@
+package gitprovider_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/xiaowumin-mark/AMLX/gitprovider"
+	"github.com/xiaowumin-mark/AMLX/gitprovider/gitprovidertest"
+)
+
+func TestOpenPullRequest(t *testing.T) {
+	server := gitprovidertest.NewServer("owner", "repo", "main")
+	github := server.Provider()
+	ctx := context.Background()
+
+	req := gitprovider.NewPullRequest{
+		Branch: "amlx/draft-1",
+		Title:  "Add lyrics",
+		Files:  []gitprovider.File{{Path: "raw-lyrics/1-a.ttml", Content: []byte("first")}},
+	}
+	pr, err := github.OpenPullRequest(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pr.Number != 1 || pr.State != gitprovider.StateOpen || pr.URL == "" {
+		t.Fatalf("opened %+v", pr)
+	}
+	if content, ok := server.File("amlx/draft-1", "raw-lyrics/1-a.ttml"); !ok || string(content) != "first" {
+		t.Fatalf("branch file %q, %v", content, ok)
+	}
+	if _, ok := server.File("main", "raw-lyrics/1-a.ttml"); ok {
+		t.Fatal("file written to base branch before merge")
+	}
+
+	// 重试：分支与 PR 已存在时沿用，文件被覆盖
+	req.Files[0].Content = []byte("second")
+	again, err := github.OpenPullRequest(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *again != *pr {
+		t.Fatalf("retry opened %+v, want %+v", again, pr)
+	}
+	if content, _ := server.File("amlx/draft-1", "raw-lyrics/1-a.ttml"); string(content) != "second" {
+		t.Fatalf("branch file %q after retry", content)
+	}
+}
+
+func TestOpenPullRequestAfterClose(t *testing.T) {
+	server := gitprovidertest.NewServer("owner", "repo", "main")
+	github := server.Provider()
+	ctx := context.Background()
+
+	req := gitprovider.NewPullRequest{
+		Branch: "amlx/draft-2",
+		Title:  "Add lyrics",
+		Files:  []gitprovider.File{{Path: "raw-lyrics/2-a.ttml", Content: []byte("first")}},
+	}
+	first, err := github.OpenPullRequest(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := server.Close(first.Number); err != nil {
+		t.Fatal(err)
+	}
+
+	// 分支上只有已关闭的 PR 时开新的 PR
+	second, err := github.OpenPullRequest(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Number == first.Number || second.State != gitprovider.StateOpen {
+		t.Fatalf("opened %+v, closed was #%d", second, first.Number)
+	}
+
+	// 较新的 PR 已关闭、较早的 PR 重新打开：沿用打开的那个
+	if err := server.Close(second.Number); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.Reopen(first.Number); err != nil {
+		t.Fatal(err)
+	}
+	again, err := github.OpenPullRequest(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Number != first.Number || again.State != gitprovider.StateOpen {
+		t.Fatalf("retry opened %+v, want open #%d", again, first.Number)
+	}
+}
+
+func TestGetPullRequest(t *testing.T) {
+	server := gitprovidertest.NewServer("owner", "repo", "main")
+	github := server.Provider()
+	ctx := context.Background()
+
+	open := func(branch string) int {
+		pr, err := github.OpenPullRequest(ctx, gitprovider.NewPullRequest{
+			Branch: branch,
+			Title:  branch,
+			Files:  []gitprovider.File{{Path: "raw-lyrics/" + branch + ".ttml", Content: []byte(branch)}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pr.Number
+	}
+	merged, closed := open("merged"), open("closed")
+	if err := server.Merge(merged); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.Close(closed); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		number int
+		want   gitprovider.State
+	}{
+		{merged, gitprovider.StateMerged},
+		{closed, gitprovider.StateClosed},
+	}
+	for _, tt := range tests {
+		pr, err := github.GetPullRequest(ctx, tt.number)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pr.State != tt.want {
+			t.Errorf("pull request #%d: got %s, want %s", tt.number, pr.State, tt.want)
+		}
+	}
+	if content, ok := server.File("main", "raw-lyrics/merged.ttml"); !ok || string(content) != "merged" {
+		t.Fatalf("merged file on base branch %q, %v", content, ok)
+	}
+	if _, ok := server.File("main", "raw-lyrics/closed.ttml"); ok {
+		t.Fatal("closed pull request was merged")
+	}
+	if _, err := github.GetPullRequest(ctx, 99); !errors.Is(err, gitprovider.ErrNotFound) {
+		t.Fatalf("got %v, want ErrNotFound", err)
+	}
+}
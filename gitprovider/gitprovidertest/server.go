@@ -0,0 +1,344 @@
+// Package gitprovidertest 提供进程内模拟的 GitHub 仓库，供测试使用。
+package gitprovidertest
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/xiaowumin-mark/AMLX/gitprovider"
+)
+
+// 进程内模拟的 GitHub 仓库，实现 GitHub 客户端用到的那部分 REST 接口
+type Server struct {
+	Owner string
+	Repo  string
+	Base  string
+
+	mu       sync.Mutex
+	branches map[string]map[string][]byte // 分支 -> 路径 -> 内容
+	pulls    []*pullRequest
+	handler  http.Handler
+}
+
+type pullRequest struct {
+	Number int
+	Title  string
+	Body   string
+	Head   string
+	State  gitprovider.State
+}
+
+func NewServer(owner, repo, base string) *Server {
+	s := &Server{
+		Owner:    owner,
+		Repo:     repo,
+		Base:     base,
+		branches: map[string]map[string][]byte{base: {}},
+	}
+	mux := http.NewServeMux()
+	prefix := "/repos/{owner}/{repo}/"
+	mux.HandleFunc("GET "+prefix+"git/ref/heads/{branch...}", s.getRef)
+	mux.HandleFunc("POST "+prefix+"git/refs", s.createRef)
+	mux.HandleFunc("GET "+prefix+"contents/{path...}", s.getContent)
+	mux.HandleFunc("PUT "+prefix+"contents/{path...}", s.putContent)
+	mux.HandleFunc("POST "+prefix+"pulls", s.createPull)
+	mux.HandleFunc("GET "+prefix+"pulls", s.listPulls)
+	mux.HandleFunc("GET "+prefix+"pulls/{number}", s.getPull)
+	s.handler = mux
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.handler.ServeHTTP(w, r)
+}
+
+// 返回直接在进程内调用本服务的 GitHub 客户端
+func (s *Server) Provider() *gitprovider.GitHub {
+	g := gitprovider.NewGitHub("http://gitprovider.local", s.Owner, s.Repo, s.Base, "")
+	g.Client = &http.Client{Transport: transport{s}}
+	return g
+}
+
+// 合并 PR，分支上的文件写入目标分支
+func (s *Server) Merge(number int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pull := s.pull(number)
+	if pull == nil || pull.State != gitprovider.StateOpen {
+		return gitprovider.ErrNotFound
+	}
+	for path, content := range s.branches[pull.Head] {
+		s.branches[s.Base][path] = content
+	}
+	pull.State = gitprovider.StateMerged
+	return nil
+}
+
+// 关闭 PR 且不合并
+func (s *Server) Close(number int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pull := s.pull(number)
+	if pull == nil || pull.State != gitprovider.StateOpen {
+		return gitprovider.ErrNotFound
+	}
+	pull.State = gitprovider.StateClosed
+	return nil
+}
+
+// 重新打开已关闭的 PR，同一分支已有打开的 PR 时失败
+func (s *Server) Reopen(number int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pull := s.pull(number)
+	if pull == nil || pull.State != gitprovider.StateClosed {
+		return gitprovider.ErrNotFound
+	}
+	for _, other := range s.pulls {
+		if other.Head == pull.Head && other.State == gitprovider.StateOpen {
+			return fmt.Errorf("pull request #%d is already open for %s", other.Number, pull.Head)
+		}
+	}
+	pull.State = gitprovider.StateOpen
+	return nil
+}
+
+// 读取分支上的文件
+func (s *Server) File(branch, path string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	content, ok := s.branches[branch][path]
+	return content, ok
+}
+
+func (s *Server) getRef(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	files, ok := s.branches[r.PathValue("branch")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"object": map[string]string{"sha": treeSHA(files)}})
+}
+
+func (s *Server) createRef(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Ref string `json:"ref"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	name, ok := strings.CutPrefix(req.Ref, "refs/heads/")
+	if !ok || name == "" {
+		writeError(w, http.StatusUnprocessableEntity, "Reference name is invalid")
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.branches[name]; exists {
+		writeError(w, http.StatusUnprocessableEntity, "Reference already exists")
+		return
+	}
+	files := map[string][]byte{}
+	for path, content := range s.branches[s.Base] {
+		files[path] = content
+	}
+	s.branches[name] = files
+	writeJSON(w, http.StatusCreated, map[string]any{"ref": req.Ref, "object": map[string]string{"sha": treeSHA(files)}})
+}
+
+func (s *Server) getContent(w http.ResponseWriter, r *http.Request) {
+	branch := r.URL.Query().Get("ref")
+	if branch == "" {
+		branch = s.Base
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	content, ok := s.branches[branch][r.PathValue("path")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{
+		"sha":      blobSHA(content),
+		"encoding": "base64",
+		"content":  base64.StdEncoding.EncodeToString(content),
+	})
+}
+
+func (s *Server) putContent(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Content string `json:"content"`
+		Branch  string `json:"branch"`
+		SHA     string `json:"sha"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	content, err := base64.StdEncoding.DecodeString(req.Content)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, "content is not valid Base64")
+		return
+	}
+	if req.Branch == "" {
+		req.Branch = s.Base
+	}
+	path := r.PathValue("path")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	files, ok := s.branches[req.Branch]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Branch not found")
+		return
+	}
+	old, exists := files[path]
+	if exists && req.SHA != blobSHA(old) { // 与 GitHub 一致，覆盖时 sha 必须匹配
+		writeError(w, http.StatusConflict, "sha does not match")
+		return
+	}
+	if !exists && req.SHA != "" {
+		writeError(w, http.StatusUnprocessableEntity, "sha was supplied for a new file")
+		return
+	}
+	files[path] = content
+	status := http.StatusCreated
+	if exists {
+		status = http.StatusOK
+	}
+	writeJSON(w, status, map[string]any{"content": map[string]string{"path": path, "sha": blobSHA(content)}})
+}
+
+func (s *Server) createPull(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Title string `json:"title"`
+		Body  string `json:"body"`
+		Head  string `json:"head"`
+		Base  string `json:"base"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.branches[req.Head]; !ok || req.Base != s.Base {
+		writeError(w, http.StatusUnprocessableEntity, "Validation Failed")
+		return
+	}
+	for _, pull := range s.pulls {
+		if pull.Head == req.Head && pull.State == gitprovider.StateOpen {
+			writeError(w, http.StatusUnprocessableEntity, "A pull request already exists for "+s.Owner+":"+req.Head)
+			return
+		}
+	}
+	pull := &pullRequest{Number: len(s.pulls) + 1, Title: req.Title, Body: req.Body, Head: req.Head, State: gitprovider.StateOpen}
+	s.pulls = append(s.pulls, pull)
+	writeJSON(w, http.StatusCreated, s.pullJSON(pull))
+}
+
+func (s *Server) listPulls(w http.ResponseWriter, r *http.Request) {
+	head := r.URL.Query().Get("head")
+	state := r.URL.Query().Get("state")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := []map[string]any{}
+	for i := len(s.pulls) - 1; i >= 0; i-- { // 与 GitHub 一致，新的在前
+		pull := s.pulls[i]
+		if head != "" && head != s.Owner+":"+pull.Head {
+			continue
+		}
+		if state != "all" && pull.State != gitprovider.StateOpen {
+			continue
+		}
+		out = append(out, s.pullJSON(pull))
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) getPull(w http.ResponseWriter, r *http.Request) {
+	number, err := strconv.Atoi(r.PathValue("number"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pull := s.pull(number)
+	if pull == nil {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.pullJSON(pull))
+}
+
+func (s *Server) pull(number int) *pullRequest {
+	if number < 1 || number > len(s.pulls) {
+		return nil
+	}
+	return s.pulls[number-1]
+}
+
+func (s *Server) pullJSON(pull *pullRequest) map[string]any {
+	state := "open"
+	if pull.State != gitprovider.StateOpen {
+		state = "closed"
+	}
+	return map[string]any{
+		"number":   pull.Number,
+		"html_url": fmt.Sprintf("http://gitprovider.local/%s/%s/pull/%d", s.Owner, s.Repo, pull.Number),
+		"state":    state,
+		"merged":   pull.State == gitprovider.StateMerged,
+		"title":    pull.Title,
+		"body":     pull.Body,
+	}
+}
+
+// 不经网络，直接交给 Server 处理
+type transport struct {
+	server *Server
+}
+
+func (t transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rec := httptest.NewRecorder()
+	t.server.ServeHTTP(rec, req)
+	resp := rec.Result()
+	resp.Request = req
+	return resp, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"message": message})
+}
+
+func blobSHA(content []byte) string {
+	sum := sha1.Sum(fmt.Appendf(nil, "blob %d\x00%s", len(content), content))
+	return hex.EncodeToString(sum[:])
+}
+
+// 以文件内容摘要充当分支的提交 sha
+func treeSHA(files map[string][]byte) string {
+	h := sha1.New()
+	for _, path := range slices.Sorted(maps.Keys(files)) {
+		fmt.Fprintf(h, "%s %s\n", path, blobSHA(files[path]))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
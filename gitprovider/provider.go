@@ -0,0 +1,67 @@
+// Package gitprovider 向 Git 托管平台提交歌词文件并创建 Pull Request。
+//
+// GitHub 实现走 REST API；测试可使用 gitprovidertest 在进程内模拟同一组接口。
+package gitprovider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var ErrNotFound = errors.New("gitprovider: not found")
+
+// PR 状态
+type State string
+
+const (
+	StateOpen   State = "OPEN"
+	StateMerged State = "MERGED"
+	StateClosed State = "CLOSED" // 关闭且未合并
+)
+
+type File struct {
+	Path    string // 仓库内相对路径
+	Content []byte
+}
+
+// 新建 PR 的参数，Branch 为承载提交的分支，已存在时在其上继续提交
+type NewPullRequest struct {
+	Branch string
+	Title  string
+	Body   string
+	Files  []File
+}
+
+type PullRequest struct {
+	Number int
+	URL    string
+	State  State
+}
+
+type GitProvider interface {
+	OpenPullRequest(ctx context.Context, req NewPullRequest) (*PullRequest, error) // 创建分支、提交文件并打开 PR
+	GetPullRequest(ctx context.Context, number int) (*PullRequest, error)          // 查询 PR 当前状态
+}
+
+// 平台返回的错误
+type APIError struct {
+	Status  int
+	Message string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("gitprovider: api status %d: %s", e.Status, e.Message)
+}
+
+// 由 GitHub 的 state 与 merged 字段得出状态
+func stateOf(state string, merged bool) State {
+	switch {
+	case merged:
+		return StateMerged
+	case state == "closed":
+		return StateClosed
+	default:
+		return StateOpen
+	}
+}
@@ -11,7 +11,7 @@ import (
 )
 
 type AuthHandler struct {
-	auth service.AuthService
+	auth  service.AuthService
 	users service.UserService
 }
 
@@ -58,7 +58,7 @@ type changePasswordRequest struct {
 }
 
 type authResponse struct {
-	User   userResponse   `json:"user"`
+	User   userResponse      `json:"user"`
 	Tokens service.TokenPair `json:"tokens"`
 }
 
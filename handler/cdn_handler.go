@@ -0,0 +1,210 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaowumin-mark/AMLX/cdn"
+	"github.com/xiaowumin-mark/AMLX/model"
+	"github.com/xiaowumin-mark/AMLX/service"
+)
+
+type CDNHandler struct {
+	nodes   service.CDNNodeService
+	publish service.PublishService
+}
+
+func NewCDNHandler(nodes service.CDNNodeService, publish service.PublishService) *CDNHandler {
+	return &CDNHandler{nodes: nodes, publish: publish}
+}
+
+// 节点接口，由 CDN token 认证
+func (h *CDNHandler) Register(rg *gin.RouterGroup) {
+	rg.POST("/nodes", h.registerNode)
+	rg.GET("/manifests", h.listManifests)
+	rg.GET("/objects/:hash", h.getObject)
+}
+
+// 节点心跳，以节点凭据认证
+func (h *CDNHandler) RegisterHeartbeat(rg *gin.RouterGroup) {
+	rg.POST("/cdn/nodes/:id/heartbeat", h.heartbeat)
+}
+
+// 管理接口
+func (h *CDNHandler) RegisterAdmin(rg *gin.RouterGroup) {
+	rg.POST("/cdn/publish/drafts/:id", h.publishDraft)
+	rg.GET("/cdn/nodes", h.listNodes)
+	rg.GET("/cdn/nodes/:id/events", h.listNodeEvents)
+}
+
+type cdnNodeResponse struct {
+	ID              string              `json:"id"`
+	Name            string              `json:"name"`
+	Region          string              `json:"region"`
+	Endpoint        string              `json:"endpoint"`
+	Version         string              `json:"version"`
+	Status          model.CDNNodeStatus `json:"status"`
+	LastHeartbeatAt *string             `json:"last_heartbeat_at"`
+	Cursor          uint                `json:"cursor"`
+	Lag             uint                `json:"lag"`
+	ReportedErrors  uint                `json:"reported_errors"`
+	PushErrors      uint                `json:"push_errors"`
+	RegisteredAt    string              `json:"registered_at"`
+}
+
+type cdnNodeEventResponse struct {
+	FromStatus model.CDNNodeStatus `json:"from_status"`
+	ToStatus   model.CDNNodeStatus `json:"to_status"`
+	Reason     string              `json:"reason"`
+	CreatedAt  string              `json:"created_at"`
+}
+
+func (h *CDNHandler) registerNode(c *gin.Context) {
+	var req cdn.RegisterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+	reg, err := h.nodes.Register(c.Request.Context(), req)
+	if err != nil {
+		handleCDNError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, reg)
+}
+
+func (h *CDNHandler) heartbeat(c *gin.Context) {
+	var req cdn.HeartbeatRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+	resp, err := h.nodes.Heartbeat(c.Request.Context(), c.Param("id"), bearerToken(c), req)
+	if err != nil {
+		handleCDNError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *CDNHandler) listNodes(c *gin.Context) {
+	nodes, err := h.nodes.Nodes(c.Request.Context(), model.CDNNodeStatus(strings.ToUpper(c.Query("status"))))
+	if err != nil {
+		handleCDNError(c, err)
+		return
+	}
+	resp := make([]cdnNodeResponse, 0, len(nodes))
+	for i := range nodes {
+		resp = append(resp, toCDNNodeResponse(&nodes[i]))
+	}
+	c.JSON(http.StatusOK, gin.H{"nodes": resp})
+}
+
+func (h *CDNHandler) listNodeEvents(c *gin.Context) {
+	events, err := h.nodes.ListEvents(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		handleCDNError(c, err)
+		return
+	}
+	resp := make([]cdnNodeEventResponse, 0, len(events))
+	for _, event := range events {
+		resp = append(resp, cdnNodeEventResponse{
+			FromStatus: event.FromStatus,
+			ToStatus:   event.ToStatus,
+			Reason:     event.Reason,
+			CreatedAt:  event.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"events": resp})
+}
+
+func (h *CDNHandler) listManifests(c *gin.Context) {
+	after, err := strconv.ParseUint(c.DefaultQuery("after", "0"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid after"})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+		return
+	}
+	page, err := h.publish.Manifests(c.Request.Context(), uint(after), limit)
+	if err != nil {
+		handleCDNError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+func (h *CDNHandler) getObject(c *gin.Context) {
+	object, err := h.publish.Object(c.Request.Context(), c.Param("hash"))
+	if err != nil {
+		handleCDNError(c, err)
+		return
+	}
+	c.Header("ETag", `"`+object.Hash+`"`)
+	c.Header("Cache-Control", "public, max-age=31536000, immutable") // 按哈希寻址，内容不变
+	c.Data(http.StatusOK, object.ContentType, object.Data)
+}
+
+func (h *CDNHandler) publishDraft(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	manifest, err := h.publish.PublishByID(c.Request.Context(), id)
+	if err != nil {
+		handleCDNError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"manifest": manifest})
+}
+
+func toCDNNodeResponse(node *model.CDNNode) cdnNodeResponse {
+	return cdnNodeResponse{
+		ID:              node.NodeID,
+		Name:            node.Name,
+		Region:          node.Region,
+		Endpoint:        node.Endpoint,
+		Version:         node.Version,
+		Status:          node.Status,
+		LastHeartbeatAt: formatOptionalTime(node.LastHeartbeatAt),
+		Cursor:          node.Cursor,
+		Lag:             node.Lag,
+		ReportedErrors:  node.ReportedErrors,
+		PushErrors:      node.PushErrors,
+		RegisteredAt:    node.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// Authorization 中的 Bearer 凭据
+func bearerToken(c *gin.Context) string {
+	scheme, token, ok := strings.Cut(c.GetHeader("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+func handleCDNError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidInput):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input"})
+	case errors.Is(err, service.ErrCDNDisabled):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrCDNUnauthorized):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrNotCDNTarget), errors.Is(err, service.ErrInvalidDraftState):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrDraftNotFound), errors.Is(err, service.ErrObjectNotFound), errors.Is(err, service.ErrCDNNodeNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
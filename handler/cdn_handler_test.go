@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaowumin-mark/AMLX/cdn"
+	"github.com/xiaowumin-mark/AMLX/config"
+	"github.com/xiaowumin-mark/AMLX/lyrics"
+	"github.com/xiaowumin-mark/AMLX/lyrics/pack"
+	"github.com/xiaowumin-mark/AMLX/lyrics/patch"
+	"github.com/xiaowumin-mark/AMLX/lyrics/ttml"
+	"github.com/xiaowumin-mark/AMLX/model"
+	"github.com/xiaowumin-mark/AMLX/service"
+	"github.com/xiaowumin-mark/AMLX/store/storetest"
+)
+
+// 没有登记节点，发布后不推送，节点只能拉取
+type noCDNNodes struct {
+	service.CDNNodeService
+}
+
+func (noCDNNodes) Nodes(ctx context.Context, status model.CDNNodeStatus) ([]model.CDNNode, error) {
+	return nil, nil
+}
+
+type cdnFixture struct {
+	drafts   *storetest.DraftStore
+	versions *storetest.VersionStore
+	publish  *storetest.PublishStore
+	service  service.PublishService
+	client   *cdn.Client
+	node     *cdn.FakeNode
+}
+
+// 控制面的节点接口挂在进程内的 gin 上，FakeNode 通过 cdn.Client 访问
+func newCDNFixture(diffDepth int) *cdnFixture {
+	gin.SetMode(gin.TestMode)
+	f := &cdnFixture{
+		drafts:   storetest.NewDraftStore(),
+		versions: storetest.NewVersionStore(),
+		publish:  storetest.NewPublishStore(),
+	}
+	cfg := config.CDNConfig{Token: "cdn-token", Formats: []string{cdn.FormatBinary, cdn.FormatTTML, cdn.FormatDiff}, DiffDepth: diffDepth}
+	f.service = service.NewPublishService(cfg, storetest.Tx{}, f.drafts, f.versions, f.publish, noCDNNodes{})
+
+	engine := gin.New()
+	NewCDNHandler(noCDNNodes{}, f.service).Register(engine.Group("/api/v1/cdn"))
+	f.client = cdn.NewClient("http://amlx.local/api/v1/cdn", cfg.Token)
+	f.client.HTTP = cdn.InProcess(engine)
+	f.node = cdn.NewFakeNode("node-1", "test", f.client)
+	return f
+}
+
+// 歌曲 ncm 1 的第 revision 版：每版修改一行文本并整体平移一行
+func cdnDocument(revision int) *lyrics.Document {
+	doc := &lyrics.Document{Timing: lyrics.TimingLine}
+	doc.SetMeta(lyrics.MetaMusicName, "Song")
+	doc.SetMeta(lyrics.MetaArtists, "Artist")
+	doc.SetMeta(lyrics.MetaNCMMusicID, "1")
+	for i := 0; i < 4; i++ {
+		begin := int64(i * 2000)
+		text := fmt.Sprintf("line %d", i)
+		if i == revision%4 {
+			text = fmt.Sprintf("line %d revision %d", i, revision)
+			begin += int64(revision * 10)
+		}
+		doc.Lines = append(doc.Lines, lyrics.Line{
+			Begin:     begin,
+			End:       begin + 1500,
+			Syllables: []lyrics.Syllable{{Text: text, Begin: begin, End: begin + 1500}},
+		})
+	}
+	return doc
+}
+
+// 以新稿件发布歌曲的一个版本，返回规范 TTML
+func (f *cdnFixture) publishRevision(t *testing.T, draftID uint, revision int) []byte {
+	t.Helper()
+	content := ttml.Marshal(cdnDocument(revision))
+	snapshot := &model.LyricsVersion{DraftID: draftID, WorkflowStage: model.StageCheck, Content: string(content), IsSnapshot: true}
+	if err := f.versions.Create(context.Background(), snapshot); err != nil {
+		t.Fatal(err)
+	}
+	draft := model.LyricsDraft{Title: "Song", Artists: `["Artist"]`, Status: model.DraftReviewDone, ReviewSnapshotID: &snapshot.ID}
+	draft.ID = draftID
+	f.drafts.Put(draft)
+	if err := f.service.Publish(context.Background(), &draft); err != nil {
+		t.Fatal(err)
+	}
+	return content
+}
+
+func TestCDNPublishAndPull(t *testing.T) {
+	f := newCDNFixture(2)
+	ctx := context.Background()
+
+	first := f.publishRevision(t, 1, 1)
+	if n, err := f.node.Sync(ctx); err != nil || n != 1 {
+		t.Fatalf("first sync: %d, %v", n, err)
+	}
+	manifest, ok := f.node.Lookup("ncm", "1")
+	if !ok || manifest.ID != 1 || manifest.VersionHash != cdn.Hash(first) || len(manifest.Diffs) != 0 {
+		t.Fatalf("first manifest %+v", manifest)
+	}
+	if got, _ := f.node.Lyrics("ncm", "1", cdn.FormatTTML); string(got) != string(first) {
+		t.Fatalf("node ttml %q", got)
+	}
+	binary, _ := f.node.Lyrics("ncm", "1", cdn.FormatBinary)
+	if doc, err := pack.Decode(binary); err != nil || string(ttml.Marshal(doc)) != string(first) {
+		t.Fatalf("node binary does not decode to the published document: %v", err)
+	}
+
+	// 同一首歌的后续版本：补丁链逐版追加，最多 DiffDepth 环
+	contents := [][]byte{first}
+	for revision := 2; revision <= 4; revision++ {
+		contents = append(contents, f.publishRevision(t, uint(revision), revision))
+	}
+	if n, err := f.node.Sync(ctx); err != nil || n != 3 {
+		t.Fatalf("second sync: %d, %v", n, err)
+	}
+	if f.node.Cursor() != 4 {
+		t.Fatalf("node cursor %d, want 4", f.node.Cursor())
+	}
+	manifest, _ = f.node.Lookup("ncm", "1")
+	if manifest.DraftID != 4 || manifest.VersionHash != cdn.Hash(contents[3]) || len(manifest.Diffs) != 2 {
+		t.Fatalf("latest manifest %+v", manifest)
+	}
+
+	// 持有链上任一旧版本的客户端都能逐环补到最新版本
+	for i, diff := range manifest.Diffs {
+		base := contents[i+1]
+		if diff.FromHash != cdn.Hash(base) || diff.ToHash != cdn.Hash(contents[i+2]) {
+			t.Fatalf("diff %d: %s -> %s", i, diff.FromHash, diff.ToHash)
+		}
+		data, ok := f.node.Object(diff.Object.Hash)
+		if !ok {
+			t.Fatalf("diff %d object not pulled", i)
+		}
+		var p patch.Patch
+		if err := json.Unmarshal(data, &p); err != nil {
+			t.Fatal(err)
+		}
+		baseDoc, err := ttml.Parse(base)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, err := patch.Apply(baseDoc, &p)
+		if err != nil {
+			t.Fatalf("diff %d: %v", i, err)
+		}
+		if got := ttml.Marshal(result); string(got) != string(contents[i+2]) {
+			t.Fatalf("diff %d result differs from the published version", i)
+		}
+	}
+
+	// 快照未变化时重新发布不产生新清单
+	draft, _ := f.drafts.GetByID(ctx, 4)
+	if err := f.service.Publish(ctx, draft); err != nil {
+		t.Fatal(err)
+	}
+	if latest, _ := f.publish.LatestID(ctx); latest != 4 {
+		t.Fatalf("republish created manifest %d", latest)
+	}
+	if n, err := f.node.Sync(ctx); err != nil || n != 0 {
+		t.Fatalf("sync after republish: %d, %v", n, err)
+	}
+}
+
+func TestCDNObjectNotFound(t *testing.T) {
+	f := newCDNFixture(3)
+	_, err := f.client.Object(context.Background(), cdn.Hash([]byte("missing")))
+	var statusErr *cdn.StatusError
+	if !errors.As(err, &statusErr) || statusErr.Status != http.StatusNotFound {
+		t.Fatalf("got %v, want 404", err)
+	}
+}
@@ -0,0 +1,428 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaowumin-mark/AMLX/middleware"
+	"github.com/xiaowumin-mark/AMLX/model"
+	"github.com/xiaowumin-mark/AMLX/service"
+)
+
+type DraftHandler struct {
+	svc service.DraftService
+}
+
+func NewDraftHandler(svc service.DraftService) *DraftHandler {
+	return &DraftHandler{svc: svc}
+}
+
+func (h *DraftHandler) Register(rg *gin.RouterGroup) {
+	group := rg.Group("/drafts")
+	group.POST("", h.create)
+	group.GET("", h.list)
+	group.GET("/:id", h.get)
+	group.PATCH("/:id", h.update)
+	group.DELETE("/:id", h.delete)
+	group.POST("/:id/advance", h.advance)
+	group.POST("/:id/submit", h.submit)
+	group.GET("/:id/transitions", h.listTransitions)
+	group.GET("/:id/lint", h.lint)
+}
+
+type createDraftRequest struct {
+	Title              string               `json:"title"`
+	Artists            []string             `json:"artists"`
+	Album              string               `json:"album"`
+	Language           string               `json:"language"`
+	AllowStageRollback *bool                `json:"allow_stage_rollback"`
+	PublishTarget      string               `json:"publish_target"`
+	SongID             *uint                `json:"song_id"`
+	Import             *importLyricsRequest `json:"import"`
+}
+
+type importLyricsRequest struct {
+	Format  string `json:"format"`
+	Content string `json:"content"`
+}
+
+type updateDraftRequest struct {
+	Title              *string   `json:"title"`
+	Artists            *[]string `json:"artists"`
+	Album              *string   `json:"album"`
+	Language           *string   `json:"language"`
+	AllowStageRollback *bool     `json:"allow_stage_rollback"`
+	PublishTarget      *string   `json:"publish_target"`
+	SongID             *uint     `json:"song_id"`
+}
+
+type draftResponse struct {
+	ID                 uint                   `json:"id"`
+	Title              string                 `json:"title"`
+	Artists            []string               `json:"artists"`
+	Album              string                 `json:"album"`
+	Language           string                 `json:"language"`
+	SongID             *uint                  `json:"song_id"`
+	OwnerUserID        uint                   `json:"owner_user_id"`
+	Status             model.DraftStatus      `json:"status"`
+	WorkflowStage      *model.WorkflowStage   `json:"workflow_stage"`
+	RejectCount        uint                   `json:"reject_count"`
+	LastRejectAt       *string                `json:"last_reject_at"`
+	AllowStageRollback bool                   `json:"allow_stage_rollback"`
+	ReviewSnapshotID   *uint                  `json:"review_snapshot_id"`
+	SourceVersionID    *uint                  `json:"source_version_id"`
+	GithubPRURL        string                 `json:"github_pr_url"`
+	GithubPRState      model.PullRequestState `json:"github_pr_state"`
+	PublishTarget      string                 `json:"publish_target"`
+	CreatedAt          string                 `json:"created_at"`
+	UpdatedAt          string                 `json:"updated_at"`
+}
+
+type transitionResponse struct {
+	ID          uint                   `json:"id"`
+	DraftID     uint                   `json:"draft_id"`
+	Action      model.TransitionAction `json:"action"`
+	FromStatus  model.DraftStatus      `json:"from_status"`
+	FromStage   *model.WorkflowStage   `json:"from_stage"`
+	ToStatus    model.DraftStatus      `json:"to_status"`
+	ToStage     *model.WorkflowStage   `json:"to_stage"`
+	ActorUserID uint                   `json:"actor_user_id"`
+	Reason      string                 `json:"reason"`
+	CreatedAt   string                 `json:"created_at"`
+}
+
+type versionResponse struct {
+	ID            uint                `json:"id"`
+	DraftID       uint                `json:"draft_id"`
+	WorkflowStage model.WorkflowStage `json:"workflow_stage"`
+	Content       string              `json:"content,omitempty"`
+	IsSnapshot    bool                `json:"is_snapshot"`
+	CreatedBy     uint                `json:"created_by"`
+	CreatedAt     string              `json:"created_at"`
+}
+
+func (h *DraftHandler) create(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	var req createDraftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json"})
+		return
+	}
+	var importLyrics *service.ImportLyrics
+	if req.Import != nil {
+		importLyrics = &service.ImportLyrics{Format: req.Import.Format, Content: req.Import.Content}
+	}
+
+	draft, err := h.svc.Create(c.Request.Context(), userID, service.CreateDraftRequest{
+		Title:              req.Title,
+		Artists:            req.Artists,
+		Album:              req.Album,
+		Language:           req.Language,
+		AllowStageRollback: req.AllowStageRollback,
+		PublishTarget:      req.PublishTarget,
+		SongID:             req.SongID,
+		Import:             importLyrics,
+	})
+	if err != nil {
+		handleDraftError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"draft": toDraftResponse(draft)})
+}
+
+func (h *DraftHandler) list(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	page, pageSize, err := parsePageQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid pagination"})
+		return
+	}
+
+	drafts, total, err := h.svc.List(c.Request.Context(), userID, service.ListDraftsRequest{
+		Status:   c.Query("status"),
+		Page:     page,
+		PageSize: pageSize,
+	})
+	if err != nil {
+		handleDraftError(c, err)
+		return
+	}
+	items := make([]draftResponse, 0, len(drafts))
+	for i := range drafts {
+		items = append(items, toDraftResponse(&drafts[i]))
+	}
+	c.JSON(http.StatusOK, gin.H{"drafts": items, "total": total})
+}
+
+func (h *DraftHandler) get(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	draft, err := h.svc.Get(c.Request.Context(), userID, id)
+	if err != nil {
+		handleDraftError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"draft": toDraftResponse(draft)})
+}
+
+func (h *DraftHandler) update(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	var req updateDraftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json"})
+		return
+	}
+
+	draft, err := h.svc.Update(c.Request.Context(), userID, id, service.UpdateDraftRequest{
+		Title:              req.Title,
+		Artists:            req.Artists,
+		Album:              req.Album,
+		Language:           req.Language,
+		AllowStageRollback: req.AllowStageRollback,
+		PublishTarget:      req.PublishTarget,
+		SongID:             req.SongID,
+	})
+	if err != nil {
+		handleDraftError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"draft": toDraftResponse(draft)})
+}
+
+func (h *DraftHandler) delete(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	if err := h.svc.Delete(c.Request.Context(), userID, id); err != nil {
+		handleDraftError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"ok": true})
+}
+
+func (h *DraftHandler) advance(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	draft, err := h.svc.Advance(c.Request.Context(), userID, id)
+	if err != nil {
+		handleDraftError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"draft": toDraftResponse(draft)})
+}
+
+func (h *DraftHandler) submit(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	draft, err := h.svc.Submit(c.Request.Context(), userID, id)
+	if err != nil {
+		handleDraftError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"draft": toDraftResponse(draft)})
+}
+
+func (h *DraftHandler) listTransitions(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	transitions, err := h.svc.ListTransitions(c.Request.Context(), userID, id)
+	if err != nil {
+		handleDraftError(c, err)
+		return
+	}
+	items := make([]transitionResponse, 0, len(transitions))
+	for i := range transitions {
+		items = append(items, toTransitionResponse(&transitions[i]))
+	}
+	c.JSON(http.StatusOK, gin.H{"transitions": items})
+}
+
+func (h *DraftHandler) lint(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	report, err := h.svc.Lint(c.Request.Context(), userID, id)
+	if err != nil {
+		handleDraftError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"lint": report})
+}
+
+func handleDraftError(c *gin.Context, err error) {
+	var lintErr *service.LintError
+	switch {
+	case errors.As(err, &lintErr):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "lint": lintErr.Report})
+	case errors.Is(err, service.ErrInvalidInput):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input"})
+	case errors.Is(err, service.ErrUnsupportedFormat), errors.Is(err, service.ErrInvalidLyrics), errors.Is(err, service.ErrInvalidPatch):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidTransition), errors.Is(err, service.ErrInvalidDraftState):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrDraftForbidden), errors.Is(err, service.ErrSelfReview),
+		errors.Is(err, service.ErrRollbackDisabled):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrSnapshotImmutable), errors.Is(err, service.ErrDraftLocked), errors.Is(err, service.ErrRollbackPending),
+		errors.Is(err, service.ErrRollbackNotPending), errors.Is(err, service.ErrRollbackStale), errors.Is(err, service.ErrPatchConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrDraftNotFound), errors.Is(err, service.ErrSnapshotNotFound),
+		errors.Is(err, service.ErrRollbackNotFound), errors.Is(err, service.ErrVersionNotFound),
+		errors.Is(err, service.ErrVersionNotApproved), errors.Is(err, service.ErrSongNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
+
+// 获取当前登录用户，失败时直接写入 401
+func requireUserID(c *gin.Context) (uint, bool) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok || userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return 0, false
+	}
+	return userID, true
+}
+
+// 解析 page / page_size 查询参数
+func parsePageQuery(c *gin.Context) (int, int, error) {
+	page, pageSize := 0, 0
+	if value := c.Query("page"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return 0, 0, err
+		}
+		page = parsed
+	}
+	if value := c.Query("page_size"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return 0, 0, err
+		}
+		pageSize = parsed
+	}
+	return page, pageSize, nil
+}
+
+func formatOptionalTime(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	value := t.Format(time.RFC3339)
+	return &value
+}
+
+func toDraftResponse(draft *model.LyricsDraft) draftResponse {
+	return draftResponse{
+		ID:                 draft.ID,
+		Title:              draft.Title,
+		Artists:            service.DecodeArtists(draft.Artists),
+		Album:              draft.Album,
+		Language:           draft.Language,
+		SongID:             draft.SongID,
+		OwnerUserID:        draft.OwnerUserID,
+		Status:             draft.Status,
+		WorkflowStage:      draft.WorkflowStage,
+		RejectCount:        draft.RejectCount,
+		LastRejectAt:       formatOptionalTime(draft.LastRejectAt),
+		AllowStageRollback: draft.AllowStageRollback,
+		ReviewSnapshotID:   draft.ReviewSnapshotID,
+		SourceVersionID:    draft.SourceVersionID,
+		GithubPRURL:        draft.GithubPRURL,
+		GithubPRState:      draft.GithubPRState,
+		PublishTarget:      draft.PublishTarget,
+		CreatedAt:          draft.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:          draft.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+func toTransitionResponse(t *model.DraftTransition) transitionResponse {
+	return transitionResponse{
+		ID:          t.ID,
+		DraftID:     t.DraftID,
+		Action:      t.Action,
+		FromStatus:  t.FromStatus,
+		FromStage:   t.FromStage,
+		ToStatus:    t.ToStatus,
+		ToStage:     t.ToStage,
+		ActorUserID: t.ActorUserID,
+		Reason:      t.Reason,
+		CreatedAt:   t.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func toVersionResponse(version *model.LyricsVersion) versionResponse {
+	return versionResponse{
+		ID:            version.ID,
+		DraftID:       version.DraftID,
+		WorkflowStage: version.WorkflowStage,
+		Content:       version.Content,
+		IsSnapshot:    version.IsSnapshot,
+		CreatedBy:     version.CreatedBy,
+		CreatedAt:     version.CreatedAt.Format(time.RFC3339),
+	}
+}
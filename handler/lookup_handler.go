@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaowumin-mark/AMLX/service"
+)
+
+// 按 Accept 协商时可返回的媒体类型
+var lookupMediaTypes = map[string]string{
+	"application/ttml+xml":      service.FormatTTML,
+	"application/xml":           service.FormatTTML,
+	"text/xml":                  service.FormatTTML,
+	"application/vnd.amlx.pack": service.FormatPack,
+	"application/octet-stream":  service.FormatPack,
+	"text/x-lrc":                service.FormatLRC,
+	"text/plain":                service.FormatLRC,
+	"application/json":          service.FormatJSON,
+}
+
+type LookupHandler struct {
+	svc    service.LookupService
+	maxAge time.Duration
+}
+
+func NewLookupHandler(svc service.LookupService, maxAge time.Duration) *LookupHandler {
+	return &LookupHandler{svc: svc, maxAge: maxAge}
+}
+
+// 公开接口，无需登录
+func (h *LookupHandler) Register(rg *gin.RouterGroup) {
+	rg.GET("/lyrics/:platform/:id", h.lyrics)
+}
+
+func (h *LookupHandler) lyrics(c *gin.Context) {
+	c.Header("Vary", "Accept")
+	format := c.Query("format")
+	if format == "" {
+		var ok bool
+		if format, ok = negotiateFormat(c.GetHeader("Accept")); !ok {
+			c.JSON(http.StatusNotAcceptable, gin.H{"error": "not acceptable"})
+			return
+		}
+	}
+
+	result, err := h.svc.Lyrics(c.Request.Context(), c.Param("platform"), c.Param("id"), format)
+	if err != nil {
+		handleLookupError(c, err)
+		return
+	}
+	// 同一版本同一格式的内容不变，未变化时不必生成内容
+	etag := strconv.Quote(result.VersionHash + "-" + result.Format)
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", h.cacheControl())
+	c.Header("X-AMLX-Song-ID", strconv.FormatUint(uint64(result.SongID), 10))
+	c.Header("X-AMLX-Version-ID", strconv.FormatUint(uint64(result.VersionID), 10))
+	c.Header("X-AMLX-Version-Hash", result.VersionHash)
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	if err := h.svc.Render(result); err != nil {
+		handleLookupError(c, err)
+		return
+	}
+	c.Data(http.StatusOK, result.ContentType, result.Content)
+}
+
+func (h *LookupHandler) cacheControl() string {
+	if h.maxAge < 0 {
+		return "no-cache"
+	}
+	return fmt.Sprintf("public, max-age=%d", int(h.maxAge.Seconds()))
+}
+
+// 取 Accept 中权重最高的已知类型；未给出或接受任意类型时为 TTML
+func negotiateFormat(accept string) (string, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return service.FormatTTML, true
+	}
+	format, best := "", 0.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(value, 64); err != nil {
+				continue
+			}
+		}
+		candidate, ok := lookupMediaTypes[mediaType]
+		if !ok && (mediaType == "*/*" || mediaType == "application/*") {
+			candidate, ok = service.FormatTTML, true
+		}
+		if ok && q > best {
+			format, best = candidate, q
+		}
+	}
+	return format, format != ""
+}
+
+// If-None-Match 中任意一个实体标签与 etag 相同（弱比较）
+func etagMatches(header, etag string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+	return false
+}
+
+func handleLookupError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidInput):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input"})
+	case errors.Is(err, service.ErrUnsupportedFormat), errors.Is(err, service.ErrUnknownPlatform):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrSongNotFound), errors.Is(err, service.ErrLyricsNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
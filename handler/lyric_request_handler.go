@@ -0,0 +1,251 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaowumin-mark/AMLX/model"
+	"github.com/xiaowumin-mark/AMLX/service"
+)
+
+type LyricRequestHandler struct {
+	svc service.LyricRequestService
+}
+
+func NewLyricRequestHandler(svc service.LyricRequestService) *LyricRequestHandler {
+	return &LyricRequestHandler{svc: svc}
+}
+
+func (h *LyricRequestHandler) Register(rg *gin.RouterGroup) {
+	group := rg.Group("/requests")
+	group.POST("", h.create)
+	group.GET("", h.list)
+	group.GET("/:id", h.get)
+	group.POST("/:id/vote", h.vote)
+	group.DELETE("/:id/vote", h.unvote)
+	group.POST("/:id/claim", h.claim)
+	group.POST("/:id/release", h.release)
+}
+
+type createLyricRequestRequest struct {
+	Title   string   `json:"title"`
+	Artists []string `json:"artists"`
+	Album   string   `json:"album"`
+	Links   []string `json:"links"`
+}
+
+type lyricRequestResponse struct {
+	ID              uint                     `json:"id"`
+	Title           string                   `json:"title"`
+	Artists         []string                 `json:"artists"`
+	Album           string                   `json:"album"`
+	Links           []string                 `json:"links"`
+	RequesterUserID uint                     `json:"requester_user_id"`
+	Status          model.LyricRequestStatus `json:"status"`
+	Votes           uint                     `json:"votes"`
+	Voted           bool                     `json:"voted"`
+	ClaimedBy       uint                     `json:"claimed_by"`
+	ClaimedAt       *string                  `json:"claimed_at"`
+	DraftID         *uint                    `json:"draft_id"`
+	ClosedAt        *string                  `json:"closed_at"`
+	CreatedAt       string                   `json:"created_at"`
+}
+
+func (h *LyricRequestHandler) create(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	var req createLyricRequestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json"})
+		return
+	}
+
+	request, err := h.svc.Create(c.Request.Context(), userID, service.CreateLyricRequestRequest{
+		Title:   req.Title,
+		Artists: req.Artists,
+		Album:   req.Album,
+		Links:   req.Links,
+	})
+	if err != nil {
+		h.handleError(c, userID, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"request": toLyricRequestResponse(request, false)})
+}
+
+func (h *LyricRequestHandler) list(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	page, pageSize, err := parsePageQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid pagination"})
+		return
+	}
+
+	requests, total, err := h.svc.List(c.Request.Context(), service.ListLyricRequestsRequest{
+		Status:   c.Query("status"),
+		Sort:     c.Query("sort"),
+		Page:     page,
+		PageSize: pageSize,
+	})
+	if err != nil {
+		handleLyricRequestError(c, err)
+		return
+	}
+	voted, err := h.svc.Voted(c.Request.Context(), userID, requests)
+	if err != nil {
+		handleLyricRequestError(c, err)
+		return
+	}
+	items := make([]lyricRequestResponse, 0, len(requests))
+	for i := range requests {
+		items = append(items, toLyricRequestResponse(&requests[i], voted[requests[i].ID]))
+	}
+	c.JSON(http.StatusOK, gin.H{"requests": items, "total": total})
+}
+
+func (h *LyricRequestHandler) get(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	request, err := h.svc.Get(c.Request.Context(), id)
+	if err != nil {
+		handleLyricRequestError(c, err)
+		return
+	}
+	h.respond(c, userID, request)
+}
+
+func (h *LyricRequestHandler) vote(c *gin.Context) {
+	h.update(c, h.svc.Vote)
+}
+
+func (h *LyricRequestHandler) unvote(c *gin.Context) {
+	h.update(c, h.svc.Unvote)
+}
+
+func (h *LyricRequestHandler) release(c *gin.Context) {
+	h.update(c, h.svc.Release)
+}
+
+func (h *LyricRequestHandler) claim(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	request, draft, err := h.svc.Claim(c.Request.Context(), userID, id)
+	if err != nil {
+		handleLyricRequestError(c, err)
+		return
+	}
+	voted, err := h.svc.Voted(c.Request.Context(), userID, []model.LyricRequest{*request})
+	if err != nil {
+		handleLyricRequestError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"request": toLyricRequestResponse(request, voted[request.ID]),
+		"draft":   toDraftResponse(draft),
+	})
+}
+
+// 对单个请求执行操作并返回最新状态
+func (h *LyricRequestHandler) update(c *gin.Context, action func(ctx context.Context, userID, id uint) (*model.LyricRequest, error)) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	request, err := action(c.Request.Context(), userID, id)
+	if err != nil {
+		handleLyricRequestError(c, err)
+		return
+	}
+	h.respond(c, userID, request)
+}
+
+func (h *LyricRequestHandler) respond(c *gin.Context, userID uint, request *model.LyricRequest) {
+	voted, err := h.svc.Voted(c.Request.Context(), userID, []model.LyricRequest{*request})
+	if err != nil {
+		handleLyricRequestError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"request": toLyricRequestResponse(request, voted[request.ID])})
+}
+
+// 重复请求返回 409 并附带已有的请求
+func (h *LyricRequestHandler) handleError(c *gin.Context, userID uint, err error) {
+	var duplicate *service.DuplicateLyricRequestError
+	if !errors.As(err, &duplicate) {
+		handleLyricRequestError(c, err)
+		return
+	}
+	voted, err := h.svc.Voted(c.Request.Context(), userID, []model.LyricRequest{*duplicate.Existing})
+	if err != nil {
+		handleLyricRequestError(c, err)
+		return
+	}
+	c.JSON(http.StatusConflict, gin.H{
+		"error":   duplicate.Error(),
+		"request": toLyricRequestResponse(duplicate.Existing, voted[duplicate.Existing.ID]),
+	})
+}
+
+func toLyricRequestResponse(request *model.LyricRequest, voted bool) lyricRequestResponse {
+	return lyricRequestResponse{
+		ID:              request.ID,
+		Title:           request.Title,
+		Artists:         service.DecodeArtists(request.Artists),
+		Album:           request.Album,
+		Links:           service.DecodeLinks(request.Links),
+		RequesterUserID: request.RequesterUserID,
+		Status:          request.Status,
+		Votes:           request.Votes,
+		Voted:           voted,
+		ClaimedBy:       request.ClaimedBy,
+		ClaimedAt:       formatOptionalTime(request.ClaimedAt),
+		DraftID:         request.DraftID,
+		ClosedAt:        formatOptionalTime(request.ClosedAt),
+		CreatedAt:       request.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func handleLyricRequestError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidInput):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input"})
+	case errors.Is(err, service.ErrLyricRequestForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrLyricRequestDuplicate), errors.Is(err, service.ErrLyricRequestNotOpen), errors.Is(err, service.ErrLyricRequestNotClaimed):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrLyricRequestNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
@@ -3,6 +3,7 @@ package handler
 import (
 	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/xiaowumin-mark/AMLX/service"
@@ -18,10 +19,16 @@ func NewPermissionHandler(svc service.PermissionService) *PermissionHandler {
 
 func (h *PermissionHandler) Register(rg *gin.RouterGroup) {
 	group := rg.Group("/permissions")
+	group.GET("", h.listPermissions)
 	group.POST("", h.createPermission)
+	group.PATCH("/:id", h.updatePermission)
+	group.DELETE("/:id", h.deletePermission)
 
 	roleGroup := rg.Group("/roles")
+	roleGroup.GET("", h.listRoles)
 	roleGroup.POST("", h.createRole)
+	roleGroup.PATCH("/:id", h.updateRole)
+	roleGroup.DELETE("/:id", h.deleteRole)
 	roleGroup.GET("/:id/permissions", h.listRolePermissions)
 	roleGroup.POST("/:id/permissions", h.addPermissionToRole)
 	roleGroup.DELETE("/:id/permissions/:perm_id", h.removePermissionFromRole)
@@ -37,6 +44,16 @@ type createRoleRequest struct {
 	Description string `json:"description"`
 }
 
+type updatePermissionRequest struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+}
+
+type updateRoleRequest struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+}
+
 type addPermissionRequest struct {
 	PermissionID uint `json:"permission_id"`
 }
@@ -69,6 +86,101 @@ func (h *PermissionHandler) createRole(c *gin.Context) {
 	c.JSON(http.StatusCreated, gin.H{"role": role})
 }
 
+func (h *PermissionHandler) listPermissions(c *gin.Context) {
+	perms, err := h.svc.ListPermissions(c.Request.Context())
+	if err != nil {
+		handlePermissionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"permissions": perms})
+}
+
+func (h *PermissionHandler) listRoles(c *gin.Context) {
+	roles, err := h.svc.ListRoles(c.Request.Context())
+	if err != nil {
+		handlePermissionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"roles": roles})
+}
+
+func (h *PermissionHandler) updatePermission(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	var req updatePermissionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json"})
+		return
+	}
+	perm, err := h.svc.UpdatePermission(c.Request.Context(), id, service.UpdatePermissionRequest{
+		Name:        req.Name,
+		Description: req.Description,
+	})
+	if err != nil {
+		handlePermissionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"permission": perm})
+}
+
+func (h *PermissionHandler) updateRole(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	var req updateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json"})
+		return
+	}
+	role, err := h.svc.UpdateRole(c.Request.Context(), id, service.UpdateRoleRequest{
+		Name:        req.Name,
+		Description: req.Description,
+	})
+	if err != nil {
+		handlePermissionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"role": role})
+}
+
+func (h *PermissionHandler) deletePermission(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	if err := h.svc.DeletePermission(c.Request.Context(), id); err != nil {
+		handlePermissionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"ok": true})
+}
+
+// 仍有用户持有该角色时需通过 ?reassign_to= 指定转移到的角色
+func (h *PermissionHandler) deleteRole(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	reassignTo, err := strconv.ParseUint(c.DefaultQuery("reassign_to", "0"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid reassign_to"})
+		return
+	}
+	moved, err := h.svc.DeleteRole(c.Request.Context(), id, uint(reassignTo))
+	if err != nil {
+		handlePermissionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"ok": true, "reassigned_users": moved})
+}
+
 func (h *PermissionHandler) addPermissionToRole(c *gin.Context) {
 	roleID, err := parseUintParam(c, "id")
 	if err != nil {
@@ -123,7 +235,9 @@ func handlePermissionError(c *gin.Context, err error) {
 	switch {
 	case errors.Is(err, service.ErrInvalidInput):
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input"})
-	case errors.Is(err, service.ErrRoleExists), errors.Is(err, service.ErrPermissionExists):
+	case errors.Is(err, service.ErrRoleExists), errors.Is(err, service.ErrPermissionExists),
+		errors.Is(err, service.ErrRoleInUse), errors.Is(err, service.ErrBuiltinRole), errors.Is(err, service.ErrBuiltinPermission),
+		errors.Is(err, service.ErrDefaultRole):
 		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
 	case errors.Is(err, service.ErrRoleNotFound), errors.Is(err, service.ErrPermissionNotFound):
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaowumin-mark/AMLX/service"
+)
+
+// webhook 请求体上限
+const maxWebhookBody = 5 << 20
+
+type PullRequestHandler struct {
+	svc service.PullRequestService
+}
+
+func NewPullRequestHandler(svc service.PullRequestService) *PullRequestHandler {
+	return &PullRequestHandler{svc: svc}
+}
+
+// 管理接口
+func (h *PullRequestHandler) Register(rg *gin.RouterGroup) {
+	group := rg.Group("/pull-requests")
+	group.POST("/refresh", h.refresh)
+	group.POST("/drafts/:id", h.open)
+}
+
+// GitHub webhook，以签名认证，无需登录
+func (h *PullRequestHandler) RegisterWebhook(rg *gin.RouterGroup) {
+	rg.POST("/webhooks/github", h.webhook)
+}
+
+func (h *PullRequestHandler) refresh(c *gin.Context) {
+	changed, err := h.svc.Refresh(c.Request.Context())
+	if err != nil {
+		handlePullRequestError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"changed": changed})
+}
+
+func (h *PullRequestHandler) open(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	draft, err := h.svc.OpenByID(c.Request.Context(), id)
+	if err != nil {
+		handlePullRequestError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"draft": toDraftResponse(draft)})
+}
+
+func (h *PullRequestHandler) webhook(c *gin.Context) {
+	payload, err := io.ReadAll(io.LimitReader(c.Request.Body, maxWebhookBody))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	err = h.svc.HandleWebhook(c.Request.Context(), c.GetHeader("X-GitHub-Event"), c.GetHeader("X-Hub-Signature-256"), payload)
+	if err != nil {
+		handlePullRequestError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func handlePullRequestError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidInput):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input"})
+	case errors.Is(err, service.ErrPullRequestDisabled):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrWebhookSignature):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrNotGithubTarget), errors.Is(err, service.ErrPullRequestExists), errors.Is(err, service.ErrInvalidDraftState):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrDraftNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
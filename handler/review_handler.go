@@ -0,0 +1,270 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaowumin-mark/AMLX/model"
+	"github.com/xiaowumin-mark/AMLX/service"
+)
+
+type ReviewHandler struct {
+	svc service.ReviewService
+}
+
+func NewReviewHandler(svc service.ReviewService) *ReviewHandler {
+	return &ReviewHandler{svc: svc}
+}
+
+func (h *ReviewHandler) Register(rg *gin.RouterGroup) {
+	group := rg.Group("/reviews/drafts")
+	group.GET("", h.listPending)
+	group.GET("/:id", h.getDraft)
+	group.GET("/:id/reviews", h.listReviews)
+	group.GET("/:id/snapshot", h.getSnapshot)
+	group.GET("/:id/versions", h.listVersions)
+	group.GET("/:id/versions/:version_id", h.getVersion)
+	group.GET("/:id/versions/:version_id/export", h.exportVersion)
+	group.GET("/:id/diff", h.diffVersions)
+	group.GET("/:id/patch", h.patchVersions)
+	group.POST("/:id/approve", h.approve)
+	group.POST("/:id/reject", h.reject)
+}
+
+type rejectDraftRequest struct {
+	Reason  string `json:"reason"`
+	ToStage string `json:"to_stage"`
+}
+
+type reviewResponse struct {
+	ID             uint                 `json:"id"`
+	DraftID        uint                 `json:"draft_id"`
+	ReviewerUserID uint                 `json:"reviewer_user_id"`
+	Result         string               `json:"result"`
+	RejectReason   string               `json:"reject_reason"`
+	RejectToStage  *model.WorkflowStage `json:"reject_to_stage"`
+	CreatedAt      string               `json:"created_at"`
+}
+
+func (h *ReviewHandler) listPending(c *gin.Context) {
+	page, pageSize, err := parsePageQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid pagination"})
+		return
+	}
+
+	drafts, total, err := h.svc.ListPending(c.Request.Context(), page, pageSize)
+	if err != nil {
+		handleDraftError(c, err)
+		return
+	}
+	items := make([]draftResponse, 0, len(drafts))
+	for i := range drafts {
+		items = append(items, toDraftResponse(&drafts[i]))
+	}
+	c.JSON(http.StatusOK, gin.H{"drafts": items, "total": total})
+}
+
+func (h *ReviewHandler) getDraft(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	draft, err := h.svc.GetDraft(c.Request.Context(), id)
+	if err != nil {
+		handleDraftError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"draft": toDraftResponse(draft)})
+}
+
+func (h *ReviewHandler) listReviews(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	reviews, err := h.svc.ListReviews(c.Request.Context(), id)
+	if err != nil {
+		handleDraftError(c, err)
+		return
+	}
+	items := make([]reviewResponse, 0, len(reviews))
+	for i := range reviews {
+		items = append(items, toReviewResponse(&reviews[i]))
+	}
+	c.JSON(http.StatusOK, gin.H{"reviews": items})
+}
+
+func (h *ReviewHandler) getSnapshot(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	snapshot, err := h.svc.GetSnapshot(c.Request.Context(), id)
+	if err != nil {
+		handleDraftError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"version": toVersionResponse(snapshot)})
+}
+
+func (h *ReviewHandler) listVersions(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	versions, err := h.svc.ListVersions(c.Request.Context(), id)
+	if err != nil {
+		handleDraftError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"versions": toVersionResponses(versions)})
+}
+
+func (h *ReviewHandler) getVersion(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	versionID, err := parseUintParam(c, "version_id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid version_id"})
+		return
+	}
+
+	version, err := h.svc.GetVersion(c.Request.Context(), id, versionID)
+	if err != nil {
+		handleDraftError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"version": toVersionResponse(version)})
+}
+
+func (h *ReviewHandler) exportVersion(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	versionID, err := parseUintParam(c, "version_id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid version_id"})
+		return
+	}
+
+	exported, err := h.svc.ExportVersion(c.Request.Context(), id, versionID, c.DefaultQuery("format", service.FormatLRC))
+	if err != nil {
+		handleDraftError(c, err)
+		return
+	}
+	writeExport(c, id, versionID, exported)
+}
+
+func (h *ReviewHandler) patchVersions(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	fromID, toID, err := parseDiffQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from and to are required"})
+		return
+	}
+
+	p, err := h.svc.PatchVersions(c.Request.Context(), id, fromID, toID)
+	if err != nil {
+		handleDraftError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"patch": p, "summary": p.Summary()})
+}
+
+func (h *ReviewHandler) diffVersions(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	fromID, toID, err := parseDiffQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from and to are required"})
+		return
+	}
+
+	diff, err := h.svc.DiffVersions(c.Request.Context(), id, fromID, toID)
+	if err != nil {
+		handleDraftError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"diff": diff})
+}
+
+func (h *ReviewHandler) approve(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	draft, err := h.svc.Approve(c.Request.Context(), userID, id)
+	if err != nil {
+		handleDraftError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"draft": toDraftResponse(draft)})
+}
+
+func (h *ReviewHandler) reject(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	var req rejectDraftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json"})
+		return
+	}
+
+	draft, err := h.svc.Reject(c.Request.Context(), userID, id, service.RejectDraftRequest{
+		Reason:  req.Reason,
+		ToStage: model.WorkflowStage(strings.ToUpper(strings.TrimSpace(req.ToStage))),
+	})
+	if err != nil {
+		handleDraftError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"draft": toDraftResponse(draft)})
+}
+
+func toReviewResponse(review *model.LyricsReview) reviewResponse {
+	return reviewResponse{
+		ID:             review.ID,
+		DraftID:        review.DraftID,
+		ReviewerUserID: review.ReviewerUserID,
+		Result:         review.Result,
+		RejectReason:   review.RejectReason,
+		RejectToStage:  review.RejectToStage,
+		CreatedAt:      review.CreatedAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaowumin-mark/AMLX/model"
+	"github.com/xiaowumin-mark/AMLX/service"
+)
+
+type RollbackHandler struct {
+	svc service.RollbackService
+}
+
+func NewRollbackHandler(svc service.RollbackService) *RollbackHandler {
+	return &RollbackHandler{svc: svc}
+}
+
+func (h *RollbackHandler) Register(rg *gin.RouterGroup) {
+	group := rg.Group("/drafts/:id/rollbacks")
+	group.POST("", h.request)
+	group.GET("", h.list)
+	group.POST("/:rollback_id/approve", h.approve)
+	group.POST("/:rollback_id/deny", h.deny)
+}
+
+type requestRollbackRequest struct {
+	ToStage string `json:"to_stage"`
+	Mode    string `json:"mode"`
+	Reason  string `json:"reason"`
+}
+
+type rollbackResponse struct {
+	ID           uint                 `json:"id"`
+	DraftID      uint                 `json:"draft_id"`
+	FromStage    model.WorkflowStage  `json:"from_stage"`
+	ToStage      model.WorkflowStage  `json:"to_stage"`
+	RequestedBy  uint                 `json:"requested_by"`
+	ApprovedBy   uint                 `json:"approved_by"`
+	RollbackMode model.RollbackMode   `json:"mode"`
+	Reason       string               `json:"reason"`
+	Status       model.RollbackStatus `json:"status"`
+	DecidedAt    *string              `json:"decided_at"`
+	CreatedAt    string               `json:"created_at"`
+}
+
+func (h *RollbackHandler) request(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	draftID, err := parseUintParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	var req requestRollbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json"})
+		return
+	}
+
+	rollback, err := h.svc.Request(c.Request.Context(), userID, draftID, service.RequestRollbackRequest{
+		ToStage: model.WorkflowStage(strings.ToUpper(strings.TrimSpace(req.ToStage))),
+		Mode:    model.RollbackMode(strings.ToUpper(strings.TrimSpace(req.Mode))),
+		Reason:  req.Reason,
+	})
+	if err != nil {
+		handleDraftError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"rollback": toRollbackResponse(rollback)})
+}
+
+func (h *RollbackHandler) list(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	draftID, err := parseUintParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	rollbacks, err := h.svc.List(c.Request.Context(), userID, draftID)
+	if err != nil {
+		handleDraftError(c, err)
+		return
+	}
+	items := make([]rollbackResponse, 0, len(rollbacks))
+	for i := range rollbacks {
+		items = append(items, toRollbackResponse(&rollbacks[i]))
+	}
+	c.JSON(http.StatusOK, gin.H{"rollbacks": items})
+}
+
+func (h *RollbackHandler) approve(c *gin.Context) {
+	h.decide(c, h.svc.Approve)
+}
+
+func (h *RollbackHandler) deny(c *gin.Context) {
+	h.decide(c, h.svc.Deny)
+}
+
+func (h *RollbackHandler) decide(c *gin.Context, fn func(ctx context.Context, userID, draftID, rollbackID uint) (*model.StageRollback, error)) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	draftID, err := parseUintParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	rollbackID, err := parseUintParam(c, "rollback_id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid rollback_id"})
+		return
+	}
+
+	rollback, err := fn(c.Request.Context(), userID, draftID, rollbackID)
+	if err != nil {
+		handleDraftError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"rollback": toRollbackResponse(rollback)})
+}
+
+func toRollbackResponse(rollback *model.StageRollback) rollbackResponse {
+	return rollbackResponse{
+		ID:           rollback.ID,
+		DraftID:      rollback.DraftID,
+		FromStage:    rollback.FromStage,
+		ToStage:      rollback.ToStage,
+		RequestedBy:  rollback.RequestedBy,
+		ApprovedBy:   rollback.ApprovedBy,
+		RollbackMode: rollback.RollbackMode,
+		Reason:       rollback.Reason,
+		Status:       rollback.Status,
+		DecidedAt:    formatOptionalTime(rollback.DecidedAt),
+		CreatedAt:    rollback.CreatedAt.Format(time.RFC3339),
+	}
+}
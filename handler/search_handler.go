@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaowumin-mark/AMLX/service"
+)
+
+type SearchHandler struct {
+	svc service.SearchService
+}
+
+func NewSearchHandler(svc service.SearchService) *SearchHandler {
+	return &SearchHandler{svc: svc}
+}
+
+func (h *SearchHandler) Register(rg *gin.RouterGroup) {
+	rg.GET("/search", h.search)
+}
+
+func (h *SearchHandler) search(c *gin.Context) {
+	page, pageSize, err := parsePageQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid pagination"})
+		return
+	}
+
+	result, err := h.svc.Search(c.Request.Context(), service.SearchRequest{
+		Query:    c.Query("q"),
+		Language: c.Query("language"),
+		Artist:   c.Query("artist"),
+		Page:     page,
+		PageSize: pageSize,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"hits": result.Hits, "total": result.Total, "facets": result.Facets})
+}
@@ -0,0 +1,226 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaowumin-mark/AMLX/model"
+	"github.com/xiaowumin-mark/AMLX/service"
+)
+
+type SongHandler struct {
+	svc service.SongService
+}
+
+func NewSongHandler(svc service.SongService) *SongHandler {
+	return &SongHandler{svc: svc}
+}
+
+func (h *SongHandler) Register(rg *gin.RouterGroup) {
+	group := rg.Group("/songs")
+	group.GET("", h.list)
+	group.GET("/lookup", h.lookup)
+	group.GET("/:id", h.get)
+	group.GET("/:id/drafts", h.listDrafts)
+}
+
+// 管理接口
+func (h *SongHandler) RegisterAdmin(rg *gin.RouterGroup) {
+	rg.POST("/songs", h.create)
+	rg.PATCH("/songs/:id", h.update)
+}
+
+type songArtistRequest struct {
+	Name string           `json:"name"`
+	Role model.ArtistRole `json:"role"`
+}
+
+type createSongRequest struct {
+	Title       string               `json:"title"`
+	Album       string               `json:"album"`
+	Language    string               `json:"language"`
+	Artists     []songArtistRequest  `json:"artists"`
+	ExternalIDs []service.ExternalID `json:"external_ids"`
+}
+
+type updateSongRequest struct {
+	Title       *string               `json:"title"`
+	Album       *string               `json:"album"`
+	Language    *string               `json:"language"`
+	Artists     *[]songArtistRequest  `json:"artists"`
+	ExternalIDs *[]service.ExternalID `json:"external_ids"`
+}
+
+type songResponse struct {
+	ID          uint                     `json:"id"`
+	Title       string                   `json:"title"`
+	AlbumID     *uint                    `json:"album_id"`
+	Album       string                   `json:"album"`
+	Language    string                   `json:"language"`
+	Artists     []service.SongArtistInfo `json:"artists"`
+	ExternalIDs []service.ExternalID     `json:"external_ids"`
+	CreatedAt   string                   `json:"created_at"`
+	UpdatedAt   string                   `json:"updated_at"`
+}
+
+func (h *SongHandler) create(c *gin.Context) {
+	var req createSongRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json"})
+		return
+	}
+
+	song, err := h.svc.Create(c.Request.Context(), service.CreateSongRequest{
+		Title:       req.Title,
+		Album:       req.Album,
+		Language:    req.Language,
+		Artists:     toSongArtistInputs(req.Artists),
+		ExternalIDs: req.ExternalIDs,
+	})
+	if err != nil {
+		handleSongError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"song": toSongResponse(song)})
+}
+
+func (h *SongHandler) update(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	var req updateSongRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json"})
+		return
+	}
+	var artists *[]service.SongArtistInput
+	if req.Artists != nil {
+		inputs := toSongArtistInputs(*req.Artists)
+		artists = &inputs
+	}
+
+	song, err := h.svc.Update(c.Request.Context(), id, service.UpdateSongRequest{
+		Title:       req.Title,
+		Album:       req.Album,
+		Language:    req.Language,
+		Artists:     artists,
+		ExternalIDs: req.ExternalIDs,
+	})
+	if err != nil {
+		handleSongError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"song": toSongResponse(song)})
+}
+
+func (h *SongHandler) list(c *gin.Context) {
+	page, pageSize, err := parsePageQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid pagination"})
+		return
+	}
+
+	songs, total, err := h.svc.List(c.Request.Context(), c.Query("q"), page, pageSize)
+	if err != nil {
+		handleSongError(c, err)
+		return
+	}
+	items := make([]songResponse, 0, len(songs))
+	for i := range songs {
+		items = append(items, toSongResponse(&songs[i]))
+	}
+	c.JSON(http.StatusOK, gin.H{"songs": items, "total": total})
+}
+
+func (h *SongHandler) get(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	song, err := h.svc.Get(c.Request.Context(), id)
+	if err != nil {
+		handleSongError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"song": toSongResponse(song)})
+}
+
+// 按平台 ID 查找，如 ?platform=ncm&id=123
+func (h *SongHandler) lookup(c *gin.Context) {
+	song, err := h.svc.Lookup(c.Request.Context(), c.Query("platform"), c.Query("id"))
+	if err != nil {
+		handleSongError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"song": toSongResponse(song)})
+}
+
+func (h *SongHandler) listDrafts(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	page, pageSize, err := parsePageQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid pagination"})
+		return
+	}
+
+	drafts, total, err := h.svc.ListDrafts(c.Request.Context(), id, page, pageSize)
+	if err != nil {
+		handleSongError(c, err)
+		return
+	}
+	items := make([]draftResponse, 0, len(drafts))
+	for i := range drafts {
+		items = append(items, toDraftResponse(&drafts[i]))
+	}
+	c.JSON(http.StatusOK, gin.H{"drafts": items, "total": total})
+}
+
+func toSongArtistInputs(artists []songArtistRequest) []service.SongArtistInput {
+	inputs := make([]service.SongArtistInput, 0, len(artists))
+	for _, artist := range artists {
+		inputs = append(inputs, service.SongArtistInput{Name: artist.Name, Role: artist.Role})
+	}
+	return inputs
+}
+
+func toSongResponse(detail *service.SongDetail) songResponse {
+	resp := songResponse{
+		ID:          detail.Song.ID,
+		Title:       detail.Song.Title,
+		AlbumID:     detail.Song.AlbumID,
+		Language:    detail.Song.Language,
+		Artists:     detail.Artists,
+		ExternalIDs: detail.ExternalIDs,
+		CreatedAt:   detail.Song.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:   detail.Song.UpdatedAt.Format(time.RFC3339),
+	}
+	if detail.Album != nil {
+		resp.Album = detail.Album.Title
+	}
+	return resp
+}
+
+func handleSongError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidInput):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input"})
+	case errors.Is(err, service.ErrUnknownPlatform):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrExternalIDTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrSongNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
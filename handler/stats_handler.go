@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaowumin-mark/AMLX/service"
+)
+
+type StatsHandler struct {
+	svc service.StatsService
+}
+
+func NewStatsHandler(svc service.StatsService) *StatsHandler {
+	return &StatsHandler{svc: svc}
+}
+
+func (h *StatsHandler) Register(rg *gin.RouterGroup) {
+	rg.GET("/users/:id/stats", h.user)
+	rg.GET("/stats/leaderboard", h.leaderboard)
+}
+
+func (h *StatsHandler) user(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	window := c.DefaultQuery("window", "30d")
+
+	stats, err := h.svc.User(c.Request.Context(), id, window)
+	if err != nil {
+		handleStatsError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"window": window, "stats": stats})
+}
+
+func (h *StatsHandler) leaderboard(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+		return
+	}
+	window := c.DefaultQuery("window", "30d")
+	metric := c.DefaultQuery("metric", "approved")
+
+	entries, err := h.svc.Leaderboard(c.Request.Context(), service.LeaderboardRequest{
+		Window: window,
+		Metric: metric,
+		Limit:  limit,
+	})
+	if err != nil {
+		handleStatsError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"window": window, "metric": metric, "entries": entries})
+}
+
+func handleStatsError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidInput):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input"})
+	case errors.Is(err, service.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaowumin-mark/AMLX/service"
+	"github.com/xiaowumin-mark/AMLX/ttmldb"
+)
+
+type SyncHandler struct {
+	svc service.SyncService
+}
+
+func NewSyncHandler(svc service.SyncService) *SyncHandler {
+	return &SyncHandler{svc: svc}
+}
+
+func (h *SyncHandler) Register(rg *gin.RouterGroup) {
+	group := rg.Group("/sync")
+	group.POST("/import", h.importRepo)
+	group.POST("/export", h.exportRepo)
+}
+
+func (h *SyncHandler) importRepo(c *gin.Context) {
+	h.run(c, h.svc.Import)
+}
+
+func (h *SyncHandler) exportRepo(c *gin.Context) {
+	h.run(c, h.svc.Export)
+}
+
+func (h *SyncHandler) run(c *gin.Context, sync func(ctx context.Context, dryRun bool) (*service.SyncReport, error)) {
+	dryRun := false
+	if value := c.Query("dry_run"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid dry_run"})
+			return
+		}
+		dryRun = parsed
+	}
+
+	report, err := sync(c.Request.Context(), dryRun)
+	if err != nil {
+		handleSyncError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"report": report})
+}
+
+func handleSyncError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrSyncDisabled):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrSyncBranchNotFound), errors.Is(err, ttmldb.ErrBranchMoved):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaowumin-mark/AMLX/service"
+)
+
+type TimingReportHandler struct {
+	svc service.TimingReportService
+}
+
+func NewTimingReportHandler(svc service.TimingReportService) *TimingReportHandler {
+	return &TimingReportHandler{svc: svc}
+}
+
+func (h *TimingReportHandler) Register(rg *gin.RouterGroup) {
+	group := rg.Group("/versions/:version_id/timing-reports")
+	group.POST("", h.report)
+	group.GET("", h.summary)
+}
+
+type timingReportRequest struct {
+	OffsetMs int    `json:"offset_ms"`
+	Line     uint   `json:"line"`
+	Comment  string `json:"comment"`
+}
+
+func (h *TimingReportHandler) report(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	versionID, err := parseUintParam(c, "version_id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid version_id"})
+		return
+	}
+	var req timingReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json"})
+		return
+	}
+
+	summary, err := h.svc.Report(c.Request.Context(), userID, versionID, service.TimingReportRequest{
+		OffsetMs: req.OffsetMs,
+		Line:     req.Line,
+		Comment:  req.Comment,
+	})
+	if err != nil {
+		handleTimingReportError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"summary": summary})
+}
+
+func (h *TimingReportHandler) summary(c *gin.Context) {
+	versionID, err := parseUintParam(c, "version_id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid version_id"})
+		return
+	}
+
+	summary, err := h.svc.Summary(c.Request.Context(), versionID)
+	if err != nil {
+		handleTimingReportError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"summary": summary})
+}
+
+func handleTimingReportError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidInput):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input"})
+	case errors.Is(err, service.ErrVersionNotFound), errors.Is(err, service.ErrVersionNotApproved):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
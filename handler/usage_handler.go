@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaowumin-mark/AMLX/service"
+)
+
+type UsageHandler struct {
+	svc service.UsageService
+}
+
+func NewUsageHandler(svc service.UsageService) *UsageHandler {
+	return &UsageHandler{svc: svc}
+}
+
+// 事件上报，CDN 节点与客户端使用，无需登录
+func (h *UsageHandler) Register(rg *gin.RouterGroup) {
+	rg.POST("/usage/events", h.ingest)
+}
+
+// 管理接口
+func (h *UsageHandler) RegisterAdmin(rg *gin.RouterGroup) {
+	rg.GET("/usage/top-songs", h.topSongs)
+	rg.GET("/usage/missing", h.missing)
+}
+
+type ingestUsageRequest struct {
+	Events []service.UsageEventInput `json:"events"`
+}
+
+func (h *UsageHandler) ingest(c *gin.Context) {
+	var req ingestUsageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json"})
+		return
+	}
+
+	accepted, err := h.svc.Ingest(c.Request.Context(), req.Events)
+	if err != nil {
+		handleUsageError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"accepted": accepted, "rejected": len(req.Events) - accepted})
+}
+
+func (h *UsageHandler) topSongs(c *gin.Context) {
+	query, ok := parseUsageQuery(c)
+	if !ok {
+		return
+	}
+
+	songs, err := h.svc.TopSongs(c.Request.Context(), query)
+	if err != nil {
+		handleUsageError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"window": query.Window, "songs": songs})
+}
+
+func (h *UsageHandler) missing(c *gin.Context) {
+	query, ok := parseUsageQuery(c)
+	if !ok {
+		return
+	}
+
+	missing, err := h.svc.Missing(c.Request.Context(), query)
+	if err != nil {
+		handleUsageError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"window": query.Window, "missing": missing})
+}
+
+func parseUsageQuery(c *gin.Context) (service.UsageQuery, bool) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+		return service.UsageQuery{}, false
+	}
+	return service.UsageQuery{
+		Window: c.DefaultQuery("window", "7d"),
+		Type:   c.Query("type"),
+		Format: c.Query("format"),
+		Region: c.Query("region"),
+		Limit:  limit,
+	}, true
+}
+
+func handleUsageError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidInput):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
@@ -0,0 +1,278 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaowumin-mark/AMLX/lyrics/patch"
+	"github.com/xiaowumin-mark/AMLX/model"
+	"github.com/xiaowumin-mark/AMLX/service"
+)
+
+type VersionHandler struct {
+	svc service.VersionService
+}
+
+func NewVersionHandler(svc service.VersionService) *VersionHandler {
+	return &VersionHandler{svc: svc}
+}
+
+func (h *VersionHandler) Register(rg *gin.RouterGroup) {
+	group := rg.Group("/drafts/:id")
+	group.POST("/versions", h.save)
+	group.GET("/versions", h.list)
+	group.GET("/versions/:version_id", h.get)
+	group.POST("/versions/:version_id/restore", h.restore)
+	group.GET("/versions/:version_id/export", h.export)
+	group.GET("/versions/:version_id/pack", h.pack)
+	group.POST("/versions/:version_id/patch", h.applyPatch)
+	group.GET("/diff", h.diff)
+	group.GET("/patch", h.patch)
+}
+
+type saveVersionRequest struct {
+	Content string `json:"content"`
+}
+
+func (h *VersionHandler) save(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	draftID, err := parseUintParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	var req saveVersionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json"})
+		return
+	}
+
+	version, err := h.svc.Save(c.Request.Context(), userID, draftID, req.Content)
+	if err != nil {
+		handleDraftError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"version": toVersionResponse(version)})
+}
+
+func (h *VersionHandler) list(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	draftID, err := parseUintParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	versions, err := h.svc.List(c.Request.Context(), userID, draftID)
+	if err != nil {
+		handleDraftError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"versions": toVersionResponses(versions)})
+}
+
+func (h *VersionHandler) get(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	draftID, err := parseUintParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	versionID, err := parseUintParam(c, "version_id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid version_id"})
+		return
+	}
+
+	version, err := h.svc.Get(c.Request.Context(), userID, draftID, versionID)
+	if err != nil {
+		handleDraftError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"version": toVersionResponse(version)})
+}
+
+func (h *VersionHandler) restore(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	draftID, err := parseUintParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	versionID, err := parseUintParam(c, "version_id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid version_id"})
+		return
+	}
+
+	version, err := h.svc.Restore(c.Request.Context(), userID, draftID, versionID)
+	if err != nil {
+		handleDraftError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"version": toVersionResponse(version)})
+}
+
+func (h *VersionHandler) export(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	draftID, err := parseUintParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	versionID, err := parseUintParam(c, "version_id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid version_id"})
+		return
+	}
+
+	exported, err := h.svc.Export(c.Request.Context(), userID, draftID, versionID, c.DefaultQuery("format", service.FormatLRC))
+	if err != nil {
+		handleDraftError(c, err)
+		return
+	}
+	writeExport(c, draftID, versionID, exported)
+}
+
+func (h *VersionHandler) pack(c *gin.Context) {
+	draftID, err := parseUintParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	versionID, err := parseUintParam(c, "version_id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid version_id"})
+		return
+	}
+
+	data, err := h.svc.Pack(c.Request.Context(), draftID, versionID)
+	if err != nil {
+		handleDraftError(c, err)
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="draft-%d-v%d.amlp"`, draftID, versionID))
+	c.Data(http.StatusOK, "application/vnd.amlx.pack", data)
+}
+
+func (h *VersionHandler) diff(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	draftID, err := parseUintParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	fromID, toID, err := parseDiffQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from and to are required"})
+		return
+	}
+
+	diff, err := h.svc.Diff(c.Request.Context(), userID, draftID, fromID, toID)
+	if err != nil {
+		handleDraftError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"diff": diff})
+}
+
+func (h *VersionHandler) patch(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	draftID, err := parseUintParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	fromID, toID, err := parseDiffQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from and to are required"})
+		return
+	}
+
+	p, err := h.svc.Patch(c.Request.Context(), userID, draftID, fromID, toID)
+	if err != nil {
+		handleDraftError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"patch": p, "summary": p.Summary()})
+}
+
+func (h *VersionHandler) applyPatch(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	draftID, err := parseUintParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	versionID, err := parseUintParam(c, "version_id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid version_id"})
+		return
+	}
+	var req patch.Patch
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json"})
+		return
+	}
+
+	version, err := h.svc.ApplyPatch(c.Request.Context(), userID, draftID, versionID, &req)
+	if err != nil {
+		handleDraftError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"version": toVersionResponse(version)})
+}
+
+// 以附件形式返回导出的歌词
+func writeExport(c *gin.Context, draftID, versionID uint, exported *service.ExportedLyrics) {
+	filename := fmt.Sprintf("draft-%d-v%d.%s", draftID, versionID, exported.Extension)
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Data(http.StatusOK, exported.ContentType, exported.Content)
+}
+
+// 解析 from / to 查询参数
+func parseDiffQuery(c *gin.Context) (uint, uint, error) {
+	from, err := strconv.ParseUint(c.Query("from"), 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	to, err := strconv.ParseUint(c.Query("to"), 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	return uint(from), uint(to), nil
+}
+
+func toVersionResponses(versions []model.LyricsVersion) []versionResponse {
+	items := make([]versionResponse, 0, len(versions))
+	for i := range versions {
+		items = append(items, toVersionResponse(&versions[i]))
+	}
+	return items
+}
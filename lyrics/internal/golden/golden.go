@@ -0,0 +1,37 @@
+// Package golden 读取与比对歌词格式测试的 testdata 文件。
+//
+// 只有由转换结果生成的文件（如 TTML）可以用 -update 重写；抓包得到的输入文件只读。
+package golden
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files in testdata")
+
+// 读取 testdata 下的文件
+func Read(t testing.TB, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// 比对 got 与 testdata 下的 golden 文件，-update 时先重写
+func Check(t testing.TB, name string, got []byte) {
+	t.Helper()
+	if *update {
+		if err := os.WriteFile(filepath.Join("testdata", name), got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want := Read(t, name); !bytes.Equal(got, want) {
+		t.Fatalf("output differs from testdata/%s:\n%s", name, got)
+	}
+}
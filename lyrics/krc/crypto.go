@@ -0,0 +1,56 @@
+package krc
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var ErrNotKRC = errors.New("krc: missing krc1 header")
+
+// .krc 文件：4 字节魔数 + 按 16 字节密钥循环异或的 zlib 数据
+var (
+	magic  = []byte("krc1")
+	xorKey = []byte{0x40, 0x47, 0x61, 0x77, 0x5e, 0x32, 0x74, 0x47, 0x51, 0x36, 0x31, 0x2d, 0xce, 0xd2, 0x6e, 0x69}
+)
+
+// 解密 .krc 文件，返回 KRC 文本
+func Decrypt(data []byte) (string, error) {
+	if !bytes.HasPrefix(data, magic) {
+		return "", ErrNotKRC
+	}
+	payload := xor(data[len(magic):])
+	reader, err := zlib.NewReader(bytes.NewReader(payload))
+	if err != nil {
+		return "", fmt.Errorf("krc: %w", err)
+	}
+	defer reader.Close()
+	text, err := io.ReadAll(reader)
+	if err != nil {
+		return "", fmt.Errorf("krc: %w", err)
+	}
+	return string(bytes.TrimPrefix(text, []byte("\ufeff"))), nil
+}
+
+// 加密 KRC 文本为 .krc 文件
+func Encrypt(text string) ([]byte, error) {
+	var compressed bytes.Buffer
+	writer := zlib.NewWriter(&compressed)
+	if _, err := writer.Write([]byte(text)); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return append(append([]byte(nil), magic...), xor(compressed.Bytes())...), nil
+}
+
+func xor(data []byte) []byte {
+	out := make([]byte, len(data))
+	for i, b := range data {
+		out[i] = b ^ xorKey[i%len(xorKey)]
+	}
+	return out
+}
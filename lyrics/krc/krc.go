@@ -0,0 +1,228 @@
+// Package krc 在酷狗逐字歌词（KRC）与歌词 AST 之间转换。
+//
+// 行格式：[行开始,行时长]<字偏移,字时长,0>字<字偏移,字时长,0>字...，
+// 字偏移相对行开始。翻译与音译以 base64 JSON 存放在 [language:] 标签中。
+package krc
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/xiaowumin-mark/AMLX/lyrics"
+)
+
+var (
+	linePattern = regexp.MustCompile(`^\[(\d+),(\d+)\]`)
+	wordPattern = regexp.MustCompile(`<(\d+),(\d+),(\d+)>`)
+	tagPattern  = regexp.MustCompile(`^\[([A-Za-z]+):(.*)\]$`)
+)
+
+// [language:] 中的内容类型
+const (
+	languageRoman       = 0 // 逐字音译
+	languageTranslation = 1 // 逐行翻译
+)
+
+// 导入时使用的翻译语言，酷狗的翻译基本都是简体中文
+const TranslationLang = "zh-Hans"
+
+type languageTag struct {
+	Content []languageContent `json:"content"`
+	Version int               `json:"version"`
+}
+
+type languageContent struct {
+	Language     int        `json:"language"`
+	Type         int        `json:"type"`
+	LyricContent [][]string `json:"lyricContent"`
+}
+
+// 解析 KRC 文本
+func Parse(content string) (*lyrics.Document, error) {
+	doc := &lyrics.Document{Timing: lyrics.TimingWord}
+	var offset int64
+	var language *languageTag
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	number := 0
+	for scanner.Scan() {
+		number++
+		raw := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		if raw == "" {
+			continue
+		}
+		match := linePattern.FindStringSubmatch(raw)
+		if match == nil {
+			if tag := tagPattern.FindStringSubmatch(raw); tag != nil {
+				if strings.EqualFold(tag[1], "language") {
+					parsed, err := parseLanguage(strings.TrimSpace(tag[2]))
+					if err != nil {
+						return nil, fmt.Errorf("krc: line %d: %w", number, err)
+					}
+					language = parsed
+					continue
+				}
+				applyTag(doc, &offset, tag[1], strings.TrimSpace(tag[2]))
+			}
+			continue
+		}
+		begin, _ := strconv.ParseInt(match[1], 10, 64)
+		duration, _ := strconv.ParseInt(match[2], 10, 64)
+		line := lyrics.Line{Begin: begin, End: begin + duration}
+
+		rest := raw[len(match[0]):]
+		words := wordPattern.FindAllStringSubmatchIndex(rest, -1)
+		if len(words) == 0 {
+			return nil, fmt.Errorf("krc: line %d: no word timings", number)
+		}
+		for i, word := range words {
+			wordOffset, _ := strconv.ParseInt(rest[word[2]:word[3]], 10, 64)
+			wordDuration, _ := strconv.ParseInt(rest[word[4]:word[5]], 10, 64)
+			end := len(rest)
+			if i+1 < len(words) {
+				end = words[i+1][0]
+			}
+			wordBegin := begin + wordOffset
+			line.Syllables = append(line.Syllables, lyrics.NewSyllable(rest[word[1]:end], wordBegin, wordBegin+wordDuration))
+		}
+		shiftLine(&line, offset)
+		doc.Lines = append(doc.Lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("krc: %w", err)
+	}
+	if language != nil {
+		applyLanguage(doc, language)
+	}
+	if len(doc.Lines) == 0 {
+		doc.Timing = lyrics.TimingNone
+	}
+	return doc, nil
+}
+
+func parseLanguage(value string) (*languageTag, error) {
+	if value == "" {
+		return nil, nil
+	}
+	data, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid language tag: %w", err)
+	}
+	var tag languageTag
+	if err := json.Unmarshal(data, &tag); err != nil {
+		return nil, fmt.Errorf("invalid language tag: %w", err)
+	}
+	return &tag, nil
+}
+
+// 按行序号挂载翻译与音译
+func applyLanguage(doc *lyrics.Document, tag *languageTag) {
+	for _, content := range tag.Content {
+		for i, parts := range content.LyricContent {
+			if i >= len(doc.Lines) {
+				break
+			}
+			line := &doc.Lines[i]
+			switch content.Type {
+			case languageTranslation:
+				if text := strings.TrimSpace(strings.Join(parts, "")); text != "" {
+					line.Translations = append(line.Translations, lyrics.Annotation{Lang: TranslationLang, Text: text})
+				}
+			case languageRoman:
+				if text := strings.Join(strings.Fields(strings.Join(parts, " ")), " "); text != "" {
+					line.Romanizations = append(line.Romanizations, lyrics.Annotation{Text: text})
+				}
+			}
+		}
+	}
+}
+
+func applyTag(doc *lyrics.Document, offset *int64, key, value string) {
+	switch strings.ToLower(key) {
+	case "ti":
+		doc.SetMeta(lyrics.MetaMusicName, value)
+	case "ar":
+		doc.SetMeta(lyrics.MetaArtists, strings.Split(value, "、")...)
+	case "al":
+		doc.SetMeta(lyrics.MetaAlbum, value)
+	case "offset":
+		if parsed, err := strconv.ParseInt(strings.TrimPrefix(value, "+"), 10, 64); err == nil {
+			*offset = parsed
+		}
+	}
+}
+
+// 正的 offset 表示歌词提前显示
+func shiftLine(line *lyrics.Line, offset int64) {
+	if offset == 0 {
+		return
+	}
+	shift := func(t int64) int64 { return max(t-offset, 0) }
+	line.Begin, line.End = shift(line.Begin), shift(line.End)
+	for i := range line.Syllables {
+		line.Syllables[i].Begin = shift(line.Syllables[i].Begin)
+		line.Syllables[i].End = shift(line.Syllables[i].End)
+	}
+}
+
+// 生成 KRC 文本（未加密）
+func Marshal(doc *lyrics.Document) string {
+	var sb strings.Builder
+	writeTag(&sb, "ti", doc.MetaValue(lyrics.MetaMusicName))
+	writeTag(&sb, "ar", strings.Join(doc.MetaValues(lyrics.MetaArtists), "、"))
+	writeTag(&sb, "al", doc.MetaValue(lyrics.MetaAlbum))
+	sb.WriteString("[offset:0]\n")
+	if language := marshalLanguage(doc); language != "" {
+		sb.WriteString("[language:" + language + "]\n")
+	}
+	for _, line := range doc.Lines {
+		if len(line.Syllables) == 0 {
+			continue
+		}
+		fmt.Fprintf(&sb, "[%d,%d]", line.Begin, max(line.End-line.Begin, 0))
+		for _, syllable := range line.Syllables {
+			fmt.Fprintf(&sb, "<%d,%d,0>%s%s", max(syllable.Begin-line.Begin, 0), max(syllable.End-syllable.Begin, 0), syllable.Text, syllable.Trailing)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// 有翻译时生成 [language:] 标签内容
+func marshalLanguage(doc *lyrics.Document) string {
+	translations := make([][]string, 0, len(doc.Lines))
+	found := false
+	for _, line := range doc.Lines {
+		if len(line.Syllables) == 0 {
+			continue
+		}
+		text := ""
+		if annotation, ok := line.Translation(""); ok {
+			text, found = annotation.Text, true
+		}
+		translations = append(translations, []string{text})
+	}
+	if !found {
+		return ""
+	}
+	data, err := json.Marshal(languageTag{
+		Content: []languageContent{{Type: languageTranslation, LyricContent: translations}},
+		Version: 1,
+	})
+	if err != nil {
+		return ""
+	}
+	return base64.StdEncoding.EncodeToString(data)
+}
+
+func writeTag(sb *strings.Builder, key, value string) {
+	if value = strings.TrimSpace(value); value != "" {
+		sb.WriteString("[" + key + ":" + value + "]\n")
+	}
+}
@@ -0,0 +1,69 @@
+package krc
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/xiaowumin-mark/AMLX/lyrics/internal/golden"
+	"github.com/xiaowumin-mark/AMLX/lyrics/ttml"
+)
+
+// testdata/sample.krc.txt 为解密后的 KRC 文本，sample.krc 为对应的 .krc 文件，sample.ttml 为转换结果。
+// sample.krc 是固定文件，测试不会重写；它由本包生成而非客户端抓包，只能发现解密流程的回归
+func TestDecryptFixture(t *testing.T) {
+	plain := golden.Read(t, "sample.krc.txt")
+	text, err := Decrypt(golden.Read(t, "sample.krc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != string(plain) {
+		t.Fatalf("decrypted text differs from sample.krc.txt:\n%s", text)
+	}
+}
+
+func TestEncryptRoundTrip(t *testing.T) {
+	plain := string(golden.Read(t, "sample.krc.txt"))
+	data, err := Encrypt(plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, err := Decrypt(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != plain {
+		t.Fatalf("round trip differs from sample.krc.txt:\n%s", text)
+	}
+}
+
+func TestDecryptRejectsMissingHeader(t *testing.T) {
+	if _, err := Decrypt([]byte("krc2....")); err != ErrNotKRC {
+		t.Fatalf("got %v, want ErrNotKRC", err)
+	}
+}
+
+func TestParseGolden(t *testing.T) {
+	doc, err := Parse(string(golden.Read(t, "sample.krc.txt")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden.Check(t, "sample.ttml", ttml.Marshal(doc))
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	doc, err := Parse(string(golden.Read(t, "sample.krc.txt")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := Parse(Marshal(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 导出只写翻译，不写音译
+	for i := range doc.Lines {
+		doc.Lines[i].Romanizations = again.Lines[i].Romanizations
+	}
+	if !reflect.DeepEqual(again.Lines, doc.Lines) {
+		t.Fatalf("round trip changed the lines:\ngot  %+v\nwant %+v", again.Lines, doc.Lines)
+	}
+}
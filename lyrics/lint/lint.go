@@ -0,0 +1,199 @@
+// Package lint 按 amll-ttml-db 的约定检查 TTML 歌词。
+package lint
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/xiaowumin-mark/AMLX/lyrics"
+	"github.com/xiaowumin-mark/AMLX/lyrics/ttml"
+)
+
+type Severity string
+
+const (
+	SeverityError   Severity = "error"   // 阻止提交审核
+	SeverityWarning Severity = "warning" // 仅提示
+)
+
+// 规则 ID，对外稳定，不要修改已有取值
+const (
+	RuleParseError      = "AMLL001" // 无法解析为 TTML
+	RuleEmptyDocument   = "AMLL002" // 没有任何歌词行
+	RuleEndBeforeBegin  = "AMLL003" // 结束时间早于开始时间
+	RuleSyllableOverlap = "AMLL004" // 同一行内音节时间重叠
+	RuleLineOrder       = "AMLL005" // 歌词行开始时间不单调递增
+	RuleMissingMetadata = "AMLL006" // 缺少必填元数据
+	RuleMissingSourceID = "AMLL007" // 没有任何平台 ID
+	RuleUnknownAgent    = "AMLL008" // 引用了未声明的演唱者
+	RuleEmptySpan       = "AMLL009" // 空音节
+	RuleSpanWhitespace  = "AMLL010" // 音节首尾带空白，应放在 span 之间
+	RuleGapWhitespace   = "AMLL011" // span 之间的空白不是单个空格
+)
+
+// Finding 一条检查结果。Line / Syllable 从 1 开始，0 表示不针对具体行或音节
+type Finding struct {
+	Rule       string   `json:"rule"`
+	Severity   Severity `json:"severity"`
+	Message    string   `json:"message"`
+	Line       int      `json:"line,omitempty"`
+	Syllable   int      `json:"syllable,omitempty"`
+	Background bool     `json:"background,omitempty"` // 位于背景人声行
+	Time       *int64   `json:"time_ms,omitempty"`    // 相关时间点（毫秒）
+}
+
+// Report 检查报告
+type Report struct {
+	Findings []Finding `json:"findings"`
+	Errors   int       `json:"errors"`
+	Warnings int       `json:"warnings"`
+}
+
+// 是否存在错误级别的结果
+func (r *Report) HasErrors() bool {
+	return r.Errors > 0
+}
+
+func (r *Report) add(f Finding) {
+	r.Findings = append(r.Findings, f)
+	switch f.Severity {
+	case SeverityError:
+		r.Errors++
+	case SeverityWarning:
+		r.Warnings++
+	}
+}
+
+// 必填的 amll:meta 键
+var requiredMetadata = []string{lyrics.MetaMusicName, lyrics.MetaArtists}
+
+// 至少需要一个的平台 ID
+var sourceIDMetadata = []string{lyrics.MetaNCMMusicID, lyrics.MetaQQMusicID, lyrics.MetaSpotifyID, lyrics.MetaAppleMusicID}
+
+// 检查 TTML 文本
+func CheckTTML(content string) *Report {
+	if strings.TrimSpace(content) == "" {
+		report := &Report{Findings: []Finding{}}
+		report.add(Finding{Rule: RuleEmptyDocument, Severity: SeverityError, Message: "content is empty"})
+		return report
+	}
+	doc, err := ttml.ParseString(content)
+	if err != nil {
+		report := &Report{Findings: []Finding{}}
+		report.add(Finding{Rule: RuleParseError, Severity: SeverityError, Message: err.Error()})
+		return report
+	}
+	return Check(doc)
+}
+
+// 检查已解析的文档
+func Check(doc *lyrics.Document) *Report {
+	report := &Report{Findings: []Finding{}}
+	checkMetadata(doc, report)
+	if len(doc.Lines) == 0 {
+		report.add(Finding{Rule: RuleEmptyDocument, Severity: SeverityError, Message: "document has no lines"})
+		return report
+	}
+
+	agents := make(map[string]bool, len(doc.Agents))
+	for _, agent := range doc.Agents {
+		agents[agent.ID] = true
+	}
+	timed := doc.Timing != lyrics.TimingNone
+	for i := range doc.Lines {
+		line := &doc.Lines[i]
+		n := i + 1
+		if line.Agent != "" && !agents[line.Agent] {
+			report.add(Finding{
+				Rule:     RuleUnknownAgent,
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("agent %q is not declared in head", line.Agent),
+				Line:     n,
+			})
+		}
+		if timed && i > 0 && line.Begin < doc.Lines[i-1].Begin {
+			report.add(Finding{
+				Rule:     RuleLineOrder,
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("line begins at %s, before previous line at %s", ttml.FormatTime(line.Begin), ttml.FormatTime(doc.Lines[i-1].Begin)),
+				Line:     n,
+				Time:     ptr(line.Begin),
+			})
+		}
+		checkLine(line, n, false, timed, report)
+		if line.Background != nil {
+			checkLine(line.Background, n, true, timed, report)
+		}
+	}
+	return report
+}
+
+func checkMetadata(doc *lyrics.Document, report *Report) {
+	for _, key := range requiredMetadata {
+		if strings.TrimSpace(doc.MetaValue(key)) == "" {
+			report.add(Finding{
+				Rule:     RuleMissingMetadata,
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("missing metadata %q", key),
+			})
+		}
+	}
+	for _, key := range sourceIDMetadata {
+		if strings.TrimSpace(doc.MetaValue(key)) != "" {
+			return
+		}
+	}
+	report.add(Finding{
+		Rule:     RuleMissingSourceID,
+		Severity: SeverityWarning,
+		Message:  "no platform id metadata (" + strings.Join(sourceIDMetadata, ", ") + ")",
+	})
+}
+
+func checkLine(line *lyrics.Line, n int, background, timed bool, report *Report) {
+	if timed && line.End < line.Begin {
+		report.add(Finding{
+			Rule:       RuleEndBeforeBegin,
+			Severity:   SeverityError,
+			Message:    fmt.Sprintf("line ends at %s before it begins at %s", ttml.FormatTime(line.End), ttml.FormatTime(line.Begin)),
+			Line:       n,
+			Background: background,
+			Time:       ptr(line.Begin),
+		})
+	}
+	for j, syllable := range line.Syllables {
+		position := Finding{Line: n, Syllable: j + 1, Background: background, Time: ptr(syllable.Begin)}
+		if timed && syllable.End < syllable.Begin {
+			report.add(with(position, RuleEndBeforeBegin, SeverityError,
+				fmt.Sprintf("syllable ends at %s before it begins at %s", ttml.FormatTime(syllable.End), ttml.FormatTime(syllable.Begin))))
+		}
+		if timed && j > 0 && syllable.Begin < line.Syllables[j-1].End {
+			report.add(with(position, RuleSyllableOverlap, SeverityError,
+				fmt.Sprintf("syllable begins at %s, before previous syllable ends at %s", ttml.FormatTime(syllable.Begin), ttml.FormatTime(line.Syllables[j-1].End))))
+		}
+		if strings.TrimSpace(syllable.Text) == "" {
+			report.add(with(position, RuleEmptySpan, SeverityWarning, "syllable has no text"))
+			continue
+		}
+		if trimmed := strings.TrimFunc(syllable.Text, unicode.IsSpace); trimmed != syllable.Text {
+			report.add(with(position, RuleSpanWhitespace, SeverityWarning,
+				fmt.Sprintf("syllable %q has leading or trailing whitespace; put spaces between spans", syllable.Text)))
+		}
+		if syllable.Trailing != "" && syllable.Trailing != " " {
+			report.add(with(position, RuleGapWhitespace, SeverityWarning,
+				fmt.Sprintf("text between spans is %q, expected a single space", syllable.Trailing)))
+		}
+	}
+}
+
+func with(position Finding, rule string, severity Severity, message string) Finding {
+	position.Rule = rule
+	position.Severity = severity
+	position.Message = message
+	return position
+}
+
+func ptr(v int64) *int64 {
+	return &v
+}
@@ -0,0 +1,222 @@
+package lint
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/xiaowumin-mark/AMLX/lyrics"
+	"github.com/xiaowumin-mark/AMLX/lyrics/ttml"
+)
+
+// 一行逐字歌词，音节依次相接，每个 500ms
+func wordLine(begin int64, words ...string) lyrics.Line {
+	line := lyrics.Line{Agent: lyrics.AgentMain, Begin: begin}
+	at := begin
+	for i, word := range words {
+		syllable := lyrics.Syllable{Text: word, Begin: at, End: at + 500}
+		if i < len(words)-1 {
+			syllable.Trailing = " "
+		}
+		line.Syllables = append(line.Syllables, syllable)
+		at += 500
+	}
+	line.End = at
+	return line
+}
+
+// 没有任何问题的文档
+func cleanDocument() *lyrics.Document {
+	background := wordLine(5200, "ooh")
+	background.Agent = ""
+	doc := &lyrics.Document{
+		Language: "en",
+		Timing:   lyrics.TimingWord,
+		Metadata: []lyrics.Meta{
+			{Key: lyrics.MetaMusicName, Value: "Song"},
+			{Key: lyrics.MetaArtists, Value: "Artist"},
+			{Key: lyrics.MetaNCMMusicID, Value: "1"},
+		},
+		Agents: []lyrics.Agent{{ID: lyrics.AgentMain, Type: "person"}},
+		Lines: []lyrics.Line{
+			wordLine(0, "hello", "world"),
+			wordLine(5000, "second", "line"),
+		},
+	}
+	doc.Lines[1].Background = &background
+	return doc
+}
+
+// 只比较定位信息，不比较 Message
+type position struct {
+	Rule       string
+	Severity   Severity
+	Line       int
+	Syllable   int
+	Background bool
+	Time       int64 // -1 表示没有时间
+}
+
+func positions(report *Report) []position {
+	result := []position{}
+	for _, f := range report.Findings {
+		p := position{Rule: f.Rule, Severity: f.Severity, Line: f.Line, Syllable: f.Syllable, Background: f.Background, Time: -1}
+		if f.Time != nil {
+			p.Time = *f.Time
+		}
+		result = append(result, p)
+	}
+	return result
+}
+
+func TestCheckClean(t *testing.T) {
+	report := Check(cleanDocument())
+	if len(report.Findings) != 0 || report.Errors != 0 || report.Warnings != 0 || report.HasErrors() {
+		t.Fatalf("clean document: %+v", report)
+	}
+	// 经过 TTML 序列化后仍然没有问题
+	report = CheckTTML(ttml.MarshalString(cleanDocument()))
+	if len(report.Findings) != 0 {
+		t.Fatalf("clean TTML: %+v", report.Findings)
+	}
+}
+
+func TestCheckRules(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(doc *lyrics.Document)
+		want   []position
+	}{
+		{
+			name:   "AMLL002 no lines",
+			mutate: func(doc *lyrics.Document) { doc.Lines = nil },
+			want:   []position{{RuleEmptyDocument, SeverityError, 0, 0, false, -1}},
+		},
+		{
+			name:   "AMLL003 line ends before it begins",
+			mutate: func(doc *lyrics.Document) { doc.Lines[0].End = -1 },
+			want:   []position{{RuleEndBeforeBegin, SeverityError, 1, 0, false, 0}},
+		},
+		{
+			name: "AMLL003 syllable ends before it begins",
+			mutate: func(doc *lyrics.Document) {
+				doc.Lines[1].Syllables[1].End = 5400
+			},
+			want: []position{{RuleEndBeforeBegin, SeverityError, 2, 2, false, 5500}},
+		},
+		{
+			name: "AMLL004 syllables overlap",
+			mutate: func(doc *lyrics.Document) {
+				doc.Lines[0].Syllables[1].Begin = 400
+			},
+			want: []position{{RuleSyllableOverlap, SeverityError, 1, 2, false, 400}},
+		},
+		{
+			name: "AMLL004 in background line",
+			mutate: func(doc *lyrics.Document) {
+				bg := doc.Lines[1].Background
+				bg.Syllables = append(bg.Syllables, lyrics.Syllable{Text: "aah", Begin: 5500, End: 6000})
+			},
+			want: []position{{RuleSyllableOverlap, SeverityError, 2, 2, true, 5500}},
+		},
+		{
+			name: "AMLL005 lines out of order",
+			mutate: func(doc *lyrics.Document) {
+				doc.Lines = append(doc.Lines, wordLine(1000, "early"))
+			},
+			want: []position{{RuleLineOrder, SeverityError, 3, 0, false, 1000}},
+		},
+		{
+			name: "AMLL006 missing music name",
+			mutate: func(doc *lyrics.Document) {
+				doc.Metadata = doc.Metadata[1:]
+			},
+			want: []position{{RuleMissingMetadata, SeverityError, 0, 0, false, -1}},
+		},
+		{
+			name: "AMLL007 no platform id",
+			mutate: func(doc *lyrics.Document) {
+				doc.Metadata = doc.Metadata[:2]
+			},
+			want: []position{{RuleMissingSourceID, SeverityWarning, 0, 0, false, -1}},
+		},
+		{
+			name:   "AMLL008 undeclared agent",
+			mutate: func(doc *lyrics.Document) { doc.Lines[1].Agent = lyrics.AgentDuet },
+			want:   []position{{RuleUnknownAgent, SeverityError, 2, 0, false, -1}},
+		},
+		{
+			name:   "AMLL009 empty syllable",
+			mutate: func(doc *lyrics.Document) { doc.Lines[0].Syllables[0].Text = " " },
+			want:   []position{{RuleEmptySpan, SeverityWarning, 1, 1, false, 0}},
+		},
+		{
+			name:   "AMLL010 whitespace inside syllable",
+			mutate: func(doc *lyrics.Document) { doc.Lines[0].Syllables[1].Text = "world " },
+			want:   []position{{RuleSpanWhitespace, SeverityWarning, 1, 2, false, 500}},
+		},
+		{
+			name:   "AMLL011 gap is not a single space",
+			mutate: func(doc *lyrics.Document) { doc.Lines[1].Syllables[0].Trailing = "  " },
+			want:   []position{{RuleGapWhitespace, SeverityWarning, 2, 1, false, 5000}},
+		},
+		{
+			name: "untimed documents skip timing rules",
+			mutate: func(doc *lyrics.Document) {
+				doc.Timing = lyrics.TimingNone
+				doc.Lines[0].End = -1
+				doc.Lines[0].Syllables[1].Begin = 400
+				doc.Lines = append(doc.Lines, wordLine(1000, "early"))
+			},
+			want: []position{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := cleanDocument()
+			tt.mutate(doc)
+			report := Check(doc)
+			if got := positions(report); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("findings = %+v, want %+v", got, tt.want)
+			}
+			for _, f := range report.Findings {
+				if f.Message == "" {
+					t.Fatalf("finding %s has no message", f.Rule)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckTTML(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []position
+	}{
+		{"AMLL001 not TTML", "<html></html>", []position{{RuleParseError, SeverityError, 0, 0, false, -1}}},
+		{"AMLL001 malformed XML", "<tt><body>", []position{{RuleParseError, SeverityError, 0, 0, false, -1}}},
+		{"AMLL002 empty content", " \n", []position{{RuleEmptyDocument, SeverityError, 0, 0, false, -1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := CheckTTML(tt.content)
+			if got := positions(report); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("findings = %+v, want %+v", got, tt.want)
+			}
+			if report.Errors != 1 || !report.HasErrors() {
+				t.Fatalf("errors = %d", report.Errors)
+			}
+		})
+	}
+}
+
+func TestReportCounts(t *testing.T) {
+	doc := cleanDocument()
+	doc.Metadata = nil                        // AMLL006 x2 + AMLL007
+	doc.Lines[0].Syllables[0].Trailing = "\t" // AMLL011
+	doc.Lines[1].Agent = "v9"                 // AMLL008
+	report := Check(doc)
+	if report.Errors != 3 || report.Warnings != 2 {
+		t.Fatalf("errors = %d, warnings = %d: %+v", report.Errors, report.Warnings, positions(report))
+	}
+}
@@ -0,0 +1,285 @@
+// Package lrc 在 LRC / 增强 LRC 与歌词 AST 之间转换。
+package lrc
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/xiaowumin-mark/AMLX/lyrics"
+)
+
+var (
+	// [mm:ss.xx] / [mm:ss:xx] / [mm:ss]
+	lineTimePattern = regexp.MustCompile(`^\[(\d+):(\d{1,2})(?:[.:](\d{1,3}))?\]`)
+	// <mm:ss.xx>
+	wordTimePattern = regexp.MustCompile(`<(\d+):(\d{1,2})(?:[.:](\d{1,3}))?>`)
+	// [key:value]
+	tagPattern = regexp.MustCompile(`^\[([A-Za-z#]+):(.*)\]\s*$`)
+)
+
+// 最后一行没有后续行时的默认时长
+const lastLineDuration = 5000
+
+// 艺术家分隔符
+var artistSeparators = []string{"/", "、", ";", "；"}
+
+// 解析 LRC 或增强 LRC（<mm:ss.xx> 逐字时间）
+func Parse(content string) (*lyrics.Document, error) {
+	doc := &lyrics.Document{}
+	var offset int64
+	var lines []lyrics.Line
+	enhanced := false
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	number := 0
+	for scanner.Scan() {
+		number++
+		raw := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		if raw == "" {
+			continue
+		}
+
+		var times []int64
+		rest := raw
+		for {
+			match := lineTimePattern.FindStringSubmatch(rest)
+			if match == nil {
+				break
+			}
+			times = append(times, toMillis(match[1], match[2], match[3]))
+			rest = rest[len(match[0]):]
+		}
+		if len(times) == 0 {
+			if match := tagPattern.FindStringSubmatch(raw); match != nil {
+				if err := applyTag(doc, &offset, match[1], strings.TrimSpace(match[2])); err != nil {
+					return nil, fmt.Errorf("lrc: line %d: %w", number, err)
+				}
+			}
+			// 其它无时间戳的行（注释等）忽略
+			continue
+		}
+
+		syllables, isEnhanced := parseWords(rest)
+		enhanced = enhanced || isEnhanced
+		for _, begin := range times {
+			line := lyrics.Line{Begin: begin}
+			if isEnhanced {
+				line.Syllables = shiftSyllables(syllables, begin-times[0])
+			} else if text := strings.TrimSpace(rest); text != "" {
+				line.Syllables = []lyrics.Syllable{{Text: text, Begin: begin}}
+			}
+			lines = append(lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("lrc: %w", err)
+	}
+
+	// 一行多个时间戳时按时间重新排序
+	sort.SliceStable(lines, func(i, j int) bool { return lines[i].Begin < lines[j].Begin })
+	for i := range lines {
+		line := &lines[i]
+		next := line.Begin + lastLineDuration
+		if i+1 < len(lines) {
+			next = lines[i+1].Begin
+		}
+		finishLine(line, next)
+		applyOffset(line, offset)
+	}
+	// 去掉只用于结束上一行的空行
+	doc.Lines = make([]lyrics.Line, 0, len(lines))
+	for _, line := range lines {
+		if len(line.Syllables) > 0 {
+			doc.Lines = append(doc.Lines, line)
+		}
+	}
+
+	doc.Timing = lyrics.TimingLine
+	if enhanced {
+		doc.Timing = lyrics.TimingWord
+	}
+	if len(doc.Lines) == 0 {
+		doc.Timing = lyrics.TimingNone
+	}
+	return doc, nil
+}
+
+func applyTag(doc *lyrics.Document, offset *int64, key, value string) error {
+	switch strings.ToLower(key) {
+	case "ti":
+		doc.SetMeta(lyrics.MetaMusicName, value)
+	case "ar":
+		doc.SetMeta(lyrics.MetaArtists, SplitArtists(value)...)
+	case "al":
+		doc.SetMeta(lyrics.MetaAlbum, value)
+	case "offset":
+		if value == "" {
+			return nil
+		}
+		parsed, err := strconv.ParseInt(strings.TrimPrefix(value, "+"), 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid offset %q", value)
+		}
+		*offset = parsed
+	}
+	return nil
+}
+
+// 拆分 [ar:] 中的多个艺术家
+func SplitArtists(value string) []string {
+	for _, sep := range artistSeparators[1:] {
+		value = strings.ReplaceAll(value, sep, artistSeparators[0])
+	}
+	var artists []string
+	for _, artist := range strings.Split(value, artistSeparators[0]) {
+		if artist = strings.TrimSpace(artist); artist != "" {
+			artists = append(artists, artist)
+		}
+	}
+	return artists
+}
+
+// 解析增强 LRC 的逐字部分。每个 <time> 标记一个音节的开始，同时结束上一个音节；
+// 音节末尾的空格归入 Trailing。没有 <time> 标记时返回 false
+func parseWords(text string) ([]lyrics.Syllable, bool) {
+	matches := wordTimePattern.FindAllStringSubmatchIndex(text, -1)
+	if len(matches) == 0 {
+		return nil, false
+	}
+	var syllables []lyrics.Syllable
+	for i, match := range matches {
+		at := toMillis(text[match[2]:match[3]], text[match[4]:match[5]], submatch(text, match, 6))
+		if n := len(syllables); n > 0 && syllables[n-1].End == 0 {
+			syllables[n-1].End = at
+		}
+		end := len(text)
+		if i+1 < len(matches) {
+			end = matches[i+1][0]
+		}
+		word := text[match[1]:end]
+		trimmed := strings.TrimRight(word, " \t")
+		if strings.TrimSpace(trimmed) == "" {
+			// 只有结束时间的标记（通常位于行尾）
+			if n := len(syllables); n > 0 && word != "" {
+				syllables[n-1].Trailing += word
+			}
+			continue
+		}
+		syllables = append(syllables, lyrics.Syllable{
+			Text:     strings.TrimLeft(trimmed, " \t"),
+			Begin:    at,
+			Trailing: word[len(trimmed):],
+		})
+	}
+	if n := len(syllables); n > 0 {
+		syllables[n-1].Trailing = ""
+	}
+	return syllables, true
+}
+
+func shiftSyllables(syllables []lyrics.Syllable, delta int64) []lyrics.Syllable {
+	shifted := make([]lyrics.Syllable, len(syllables))
+	for i, syllable := range syllables {
+		syllable.Begin += delta
+		if syllable.End > 0 {
+			syllable.End += delta
+		}
+		shifted[i] = syllable
+	}
+	return shifted
+}
+
+// 补齐缺失的结束时间：音节以下一行开始为界，行以最后一个音节结束为界
+func finishLine(line *lyrics.Line, next int64) {
+	for i := range line.Syllables {
+		syllable := &line.Syllables[i]
+		if syllable.End == 0 || syllable.End < syllable.Begin {
+			syllable.End = max(next, syllable.Begin)
+		}
+	}
+	line.End = max(next, line.Begin)
+	if n := len(line.Syllables); n > 0 {
+		line.End = max(line.Syllables[n-1].End, line.Begin)
+	}
+}
+
+// LRC 中正的 offset 表示歌词提前显示
+func applyOffset(line *lyrics.Line, offset int64) {
+	if offset == 0 {
+		return
+	}
+	shift := func(t int64) int64 { return max(t-offset, 0) }
+	line.Begin, line.End = shift(line.Begin), shift(line.End)
+	for i := range line.Syllables {
+		line.Syllables[i].Begin = shift(line.Syllables[i].Begin)
+		line.Syllables[i].End = shift(line.Syllables[i].End)
+	}
+}
+
+// 生成 LRC。enhanced 为 true 时输出 <mm:ss.xx> 逐字时间
+func Marshal(doc *lyrics.Document, enhanced bool) string {
+	var sb strings.Builder
+	writeTag(&sb, "ti", doc.MetaValue(lyrics.MetaMusicName))
+	writeTag(&sb, "ar", strings.Join(doc.MetaValues(lyrics.MetaArtists), "/"))
+	writeTag(&sb, "al", doc.MetaValue(lyrics.MetaAlbum))
+
+	for i := range doc.Lines {
+		line := &doc.Lines[i]
+		sb.WriteString("[" + FormatTime(line.Begin) + "]")
+		if enhanced && len(line.Syllables) > 0 {
+			for _, syllable := range line.Syllables {
+				sb.WriteString("<" + FormatTime(syllable.Begin) + ">")
+				sb.WriteString(syllable.Text)
+				sb.WriteString(syllable.Trailing)
+			}
+			sb.WriteString("<" + FormatTime(line.Syllables[len(line.Syllables)-1].End) + ">")
+		} else {
+			sb.WriteString(line.Text())
+		}
+		sb.WriteString("\n")
+
+		// 与下一行之间有间隔时写一个空行结束本行
+		if i+1 < len(doc.Lines) && doc.Lines[i+1].Begin > line.End {
+			sb.WriteString("[" + FormatTime(line.End) + "]\n")
+		}
+	}
+	return sb.String()
+}
+
+func writeTag(sb *strings.Builder, key, value string) {
+	if value = strings.TrimSpace(value); value != "" {
+		sb.WriteString("[" + key + ":" + value + "]\n")
+	}
+}
+
+// 格式化为 mm:ss.xx
+func FormatTime(ms int64) string {
+	if ms < 0 {
+		ms = 0
+	}
+	return fmt.Sprintf("%02d:%02d.%02d", ms/60_000, ms/1000%60, ms%1000/10)
+}
+
+func toMillis(minutes, seconds, fraction string) int64 {
+	m, _ := strconv.ParseInt(minutes, 10, 64)
+	s, _ := strconv.ParseInt(seconds, 10, 64)
+	ms := int64(0)
+	if fraction != "" {
+		// .x 为十分之一秒，.xx 为百分之一秒，.xxx 为毫秒
+		f, _ := strconv.ParseInt((fraction + "00")[:3], 10, 64)
+		ms = f
+	}
+	return m*60_000 + s*1000 + ms
+}
+
+func submatch(text string, match []int, index int) string {
+	if match[index] < 0 {
+		return ""
+	}
+	return text[match[index]:match[index+1]]
+}
@@ -0,0 +1,262 @@
+package lrc
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/xiaowumin-mark/AMLX/lyrics"
+)
+
+func syllable(text string, begin, end int64) lyrics.Syllable {
+	return lyrics.Syllable{Text: text, Begin: begin, End: end}
+}
+
+// 逐行歌词：整行一个音节
+func plainLine(text string, begin, end int64) lyrics.Line {
+	return lyrics.Line{Begin: begin, End: end, Syllables: []lyrics.Syllable{syllable(text, begin, end)}}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    *lyrics.Document
+	}{
+		{
+			name:    "line timing",
+			content: "[00:01.00]first\n[00:03.50]second\n",
+			want: &lyrics.Document{Timing: lyrics.TimingLine, Lines: []lyrics.Line{
+				plainLine("first", 1000, 3500),
+				plainLine("second", 3500, 8500),
+			}},
+		},
+		{
+			name:    "timestamp precision",
+			content: "[00:01.5]a\n[00:02.25]b\n[00:03.125]c\n[00:04:50]d\n[01:05]e\n",
+			want: &lyrics.Document{Timing: lyrics.TimingLine, Lines: []lyrics.Line{
+				plainLine("a", 1500, 2250),
+				plainLine("b", 2250, 3125),
+				plainLine("c", 3125, 4500),
+				plainLine("d", 4500, 65000),
+				plainLine("e", 65000, 70000),
+			}},
+		},
+		{
+			name:    "empty line ends the previous line",
+			content: "[00:01.00]first\n[00:02.00]\n[00:05.00]second\n",
+			want: &lyrics.Document{Timing: lyrics.TimingLine, Lines: []lyrics.Line{
+				plainLine("first", 1000, 2000),
+				plainLine("second", 5000, 10000),
+			}},
+		},
+		{
+			name:    "multiple timestamps",
+			content: "[00:01.00][00:10.00]chorus\n[00:05.00]verse\n",
+			want: &lyrics.Document{Timing: lyrics.TimingLine, Lines: []lyrics.Line{
+				plainLine("chorus", 1000, 5000),
+				plainLine("verse", 5000, 10000),
+				plainLine("chorus", 10000, 15000),
+			}},
+		},
+		{
+			name:    "metadata tags",
+			content: "\ufeff[ti:Song]\n[ar:A / B、C；D]\n[al:Album]\n[by:someone]\ncomment\n[00:01.00]line\n",
+			want: &lyrics.Document{
+				Timing: lyrics.TimingLine,
+				Metadata: []lyrics.Meta{
+					{Key: lyrics.MetaMusicName, Value: "Song"},
+					{Key: lyrics.MetaArtists, Value: "A"},
+					{Key: lyrics.MetaArtists, Value: "B"},
+					{Key: lyrics.MetaArtists, Value: "C"},
+					{Key: lyrics.MetaArtists, Value: "D"},
+					{Key: lyrics.MetaAlbum, Value: "Album"},
+				},
+				Lines: []lyrics.Line{plainLine("line", 1000, 6000)},
+			},
+		},
+		{
+			// 正的 offset 使歌词提前显示，即从时间中减去
+			name:    "positive offset",
+			content: "[offset:+500]\n[00:01.00]a\n[00:02.00]b\n",
+			want: &lyrics.Document{Timing: lyrics.TimingLine, Lines: []lyrics.Line{
+				plainLine("a", 500, 1500),
+				plainLine("b", 1500, 6500),
+			}},
+		},
+		{
+			name:    "negative offset",
+			content: "[offset:-500]\n[00:01.00]a\n",
+			want: &lyrics.Document{Timing: lyrics.TimingLine, Lines: []lyrics.Line{
+				plainLine("a", 1500, 6500),
+			}},
+		},
+		{
+			name:    "offset clamps at zero",
+			content: "[offset:2000]\n[00:01.00]a\n",
+			want: &lyrics.Document{Timing: lyrics.TimingLine, Lines: []lyrics.Line{
+				plainLine("a", 0, 4000),
+			}},
+		},
+		{
+			name:    "enhanced word timing",
+			content: "[00:01.00]<00:01.00>Hello <00:01.50>world<00:02.20>\n[00:03.00]<00:03.00>next<00:03.40>\n",
+			want: &lyrics.Document{Timing: lyrics.TimingWord, Lines: []lyrics.Line{
+				{Begin: 1000, End: 2200, Syllables: []lyrics.Syllable{
+					{Text: "Hello", Begin: 1000, End: 1500, Trailing: " "},
+					syllable("world", 1500, 2200),
+				}},
+				{Begin: 3000, End: 3400, Syllables: []lyrics.Syllable{syllable("next", 3000, 3400)}},
+			}},
+		},
+		{
+			name:    "enhanced without end marker",
+			content: "[00:01.00]<00:01.00>a<00:01.40>b\n[00:02.00]x\n",
+			want: &lyrics.Document{Timing: lyrics.TimingWord, Lines: []lyrics.Line{
+				{Begin: 1000, End: 2000, Syllables: []lyrics.Syllable{syllable("a", 1000, 1400), syllable("b", 1400, 2000)}},
+				plainLine("x", 2000, 7000),
+			}},
+		},
+		{
+			// 多个时间戳的增强行，逐字时间随行平移
+			name:    "enhanced with multiple timestamps",
+			content: "[00:01.00][00:11.00]<00:01.00>la <00:01.50>la<00:02.00>\n",
+			want: &lyrics.Document{Timing: lyrics.TimingWord, Lines: []lyrics.Line{
+				{Begin: 1000, End: 2000, Syllables: []lyrics.Syllable{{Text: "la", Begin: 1000, End: 1500, Trailing: " "}, syllable("la", 1500, 2000)}},
+				{Begin: 11000, End: 12000, Syllables: []lyrics.Syllable{{Text: "la", Begin: 11000, End: 11500, Trailing: " "}, syllable("la", 11500, 12000)}},
+			}},
+		},
+		{
+			name:    "enhanced with offset",
+			content: "[offset:200]\n[00:01.00]<00:01.00>a<00:01.50>\n",
+			want: &lyrics.Document{Timing: lyrics.TimingWord, Lines: []lyrics.Line{
+				{Begin: 800, End: 1300, Syllables: []lyrics.Syllable{syllable("a", 800, 1300)}},
+			}},
+		},
+		{
+			name:    "no lines",
+			content: "[ti:Song]\n",
+			want: &lyrics.Document{
+				Timing:   lyrics.TimingNone,
+				Metadata: []lyrics.Meta{{Key: lyrics.MetaMusicName, Value: "Song"}},
+				Lines:    []lyrics.Line{},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.content)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Parse() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseInvalidOffset(t *testing.T) {
+	if _, err := Parse("[offset:soon]\n[00:01.00]a\n"); err == nil {
+		t.Fatal("expected an error for a non-numeric offset")
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	doc := &lyrics.Document{
+		Timing: lyrics.TimingWord,
+		Metadata: []lyrics.Meta{
+			{Key: lyrics.MetaMusicName, Value: "Song"},
+			{Key: lyrics.MetaArtists, Value: "A"},
+			{Key: lyrics.MetaArtists, Value: "B"},
+			{Key: lyrics.MetaAlbum, Value: "Album"},
+		},
+		Lines: []lyrics.Line{
+			{Begin: 1000, End: 2200, Syllables: []lyrics.Syllable{
+				{Text: "Hello", Begin: 1000, End: 1500, Trailing: " "},
+				syllable("world", 1500, 2200),
+			}},
+			{Begin: 5000, End: 5400, Syllables: []lyrics.Syllable{syllable("next", 5000, 5400)}},
+		},
+	}
+	tests := []struct {
+		name     string
+		enhanced bool
+		want     string
+	}{
+		{
+			name: "line",
+			want: "[ti:Song]\n[ar:A/B]\n[al:Album]\n" +
+				"[00:01.00]Hello world\n[00:02.20]\n" +
+				"[00:05.00]next\n",
+		},
+		{
+			name:     "enhanced",
+			enhanced: true,
+			want: "[ti:Song]\n[ar:A/B]\n[al:Album]\n" +
+				"[00:01.00]<00:01.00>Hello <00:01.50>world<00:02.20>\n[00:02.20]\n" +
+				"[00:05.00]<00:05.00>next<00:05.40>\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Marshal(doc, tt.enhanced); got != tt.want {
+				t.Fatalf("Marshal() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		enhanced bool
+	}{
+		{"line", "[ti:Song]\n[ar:A/B]\n[al:Album]\n[00:01.00]first\n[00:03.50]second\n[00:08.50]\n[01:00.00]third\n", false},
+		{"enhanced", "[ti:Song]\n[ar:A]\n[00:01.00]<00:01.00>Hello <00:01.50>world<00:02.20>\n[00:05.00]<00:05.00>next<00:05.40>\n", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Parse(tt.content)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out := Marshal(doc, tt.enhanced)
+			reparsed, err := Parse(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(reparsed, doc) {
+				t.Fatalf("round trip changed the document\nfirst:  %+v\nsecond: %+v\noutput:\n%s", doc, reparsed, out)
+			}
+			if again := Marshal(reparsed, tt.enhanced); again != out {
+				t.Fatalf("output is not stable:\n%s\nvs\n%s", out, again)
+			}
+		})
+	}
+}
+
+func TestFormatTime(t *testing.T) {
+	tests := []struct {
+		ms   int64
+		want string
+	}{
+		{0, "00:00.00"},
+		{1234, "00:01.23"},
+		{61_005, "01:01.00"},
+		{3_600_000, "60:00.00"},
+		{-5, "00:00.00"},
+	}
+	for _, tt := range tests {
+		if got := FormatTime(tt.ms); got != tt.want {
+			t.Errorf("FormatTime(%d) = %q, want %q", tt.ms, got, tt.want)
+		}
+	}
+}
+
+func TestSplitArtists(t *testing.T) {
+	got := SplitArtists(" A /B;C；D、 ")
+	if want := []string{"A", "B", "C", "D"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("SplitArtists() = %v, want %v", got, want)
+	}
+}
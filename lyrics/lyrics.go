@@ -0,0 +1,195 @@
+// Package lyrics 定义歌词的统一中间表示（AST），各格式的编解码都以它为中心。
+package lyrics
+
+import "strings"
+
+// 时间轴模式（itunes:timing）
+const (
+	TimingWord = "Word" // 逐字
+	TimingLine = "Line" // 逐行
+	TimingNone = "None" // 无时间轴
+)
+
+// 常用 amll:meta 键
+const (
+	MetaMusicName             = "musicName"
+	MetaArtists               = "artists"
+	MetaAlbum                 = "album"
+	MetaISRC                  = "isrc"
+	MetaNCMMusicID            = "ncmMusicId"
+	MetaQQMusicID             = "qqMusicId"
+	MetaSpotifyID             = "spotifyId"
+	MetaAppleMusicID          = "appleMusicId"
+	MetaTTMLAuthorGithub      = "ttmlAuthorGithub"
+	MetaTTMLAuthorGithubLogin = "ttmlAuthorGithubLogin"
+)
+
+// 常用演唱者 ID
+const (
+	AgentMain  = "v1"    // 主唱
+	AgentDuet  = "v2"    // 对唱
+	AgentGroup = "v1000" // 合唱
+)
+
+// Document 一首歌的完整歌词
+type Document struct {
+	Language    string   `json:"language,omitempty"` // tt 根节点 xml:lang
+	Timing      string   `json:"timing"`             // Word / Line / None
+	Metadata    []Meta   `json:"metadata,omitempty"`
+	Songwriters []string `json:"songwriters,omitempty"`
+	Agents      []Agent  `json:"agents,omitempty"`
+	Lines       []Line   `json:"lines"`
+}
+
+// Meta 一条元数据，同一个键可出现多次
+type Meta struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// Agent 演唱者
+type Agent struct {
+	ID   string `json:"id"`             // v1 / v2 / v1000 ...
+	Type string `json:"type,omitempty"` // person / group / other
+	Name string `json:"name,omitempty"`
+}
+
+// Line 一行歌词，时间均为毫秒
+type Line struct {
+	Key      string `json:"key,omitempty"`       // itunes:key
+	Agent    string `json:"agent,omitempty"`     // ttm:agent
+	SongPart string `json:"song_part,omitempty"` // 所在 div 的 itunes:songPart
+	Begin    int64  `json:"begin"`
+	End      int64  `json:"end"`
+
+	Syllables     []Syllable   `json:"syllables"`
+	Background    *Line        `json:"background,omitempty"` // 背景人声（x-bg），不含 Key / Agent / SongPart
+	Translations  []Annotation `json:"translations,omitempty"`
+	Romanizations []Annotation `json:"romanizations,omitempty"`
+}
+
+// Syllable 一个逐字音节
+type Syllable struct {
+	Text     string `json:"text"`
+	Begin    int64  `json:"begin"`
+	End      int64  `json:"end"`
+	Trailing string `json:"trailing,omitempty"` // span 之后、下一个 span 之前的文本（通常是单个空格）
+}
+
+// Annotation 翻译或音译
+type Annotation struct {
+	Lang string `json:"lang,omitempty"`
+	Text string `json:"text"`
+}
+
+// 由带空白的单词创建音节，首部空白丢弃，尾部空白放入 Trailing
+func NewSyllable(word string, begin, end int64) Syllable {
+	text := strings.TrimRight(word, " \t\u3000")
+	return Syllable{
+		Text:     strings.TrimLeft(text, " \t\u3000"),
+		Begin:    begin,
+		End:      end,
+		Trailing: word[len(text):],
+	}
+}
+
+// 获取某个元数据键的全部值
+func (d *Document) MetaValues(key string) []string {
+	var values []string
+	for _, meta := range d.Metadata {
+		if meta.Key == key {
+			values = append(values, meta.Value)
+		}
+	}
+	return values
+}
+
+// 获取某个元数据键的第一个值
+func (d *Document) MetaValue(key string) string {
+	for _, meta := range d.Metadata {
+		if meta.Key == key {
+			return meta.Value
+		}
+	}
+	return ""
+}
+
+// 覆盖某个元数据键的全部值
+func (d *Document) SetMeta(key string, values ...string) {
+	kept := d.Metadata[:0]
+	for _, meta := range d.Metadata {
+		if meta.Key != key {
+			kept = append(kept, meta)
+		}
+	}
+	d.Metadata = kept
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			d.Metadata = append(d.Metadata, Meta{Key: key, Value: value})
+		}
+	}
+}
+
+// 查找演唱者
+func (d *Document) Agent(id string) (Agent, bool) {
+	for _, agent := range d.Agents {
+		if agent.ID == id {
+			return agent, true
+		}
+	}
+	return Agent{}, false
+}
+
+// 整行文本
+func (l *Line) Text() string {
+	var sb strings.Builder
+	for _, syllable := range l.Syllables {
+		sb.WriteString(syllable.Text)
+		sb.WriteString(syllable.Trailing)
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// 第一个指定语言的翻译，lang 为空时返回第一个翻译
+func (l *Line) Translation(lang string) (Annotation, bool) {
+	return findAnnotation(l.Translations, lang)
+}
+
+// 第一个指定语言的音译，lang 为空时返回第一个音译
+func (l *Line) Romanization(lang string) (Annotation, bool) {
+	return findAnnotation(l.Romanizations, lang)
+}
+
+// 根据音节重新计算行的起止时间
+func (l *Line) RecomputeTiming() {
+	if len(l.Syllables) == 0 {
+		return
+	}
+	begin, end := l.Syllables[0].Begin, l.Syllables[0].End
+	for _, syllable := range l.Syllables[1:] {
+		begin = min(begin, syllable.Begin)
+		end = max(end, syllable.End)
+	}
+	l.Begin, l.End = begin, end
+}
+
+// 文档的结束时间
+func (d *Document) Duration() int64 {
+	var end int64
+	for _, line := range d.Lines {
+		end = max(end, line.End)
+		if line.Background != nil {
+			end = max(end, line.Background.End)
+		}
+	}
+	return end
+}
+
+func findAnnotation(annotations []Annotation, lang string) (Annotation, bool) {
+	for _, annotation := range annotations {
+		if lang == "" || strings.EqualFold(annotation.Lang, lang) {
+			return annotation, true
+		}
+	}
+	return Annotation{}, false
+}
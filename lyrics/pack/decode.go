@@ -0,0 +1,207 @@
+package pack
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"math"
+
+	"github.com/xiaowumin-mark/AMLX/lyrics"
+)
+
+// 背景行嵌套的最大深度，防止恶意数据导致栈溢出
+const maxBackgroundDepth = 4
+
+// 解码 Binary Pack
+func Decode(data []byte) (*lyrics.Document, error) {
+	if len(data) < len(Magic)+2+4 || !bytes.HasPrefix(data, []byte(Magic)) {
+		return nil, ErrBadMagic
+	}
+	if data[len(Magic)] != Version {
+		return nil, ErrUnsupportedVersion
+	}
+	payload, sum := data[:len(data)-4], data[len(data)-4:]
+	if crc32.ChecksumIEEE(payload) != binary.LittleEndian.Uint32(sum) {
+		return nil, ErrChecksum
+	}
+
+	d := &decoder{buf: payload[len(Magic)+2:]}
+	flags := payload[len(Magic)+1]
+
+	count := d.count()
+	d.strings = make([]string, 1, count+1)
+	for range count {
+		n := d.count()
+		d.strings = append(d.strings, string(d.take(n)))
+	}
+
+	doc := &lyrics.Document{}
+	doc.Language = d.str()
+	doc.Timing = d.str()
+	if n := d.count(); n > 0 {
+		doc.Metadata = make([]lyrics.Meta, n)
+		for i := range doc.Metadata {
+			doc.Metadata[i] = lyrics.Meta{Key: d.str(), Value: d.str()}
+		}
+	}
+	if n := d.count(); n > 0 {
+		doc.Songwriters = make([]string, n)
+		for i := range doc.Songwriters {
+			doc.Songwriters[i] = d.str()
+		}
+	}
+	if n := d.count(); n > 0 {
+		doc.Agents = make([]lyrics.Agent, n)
+		for i := range doc.Agents {
+			doc.Agents[i] = lyrics.Agent{ID: d.str(), Type: d.str(), Name: d.str()}
+		}
+	}
+
+	if n := d.count(); n > 0 {
+		doc.Lines = make([]lyrics.Line, n)
+		var prev int64
+		for i := range doc.Lines {
+			line := &doc.Lines[i]
+			line.Key, line.Agent, line.SongPart = d.str(), d.str(), d.str()
+			d.line(line, prev, 0)
+			prev = line.Begin
+		}
+	}
+	if flags&flagTranslations != 0 {
+		for i := range doc.Lines {
+			doc.Lines[i].Translations = d.annotations()
+		}
+	}
+	if flags&flagRomanizations != 0 {
+		for i := range doc.Lines {
+			doc.Lines[i].Romanizations = d.annotations()
+		}
+	}
+
+	if d.err != nil || len(d.buf) != 0 {
+		return nil, ErrCorrupt
+	}
+	return doc, nil
+}
+
+// 出错后所有读取都返回零值，最后统一检查 err
+type decoder struct {
+	buf     []byte
+	strings []string
+	err     error
+}
+
+func (d *decoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(d.buf)
+	if n <= 0 {
+		d.err = ErrCorrupt
+		return 0
+	}
+	d.buf = d.buf[n:]
+	return v
+}
+
+func (d *decoder) varint() int64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(d.buf)
+	if n <= 0 {
+		d.err = ErrCorrupt
+		return 0
+	}
+	d.buf = d.buf[n:]
+	return v
+}
+
+// 读取元素个数；每个元素至少占 1 字节，超过剩余长度即为损坏
+func (d *decoder) count() int {
+	v := d.uvarint()
+	if v > uint64(len(d.buf)) {
+		d.err = ErrCorrupt
+		return 0
+	}
+	return int(v)
+}
+
+func (d *decoder) take(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n > len(d.buf) {
+		d.err = ErrCorrupt
+		return nil
+	}
+	b := d.buf[:n]
+	d.buf = d.buf[n:]
+	return b
+}
+
+func (d *decoder) flag() byte {
+	if b := d.take(1); len(b) == 1 {
+		return b[0]
+	}
+	return 0
+}
+
+func (d *decoder) str() string {
+	id := d.uvarint()
+	if id >= uint64(len(d.strings)) {
+		d.err = ErrCorrupt
+		return ""
+	}
+	return d.strings[id]
+}
+
+func (d *decoder) line(line *lyrics.Line, base int64, depth int) {
+	line.Begin = base + d.varint()
+	line.End = d.end(line.Begin)
+	if n := d.count(); n > 0 {
+		line.Syllables = make([]lyrics.Syllable, n)
+		cursor := line.Begin
+		for i := range line.Syllables {
+			syllable := &line.Syllables[i]
+			syllable.Text, syllable.Trailing = d.str(), d.str()
+			syllable.Begin = cursor + d.varint()
+			syllable.End = d.end(syllable.Begin)
+			cursor = syllable.End
+		}
+	}
+	switch d.flag() {
+	case 0:
+	case 1:
+		if depth >= maxBackgroundDepth {
+			d.err = ErrCorrupt
+			return
+		}
+		line.Background = &lyrics.Line{}
+		d.line(line.Background, line.Begin, depth+1)
+	default:
+		d.err = ErrCorrupt
+	}
+}
+
+// 读取时长并返回结束时间；结束时间溢出时数据无法按原样重新编码，视为损坏
+func (d *decoder) end(begin int64) int64 {
+	duration := d.uvarint()
+	if duration > math.MaxInt64 || begin+int64(duration) < begin {
+		d.err = ErrCorrupt
+		return begin
+	}
+	return begin + int64(duration)
+}
+
+func (d *decoder) annotations() []lyrics.Annotation {
+	n := d.count()
+	if n == 0 {
+		return nil
+	}
+	annotations := make([]lyrics.Annotation, n)
+	for i := range annotations {
+		annotations[i] = lyrics.Annotation{Lang: d.str(), Text: d.str()}
+	}
+	return annotations
+}
@@ -0,0 +1,162 @@
+// Package pack 实现 AMLX Binary Pack：歌词 AST 的紧凑二进制编码，供 AMLX-CDN 分发给客户端。
+//
+// 版本 1 的布局（整数均为 LEB128 varint，svarint 为 zigzag 编码）：
+//
+//	magic    "AMLP"
+//	version  u8 = 1
+//	flags    u8  bit0 含翻译段，bit1 含音译段
+//	strings  count, count × (len, utf-8 bytes)   字符串表，下标 0 固定为空串
+//	head     language, timing                    字符串下标
+//	         metadata count × (key, value)
+//	         songwriters count × name
+//	         agents count × (id, type, name)
+//	lines    count × line
+//	line     key, agent, songPart                字符串下标（背景行没有这三项）
+//	         begin svarint                       相对上一行 begin（背景行相对所属行 begin）
+//	         duration
+//	         syllables count × (text, trailing, begin svarint 相对上一个音节 end 或行 begin, duration)
+//	         background u8 0/1, [line]
+//	translations  lines × (count × (lang, text)) 仅 flags bit0
+//	romanizations lines × (count × (lang, text)) 仅 flags bit1
+//	checksum u32 little-endian，之前所有字节的 CRC-32 (IEEE)
+package pack
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+
+	"github.com/xiaowumin-mark/AMLX/lyrics"
+)
+
+const (
+	Magic   = "AMLP"
+	Version = 1
+)
+
+const (
+	flagTranslations  = 1 << 0
+	flagRomanizations = 1 << 1
+)
+
+var (
+	ErrBadMagic           = errors.New("pack: bad magic")
+	ErrUnsupportedVersion = errors.New("pack: unsupported version")
+	ErrChecksum           = errors.New("pack: checksum mismatch")
+	ErrCorrupt            = errors.New("pack: corrupt data")
+)
+
+// 编码文档
+func Encode(doc *lyrics.Document) []byte {
+	e := &encoder{index: map[string]uint64{"": 0}, strings: []string{""}}
+
+	// 先编码正文收集字符串，再拼接字符串表
+	var body []byte
+	body = e.str(body, doc.Language)
+	body = e.str(body, doc.Timing)
+	body = binary.AppendUvarint(body, uint64(len(doc.Metadata)))
+	for _, meta := range doc.Metadata {
+		body = e.str(body, meta.Key)
+		body = e.str(body, meta.Value)
+	}
+	body = binary.AppendUvarint(body, uint64(len(doc.Songwriters)))
+	for _, name := range doc.Songwriters {
+		body = e.str(body, name)
+	}
+	body = binary.AppendUvarint(body, uint64(len(doc.Agents)))
+	for _, agent := range doc.Agents {
+		body = e.str(body, agent.ID)
+		body = e.str(body, agent.Type)
+		body = e.str(body, agent.Name)
+	}
+
+	var flags byte
+	body = binary.AppendUvarint(body, uint64(len(doc.Lines)))
+	var prev int64
+	for i := range doc.Lines {
+		line := &doc.Lines[i]
+		body = e.str(body, line.Key)
+		body = e.str(body, line.Agent)
+		body = e.str(body, line.SongPart)
+		body = e.line(body, line, prev)
+		prev = line.Begin
+		if len(line.Translations) > 0 {
+			flags |= flagTranslations
+		}
+		if len(line.Romanizations) > 0 {
+			flags |= flagRomanizations
+		}
+	}
+	if flags&flagTranslations != 0 {
+		for i := range doc.Lines {
+			body = e.annotations(body, doc.Lines[i].Translations)
+		}
+	}
+	if flags&flagRomanizations != 0 {
+		for i := range doc.Lines {
+			body = e.annotations(body, doc.Lines[i].Romanizations)
+		}
+	}
+
+	out := make([]byte, 0, len(body)+64)
+	out = append(out, Magic...)
+	out = append(out, Version, flags)
+	out = binary.AppendUvarint(out, uint64(len(e.strings)-1))
+	for _, s := range e.strings[1:] {
+		out = binary.AppendUvarint(out, uint64(len(s)))
+		out = append(out, s...)
+	}
+	out = append(out, body...)
+	return binary.LittleEndian.AppendUint32(out, crc32.ChecksumIEEE(out))
+}
+
+type encoder struct {
+	index   map[string]uint64
+	strings []string
+}
+
+func (e *encoder) str(buf []byte, s string) []byte {
+	id, ok := e.index[s]
+	if !ok {
+		id = uint64(len(e.strings))
+		e.index[s] = id
+		e.strings = append(e.strings, s)
+	}
+	return binary.AppendUvarint(buf, id)
+}
+
+func (e *encoder) line(buf []byte, line *lyrics.Line, base int64) []byte {
+	buf = binary.AppendVarint(buf, line.Begin-base)
+	buf = binary.AppendUvarint(buf, duration(line.Begin, line.End))
+	buf = binary.AppendUvarint(buf, uint64(len(line.Syllables)))
+	cursor := line.Begin
+	for _, syllable := range line.Syllables {
+		buf = e.str(buf, syllable.Text)
+		buf = e.str(buf, syllable.Trailing)
+		buf = binary.AppendVarint(buf, syllable.Begin-cursor)
+		buf = binary.AppendUvarint(buf, duration(syllable.Begin, syllable.End))
+		cursor = syllable.Begin + int64(duration(syllable.Begin, syllable.End))
+	}
+	if line.Background == nil {
+		return append(buf, 0)
+	}
+	buf = append(buf, 1)
+	return e.line(buf, line.Background, line.Begin)
+}
+
+func (e *encoder) annotations(buf []byte, annotations []lyrics.Annotation) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(annotations)))
+	for _, annotation := range annotations {
+		buf = e.str(buf, annotation.Lang)
+		buf = e.str(buf, annotation.Text)
+	}
+	return buf
+}
+
+// 结束早于开始时按 0 处理，保证时长非负
+func duration(begin, end int64) uint64 {
+	if end < begin {
+		return 0
+	}
+	return uint64(end - begin)
+}
@@ -0,0 +1,186 @@
+package pack
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/xiaowumin-mark/AMLX/lyrics"
+	"github.com/xiaowumin-mark/AMLX/lyrics/ttml"
+)
+
+// 一首约 4 分钟的逐字歌词：60 行、每行 8 个音节，一半的行有翻译与音译，每 6 行一条背景人声
+func sampleDocument() *lyrics.Document {
+	doc := &lyrics.Document{
+		Language: "ja",
+		Timing:   lyrics.TimingWord,
+		Metadata: []lyrics.Meta{
+			{Key: lyrics.MetaMusicName, Value: "サンプル"},
+			{Key: lyrics.MetaArtists, Value: "Sample Artist"},
+			{Key: lyrics.MetaNCMMusicID, Value: "1234567"},
+		},
+		Songwriters: []string{"Writer A", "Writer B"},
+		Agents:      []lyrics.Agent{{ID: lyrics.AgentMain, Type: "person"}, {ID: lyrics.AgentDuet, Type: "person"}},
+	}
+	words := []string{"夜", "空", "に", "光", "る", "星", "を", "見た"}
+	begin := int64(12000)
+	for i := 0; i < 60; i++ {
+		line := lyrics.Line{Agent: lyrics.AgentMain, Begin: begin}
+		if i%4 == 3 {
+			line.Agent = lyrics.AgentDuet
+		}
+		t := begin
+		for _, word := range words {
+			line.Syllables = append(line.Syllables, lyrics.Syllable{Text: word, Begin: t, End: t + 420})
+			t += 450
+		}
+		line.End = t
+		if i%2 == 0 {
+			line.Translations = []lyrics.Annotation{{Lang: "zh-Hans", Text: "看见夜空中闪耀的星星"}}
+			line.Romanizations = []lyrics.Annotation{{Text: "yo zo ra ni hi ka ru ho shi wo mi ta"}}
+		}
+		if i%6 == 5 {
+			line.Background = &lyrics.Line{
+				Begin:     t - 900,
+				End:       t,
+				Syllables: []lyrics.Syllable{{Text: "ah", Begin: t - 900, End: t - 450, Trailing: " "}, {Text: "ah", Begin: t - 450, End: t}},
+			}
+		}
+		doc.Lines = append(doc.Lines, line)
+		begin = t + 300
+	}
+	return doc
+}
+
+func TestRoundTrip(t *testing.T) {
+	doc := sampleDocument()
+	got, err := Decode(Encode(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, doc) {
+		t.Fatalf("round trip changed the document:\ngot  %+v\nwant %+v", got, doc)
+	}
+}
+
+func TestDecodeRejectsDamage(t *testing.T) {
+	data := Encode(sampleDocument())
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"empty", nil, ErrBadMagic},
+		{"magic", append([]byte("AMLQ"), data[4:]...), ErrBadMagic},
+		{"version", append(append([]byte(Magic), Version+1), data[5:]...), ErrUnsupportedVersion},
+		{"truncated", data[:len(data)-1], ErrChecksum},
+		{"flipped", flip(data, len(data)/2), ErrChecksum},
+		{"overflowing duration", overflowingDuration(), ErrCorrupt},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Decode(tt.data); err != tt.want {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+// 一行，begin 为 1，时长为 2^64-1，结束时间溢出
+func overflowingDuration() []byte {
+	data := append([]byte(Magic), Version, 0)
+	data = append(data, 0, 0, 0, 0, 0, 0) // 字符串表、language、timing、metadata、songwriters、agents
+	data = append(data, 1, 0, 0, 0)       // 行数、key、agent、songPart
+	data = binary.AppendVarint(data, 1)
+	data = binary.AppendUvarint(data, math.MaxUint64)
+	data = append(data, 0, 0) // 音节数、背景行
+	return binary.LittleEndian.AppendUint32(data, crc32.ChecksumIEEE(data))
+}
+
+func flip(data []byte, i int) []byte {
+	out := bytes.Clone(data)
+	out[i] ^= 0x01
+	return out
+}
+
+// 任意输入都不能使解码崩溃；能解码的文档再次编码后必须解码出相同的结果。
+// 校验和会拦下几乎所有变异，因此输入同时以补上正确校验和的形式解码，让变异进入正文解析。
+// 种子只取两行（含翻译、音译与背景行），整首歌作种子时最小化过慢，模糊测试几乎停滞
+func FuzzDecode(f *testing.F) {
+	seed := sampleDocument()
+	seed.Lines = seed.Lines[4:6]
+	f.Add(Encode(seed))
+	f.Add(Encode(&lyrics.Document{Timing: lyrics.TimingNone}))
+	f.Add([]byte(Magic))
+	f.Add(overflowingDuration())
+	f.Fuzz(func(t *testing.T, data []byte) {
+		checkDecode(t, data)
+		if len(data) >= 4 {
+			payload := data[: len(data)-4 : len(data)-4]
+			checkDecode(t, binary.LittleEndian.AppendUint32(payload, crc32.ChecksumIEEE(payload)))
+		}
+	})
+}
+
+func checkDecode(t *testing.T, data []byte) {
+	doc, err := Decode(data)
+	if err != nil {
+		return
+	}
+	again, err := Decode(Encode(doc))
+	if err != nil {
+		t.Fatalf("re-encoded document does not decode: %v", err)
+	}
+	if !reflect.DeepEqual(again, doc) {
+		t.Fatalf("round trip changed the document:\ngot  %+v\nwant %+v", again, doc)
+	}
+}
+
+func BenchmarkEncode(b *testing.B) {
+	doc := sampleDocument()
+	b.Run("pack", func(b *testing.B) {
+		var size int
+		for i := 0; i < b.N; i++ {
+			size = len(Encode(doc))
+		}
+		b.ReportMetric(float64(size), "bytes/doc")
+	})
+	b.Run("ttml", func(b *testing.B) {
+		var size int
+		for i := 0; i < b.N; i++ {
+			size = len(ttml.Marshal(doc))
+		}
+		b.ReportMetric(float64(size), "bytes/doc")
+	})
+}
+
+func BenchmarkDecode(b *testing.B) {
+	doc := sampleDocument()
+	packed, marshalled := Encode(doc), ttml.Marshal(doc)
+	b.Run("pack", func(b *testing.B) {
+		b.SetBytes(int64(len(packed)))
+		for i := 0; i < b.N; i++ {
+			if _, err := Decode(packed); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("ttml", func(b *testing.B) {
+		b.SetBytes(int64(len(marshalled)))
+		for i := 0; i < b.N; i++ {
+			if _, err := ttml.Parse(marshalled); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func ExampleEncode() {
+	doc := sampleDocument()
+	fmt.Println(len(Encode(doc)) < len(ttml.Marshal(doc)))
+	// Output: true
+}
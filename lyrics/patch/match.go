@@ -0,0 +1,275 @@
+package patch
+
+import (
+	"reflect"
+	"slices"
+	"sort"
+
+	"github.com/xiaowumin-mark/AMLX/lyrics"
+)
+
+const (
+	// 超过该规模时不做 LCS，只按文本配对
+	maxMatchCells = 4_000_000
+	// 文本不同的两行开始时间相差不超过该值（毫秒）时视为同一行的修改
+	maxPairDistance = 10_000
+)
+
+type linePair struct {
+	base   int
+	target int
+}
+
+// 行配对：先按文本做 LCS 得到保持顺序的锚点；剩余行中文本相同的视为移动；
+// 仍未配对的行按开始时间就近配对，视为修改
+func matchLines(base, target []lyrics.Line) []linePair {
+	baseKeys := make([]string, len(base))
+	for i := range base {
+		baseKeys[i] = lineKey(&base[i])
+	}
+	targetKeys := make([]string, len(target))
+	for j := range target {
+		targetKeys[j] = lineKey(&target[j])
+	}
+
+	anchors := lcs(baseKeys, targetKeys)
+	baseUsed := make([]bool, len(base))
+	targetUsed := make([]bool, len(target))
+	pairs := make([]linePair, 0, len(target))
+	for _, pair := range anchors {
+		baseUsed[pair.base], targetUsed[pair.target] = true, true
+		pairs = append(pairs, pair)
+	}
+
+	// 移动：文本完全相同但不在 LCS 中
+	free := map[string][]int{}
+	for i, key := range baseKeys {
+		if !baseUsed[i] {
+			free[key] = append(free[key], i)
+		}
+	}
+	for j, key := range targetKeys {
+		if targetUsed[j] || len(free[key]) == 0 {
+			continue
+		}
+		i := free[key][0]
+		free[key] = free[key][1:]
+		baseUsed[i], targetUsed[j] = true, true
+		pairs = append(pairs, linePair{base: i, target: j})
+	}
+
+	// 修改：剩余的行按开始时间就近配对
+	var candidates []linePair
+	for i := range base {
+		if baseUsed[i] {
+			continue
+		}
+		for j := range target {
+			if !targetUsed[j] && distance(&base[i], &target[j]) <= maxPairDistance {
+				candidates = append(candidates, linePair{base: i, target: j})
+			}
+		}
+	}
+	sort.SliceStable(candidates, func(x, y int) bool {
+		return distance(&base[candidates[x].base], &target[candidates[x].target]) <
+			distance(&base[candidates[y].base], &target[candidates[y].target])
+	})
+	for _, pair := range candidates {
+		if !baseUsed[pair.base] && !targetUsed[pair.target] {
+			baseUsed[pair.base], targetUsed[pair.target] = true, true
+			pairs = append(pairs, pair)
+		}
+	}
+	return pairs
+}
+
+func distance(a, b *lyrics.Line) int64 {
+	if a.Begin > b.Begin {
+		return a.Begin - b.Begin
+	}
+	return b.Begin - a.Begin
+}
+
+func lineKey(line *lyrics.Line) string {
+	return line.Agent + "\x00" + line.Text()
+}
+
+// 最长公共子序列，返回按顺序的配对
+func lcs(a, b []string) []linePair {
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 || n*m > maxMatchCells {
+		return nil
+	}
+	// dp[i][j] 为 a[i:] 与 b[j:] 的 LCS 长度
+	dp := make([][]int32, n+1)
+	for i := range dp {
+		dp[i] = make([]int32, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				dp[i][j] = dp[i+1][j+1] + 1
+			} else {
+				dp[i][j] = max(dp[i+1][j], dp[i][j+1])
+			}
+		}
+	}
+	var pairs []linePair
+	for i, j := 0, 0; i < n && j < m; {
+		switch {
+		case a[i] == b[j]:
+			pairs = append(pairs, linePair{base: i, target: j})
+			i++
+			j++
+		case dp[i+1][j] >= dp[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return pairs
+}
+
+// source[j] 为目标第 j 行的基础行号（-1 为新增）。
+// 基础行号最长递增子序列中的行保持原位，其余需要移动
+func stayingLines(source []int) []bool {
+	stay := make([]bool, len(source))
+	var tails []int // tails[k] 为长度 k+1 的递增子序列末尾所在的目标下标
+	prev := make([]int, len(source))
+	for j, from := range source {
+		if from < 0 {
+			continue
+		}
+		k := sort.Search(len(tails), func(k int) bool { return source[tails[k]] >= from })
+		if k > 0 {
+			prev[j] = tails[k-1]
+		} else {
+			prev[j] = -1
+		}
+		if k == len(tails) {
+			tails = append(tails, j)
+		} else {
+			tails[k] = j
+		}
+	}
+	if len(tails) > 0 {
+		for j := tails[len(tails)-1]; j >= 0; j = prev[j] {
+			stay[j] = true
+		}
+	}
+	return stay
+}
+
+// 一对配对行之间的操作；无法用平移与改字表达时整行替换
+func lineOps(index int, base, target *lyrics.Line) []Op {
+	candidate := cloneLine(*base)
+	var ops []Op
+
+	if delta, ok := uniformShift(base, target); ok {
+		ops = append(ops, Op{Type: OpShiftLine, Index: index, DeltaBegin: delta})
+		shiftLine(&candidate, delta)
+	} else {
+		if db, de := target.Begin-base.Begin, target.End-base.End; db != 0 || de != 0 {
+			ops = append(ops, Op{Type: OpShiftTiming, Index: index, DeltaBegin: db, DeltaEnd: de})
+			candidate.Begin += db
+			candidate.End += de
+		}
+		if len(base.Syllables) == len(target.Syllables) {
+			for k := range base.Syllables {
+				db := target.Syllables[k].Begin - base.Syllables[k].Begin
+				de := target.Syllables[k].End - base.Syllables[k].End
+				if db != 0 || de != 0 {
+					ops = append(ops, Op{Type: OpShiftTiming, Index: index, Syllable: ptr(k), DeltaBegin: db, DeltaEnd: de})
+					candidate.Syllables[k].Begin += db
+					candidate.Syllables[k].End += de
+				}
+			}
+		}
+	}
+	if len(base.Syllables) == len(target.Syllables) {
+		for k := range base.Syllables {
+			if old, updated := base.Syllables[k].Text, target.Syllables[k].Text; old != updated {
+				ops = append(ops, Op{Type: OpSetText, Index: index, Syllable: ptr(k), Old: old, New: updated})
+				candidate.Syllables[k].Text = updated
+			}
+		}
+	}
+
+	if !reflect.DeepEqual(normalizeLine(candidate), normalizeLine(*target)) {
+		line := cloneLine(*target)
+		return []Op{{Type: OpReplaceLine, Index: index, Line: &line}}
+	}
+	return ops
+}
+
+// 行、所有音节与背景人声是否整体平移了同一个非零时间
+func uniformShift(base, target *lyrics.Line) (int64, bool) {
+	delta := target.Begin - base.Begin
+	if delta == 0 || target.End-base.End != delta || len(base.Syllables) != len(target.Syllables) {
+		return 0, false
+	}
+	for k := range base.Syllables {
+		if target.Syllables[k].Begin-base.Syllables[k].Begin != delta || target.Syllables[k].End-base.Syllables[k].End != delta {
+			return 0, false
+		}
+	}
+	return delta, true
+}
+
+func shiftLine(line *lyrics.Line, delta int64) {
+	line.Begin += delta
+	line.End += delta
+	for k := range line.Syllables {
+		line.Syllables[k].Begin += delta
+		line.Syllables[k].End += delta
+	}
+	if line.Background != nil {
+		shiftLine(line.Background, delta)
+	}
+}
+
+func cloneLine(line lyrics.Line) lyrics.Line {
+	line.Syllables = slices.Clone(line.Syllables)
+	line.Translations = slices.Clone(line.Translations)
+	line.Romanizations = slices.Clone(line.Romanizations)
+	if line.Background != nil {
+		background := cloneLine(*line.Background)
+		line.Background = &background
+	}
+	return line
+}
+
+// 比较前把空切片统一为 nil
+func normalizeLine(line lyrics.Line) lyrics.Line {
+	if len(line.Syllables) == 0 {
+		line.Syllables = nil
+	}
+	if len(line.Translations) == 0 {
+		line.Translations = nil
+	}
+	if len(line.Romanizations) == 0 {
+		line.Romanizations = nil
+	}
+	if line.Background != nil {
+		background := normalizeLine(*line.Background)
+		line.Background = &background
+	}
+	return line
+}
+
+func sameHead(a, b *lyrics.Document) bool {
+	return a.Language == b.Language && a.Timing == b.Timing &&
+		slices.Equal(a.Metadata, b.Metadata) &&
+		slices.Equal(a.Songwriters, b.Songwriters) &&
+		slices.Equal(a.Agents, b.Agents)
+}
+
+func headOf(doc *lyrics.Document) *Head {
+	return &Head{
+		Language:    doc.Language,
+		Timing:      doc.Timing,
+		Metadata:    slices.Clone(doc.Metadata),
+		Songwriters: slices.Clone(doc.Songwriters),
+		Agents:      slices.Clone(doc.Agents),
+	}
+}
@@ -0,0 +1,313 @@
+// Package patch 计算两份歌词 AST 之间的结构化差异，并生成可应用、可校验的补丁。
+//
+// 差异分三层：行的新增 / 删除 / 移动；音节文本修改；时间轴平移。
+// 无法用以上操作表达的行内改动（音节数量、翻译、背景人声等）整行替换。
+package patch
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/xiaowumin-mark/AMLX/lyrics"
+	"github.com/xiaowumin-mark/AMLX/lyrics/ttml"
+)
+
+// 补丁格式版本
+const FormatVersion = 1
+
+var (
+	ErrBaseMismatch   = errors.New("patch: base hash mismatch")
+	ErrTargetMismatch = errors.New("patch: result hash mismatch")
+	ErrInvalidPatch   = errors.New("patch: invalid patch")
+)
+
+type OpType string
+
+const (
+	OpAddLine     OpType = "add_line"     // 在 index 处插入 line
+	OpRemoveLine  OpType = "remove_line"  // 删除基础版本第 from 行
+	OpMoveLine    OpType = "move_line"    // 基础版本第 from 行移动到 index
+	OpReplaceLine OpType = "replace_line" // 整行替换为 line
+	OpSetText     OpType = "set_text"     // 修改第 index 行第 syllable 个音节的文本
+	OpShiftLine   OpType = "shift_line"   // 整行（含音节与背景人声）平移 delta_begin
+	OpShiftTiming OpType = "shift_timing" // 调整行（syllable 为空）或音节的起止时间
+)
+
+// Op 一个补丁操作。index 指目标版本中的行号，from 指基础版本中的行号，均从 0 开始
+type Op struct {
+	Type       OpType       `json:"op"`
+	Index      int          `json:"index"`
+	From       *int         `json:"from,omitempty"`
+	Syllable   *int         `json:"syllable,omitempty"`
+	Old        string       `json:"old,omitempty"`
+	New        string       `json:"new,omitempty"`
+	DeltaBegin int64        `json:"delta_begin,omitempty"`
+	DeltaEnd   int64        `json:"delta_end,omitempty"`
+	Line       *lyrics.Line `json:"line,omitempty"`
+}
+
+// Head 文档头部，有变化时整体替换
+type Head struct {
+	Language    string         `json:"language"`
+	Timing      string         `json:"timing"`
+	Metadata    []lyrics.Meta  `json:"metadata"`
+	Songwriters []string       `json:"songwriters"`
+	Agents      []lyrics.Agent `json:"agents"`
+}
+
+// Patch 从基础版本到目标版本的补丁
+type Patch struct {
+	Version    int    `json:"version"`
+	BaseHash   string `json:"base_hash"`
+	TargetHash string `json:"target_hash"`
+	Head       *Head  `json:"head,omitempty"`
+	Ops        []Op   `json:"ops"`
+}
+
+// Summary 各类操作的数量
+type Summary struct {
+	Added       int  `json:"added"`
+	Removed     int  `json:"removed"`
+	Moved       int  `json:"moved"`
+	Replaced    int  `json:"replaced"`
+	TextChanged int  `json:"text_changed"`
+	Shifted     int  `json:"shifted"`
+	HeadChanged bool `json:"head_changed"`
+}
+
+// 统计操作数量
+func (p *Patch) Summary() Summary {
+	summary := Summary{HeadChanged: p.Head != nil}
+	for _, op := range p.Ops {
+		switch op.Type {
+		case OpAddLine:
+			summary.Added++
+		case OpRemoveLine:
+			summary.Removed++
+		case OpMoveLine:
+			summary.Moved++
+		case OpReplaceLine:
+			summary.Replaced++
+		case OpSetText:
+			summary.TextChanged++
+		case OpShiftLine, OpShiftTiming:
+			summary.Shifted++
+		}
+	}
+	return summary
+}
+
+// 是否没有任何变化
+func (p *Patch) Empty() bool {
+	return p.Head == nil && len(p.Ops) == 0
+}
+
+// 文档的规范哈希：规范化 TTML 的 SHA-256
+func Hash(doc *lyrics.Document) string {
+	sum := sha256.Sum256(ttml.Marshal(doc))
+	return hex.EncodeToString(sum[:])
+}
+
+// 计算从 base 到 target 的补丁
+func Diff(base, target *lyrics.Document) *Patch {
+	p := &Patch{
+		Version:    FormatVersion,
+		BaseHash:   Hash(base),
+		TargetHash: Hash(target),
+		Ops:        []Op{},
+	}
+	if !sameHead(base, target) {
+		p.Head = headOf(target)
+	}
+
+	mapping := matchLines(base.Lines, target.Lines)
+
+	// 目标行来源：-1 表示新增
+	source := make([]int, len(target.Lines))
+	for i := range source {
+		source[i] = -1
+	}
+	used := make([]bool, len(base.Lines))
+	for _, pair := range mapping {
+		source[pair.target] = pair.base
+		used[pair.base] = true
+	}
+
+	for i := range base.Lines {
+		if !used[i] {
+			p.Ops = append(p.Ops, Op{Type: OpRemoveLine, From: ptr(i)})
+		}
+	}
+
+	// 基础顺序中最长递增的部分视为保持原位，其余为移动
+	stay := stayingLines(source)
+	for j, from := range source {
+		switch {
+		case from < 0:
+			line := cloneLine(target.Lines[j])
+			p.Ops = append(p.Ops, Op{Type: OpAddLine, Index: j, Line: &line})
+		case !stay[j]:
+			p.Ops = append(p.Ops, Op{Type: OpMoveLine, Index: j, From: ptr(from)})
+		}
+	}
+	for j, from := range source {
+		if from >= 0 {
+			p.Ops = append(p.Ops, lineOps(j, &base.Lines[from], &target.Lines[j])...)
+		}
+	}
+	return p
+}
+
+// 应用补丁，校验基础版本与结果的哈希
+func Apply(base *lyrics.Document, p *Patch) (*lyrics.Document, error) {
+	if p == nil || p.Version != FormatVersion {
+		return nil, ErrInvalidPatch
+	}
+	if Hash(base) != p.BaseHash {
+		return nil, ErrBaseMismatch
+	}
+
+	removed := make([]bool, len(base.Lines))
+	moved := make([]bool, len(base.Lines))
+	placed := map[int]lyrics.Line{}
+	for _, op := range p.Ops {
+		switch op.Type {
+		case OpRemoveLine, OpMoveLine:
+			if op.From == nil || *op.From < 0 || *op.From >= len(base.Lines) || removed[*op.From] || moved[*op.From] {
+				return nil, fmt.Errorf("%w: bad source line in %s", ErrInvalidPatch, op.Type)
+			}
+			if op.Type == OpRemoveLine {
+				removed[*op.From] = true
+				continue
+			}
+			moved[*op.From] = true
+			if err := place(placed, op.Index, cloneLine(base.Lines[*op.From])); err != nil {
+				return nil, err
+			}
+		case OpAddLine:
+			if op.Line == nil {
+				return nil, fmt.Errorf("%w: add_line without line", ErrInvalidPatch)
+			}
+			if err := place(placed, op.Index, cloneLine(*op.Line)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// 未删除、未移动的行按原顺序填入剩余位置
+	var staying []lyrics.Line
+	for i, line := range base.Lines {
+		if !removed[i] && !moved[i] {
+			staying = append(staying, cloneLine(line))
+		}
+	}
+	lines := make([]lyrics.Line, len(staying)+len(placed))
+	next := 0
+	for j := range lines {
+		if line, ok := placed[j]; ok {
+			lines[j] = line
+			continue
+		}
+		if next >= len(staying) {
+			return nil, fmt.Errorf("%w: line %d has no source", ErrInvalidPatch, j)
+		}
+		lines[j] = staying[next]
+		next++
+	}
+	if len(placed) > 0 && maxKey(placed) >= len(lines) {
+		return nil, fmt.Errorf("%w: line index out of range", ErrInvalidPatch)
+	}
+
+	result := &lyrics.Document{
+		Language:    base.Language,
+		Timing:      base.Timing,
+		Metadata:    slices.Clone(base.Metadata),
+		Songwriters: slices.Clone(base.Songwriters),
+		Agents:      slices.Clone(base.Agents),
+		Lines:       lines,
+	}
+	if head := p.Head; head != nil {
+		result.Language = head.Language
+		result.Timing = head.Timing
+		result.Metadata = slices.Clone(head.Metadata)
+		result.Songwriters = slices.Clone(head.Songwriters)
+		result.Agents = slices.Clone(head.Agents)
+	}
+
+	for _, op := range p.Ops {
+		if err := applyLineOp(result, op); err != nil {
+			return nil, err
+		}
+	}
+	if Hash(result) != p.TargetHash {
+		return nil, ErrTargetMismatch
+	}
+	return result, nil
+}
+
+func place(placed map[int]lyrics.Line, index int, line lyrics.Line) error {
+	if index < 0 {
+		return fmt.Errorf("%w: negative line index", ErrInvalidPatch)
+	}
+	if _, exists := placed[index]; exists {
+		return fmt.Errorf("%w: line %d placed twice", ErrInvalidPatch, index)
+	}
+	placed[index] = line
+	return nil
+}
+
+func maxKey(m map[int]lyrics.Line) int {
+	result := -1
+	for k := range m {
+		result = max(result, k)
+	}
+	return result
+}
+
+func applyLineOp(doc *lyrics.Document, op Op) error {
+	switch op.Type {
+	case OpReplaceLine, OpSetText, OpShiftLine, OpShiftTiming:
+	default:
+		return nil
+	}
+	if op.Index < 0 || op.Index >= len(doc.Lines) {
+		return fmt.Errorf("%w: line %d out of range", ErrInvalidPatch, op.Index)
+	}
+	line := &doc.Lines[op.Index]
+	switch op.Type {
+	case OpReplaceLine:
+		if op.Line == nil {
+			return fmt.Errorf("%w: replace_line without line", ErrInvalidPatch)
+		}
+		*line = cloneLine(*op.Line)
+	case OpShiftLine:
+		shiftLine(line, op.DeltaBegin)
+	case OpSetText, OpShiftTiming:
+		if op.Syllable == nil {
+			if op.Type == OpSetText {
+				return fmt.Errorf("%w: set_text without syllable", ErrInvalidPatch)
+			}
+			line.Begin += op.DeltaBegin
+			line.End += op.DeltaEnd
+			return nil
+		}
+		k := *op.Syllable
+		if k < 0 || k >= len(line.Syllables) {
+			return fmt.Errorf("%w: syllable %d out of range in line %d", ErrInvalidPatch, k, op.Index)
+		}
+		if op.Type == OpSetText {
+			line.Syllables[k].Text = op.New
+			return nil
+		}
+		line.Syllables[k].Begin += op.DeltaBegin
+		line.Syllables[k].End += op.DeltaEnd
+	}
+	return nil
+}
+
+func ptr(v int) *int {
+	return &v
+}
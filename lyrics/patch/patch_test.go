@@ -0,0 +1,175 @@
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/xiaowumin-mark/AMLX/lyrics"
+	"github.com/xiaowumin-mark/AMLX/lyrics/ttml"
+)
+
+// 每个单词 500ms 的逐字行
+func wordLine(begin int64, words ...string) lyrics.Line {
+	line := lyrics.Line{Agent: lyrics.AgentMain}
+	for i, word := range words {
+		start := begin + int64(i)*500
+		line.Syllables = append(line.Syllables, lyrics.NewSyllable(word+" ", start, start+500))
+	}
+	line.RecomputeTiming()
+	return line
+}
+
+func baseDocument() *lyrics.Document {
+	doc := &lyrics.Document{Timing: lyrics.TimingWord}
+	doc.SetMeta(lyrics.MetaMusicName, "Song")
+	doc.Lines = []lyrics.Line{
+		wordLine(0, "first", "line"),
+		wordLine(5000, "second", "line"),
+		wordLine(10000, "third", "line"),
+		wordLine(15000, "fourth", "line"),
+	}
+	return doc
+}
+
+// 深拷贝，测试用例只修改副本
+func cloneDocument(t *testing.T, doc *lyrics.Document) *lyrics.Document {
+	t.Helper()
+	clone, err := ttml.Parse(ttml.Marshal(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return clone
+}
+
+func TestDiffApply(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(doc *lyrics.Document)
+		want   Summary
+	}{
+		{"unchanged", func(doc *lyrics.Document) {}, Summary{}},
+		{"add line", func(doc *lyrics.Document) {
+			doc.Lines = append(doc.Lines[:2], append([]lyrics.Line{wordLine(7500, "new", "line")}, doc.Lines[2:]...)...)
+		}, Summary{Added: 1}},
+		{"remove line", func(doc *lyrics.Document) {
+			doc.Lines = append(doc.Lines[:2], doc.Lines[3:]...)
+		}, Summary{Removed: 1}},
+		{"move line", func(doc *lyrics.Document) {
+			doc.Lines = append(doc.Lines[1:], doc.Lines[0])
+		}, Summary{Moved: 1}},
+		{"syllable text", func(doc *lyrics.Document) {
+			doc.Lines[1].Syllables[0].Text = "2nd"
+		}, Summary{TextChanged: 1}},
+		{"shift whole line", func(doc *lyrics.Document) {
+			doc.Lines[2] = wordLine(10300, "third", "line")
+		}, Summary{Shifted: 1}},
+		{"shift one syllable", func(doc *lyrics.Document) {
+			doc.Lines[3].Syllables[1].End += 200
+			doc.Lines[3].RecomputeTiming()
+		}, Summary{Shifted: 2}}, // 行结束时间与音节各一个
+		{"text and timing in one line", func(doc *lyrics.Document) {
+			doc.Lines[0].Syllables[1].Text = "verse"
+			doc.Lines[0].Syllables[0].Begin += 100
+			doc.Lines[0].RecomputeTiming()
+		}, Summary{TextChanged: 1, Shifted: 2}},
+		{"translation replaces line", func(doc *lyrics.Document) {
+			doc.Lines[1].Translations = []lyrics.Annotation{{Lang: "zh-CN", Text: "第二行"}}
+		}, Summary{Replaced: 1}},
+		{"head", func(doc *lyrics.Document) {
+			doc.SetMeta(lyrics.MetaNCMMusicID, "1")
+		}, Summary{HeadChanged: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := baseDocument()
+			target := cloneDocument(t, base)
+			tt.change(target)
+
+			p := Diff(base, target)
+			if got := p.Summary(); got != tt.want {
+				t.Fatalf("summary %+v, want %+v (ops %+v)", got, tt.want, p.Ops)
+			}
+			if p.Empty() != (tt.want == Summary{}) {
+				t.Fatalf("Empty() = %v", p.Empty())
+			}
+			if p.BaseHash != Hash(base) || p.TargetHash != Hash(target) {
+				t.Fatal("patch hashes do not match the documents")
+			}
+
+			// 节点收到的是 JSON
+			data, err := json.Marshal(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var shipped Patch
+			if err := json.Unmarshal(data, &shipped); err != nil {
+				t.Fatal(err)
+			}
+			result, err := Apply(base, &shipped)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := ttml.MarshalString(result), ttml.MarshalString(target); got != want {
+				t.Fatalf("applied result differs:\ngot  %s\nwant %s", got, want)
+			}
+		})
+	}
+}
+
+func TestApplyRejects(t *testing.T) {
+	base := baseDocument()
+	target := cloneDocument(t, base)
+	target.Lines[1].Syllables[0].Text = "2nd"
+	other := cloneDocument(t, base)
+	other.Lines[0].Syllables[0].Text = "1st"
+
+	tests := []struct {
+		name   string
+		base   *lyrics.Document
+		tamper func(p *Patch)
+		want   error
+	}{
+		{"wrong base", other, func(p *Patch) {}, ErrBaseMismatch},
+		{"wrong target hash", base, func(p *Patch) { p.TargetHash = Hash(other) }, ErrTargetMismatch},
+		{"altered op", base, func(p *Patch) { p.Ops[0].New = "two" }, ErrTargetMismatch},
+		{"unknown version", base, func(p *Patch) { p.Version = FormatVersion + 1 }, ErrInvalidPatch},
+		{"line out of range", base, func(p *Patch) { p.Ops[0].Index = len(base.Lines) }, ErrInvalidPatch},
+		{"syllable out of range", base, func(p *Patch) { p.Ops[0].Syllable = ptr(9) }, ErrInvalidPatch},
+		{"source line out of range", base, func(p *Patch) {
+			p.Ops = append(p.Ops, Op{Type: OpRemoveLine, From: ptr(len(base.Lines))})
+		}, ErrInvalidPatch},
+		{"line removed twice", base, func(p *Patch) {
+			p.Ops = append(p.Ops, Op{Type: OpRemoveLine, From: ptr(0)}, Op{Type: OpRemoveLine, From: ptr(0)})
+		}, ErrInvalidPatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := Diff(base, target)
+			tt.tamper(p)
+			if _, err := Apply(tt.base, p); !errors.Is(err, tt.want) {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+	if _, err := Apply(base, nil); !errors.Is(err, ErrInvalidPatch) {
+		t.Fatalf("nil patch: got %v, want ErrInvalidPatch", err)
+	}
+}
+
+// 规范哈希是规范 TTML 的 SHA-256，与 CDN 的 VersionHash 一致
+func TestHash(t *testing.T) {
+	base := baseDocument()
+	if got := Hash(base); len(got) != 64 || strings.ToLower(got) != got {
+		t.Fatalf("hash %q", got)
+	}
+	if Hash(base) != Hash(cloneDocument(t, base)) {
+		t.Fatal("hash changed after a parse round trip")
+	}
+	changed := cloneDocument(t, base)
+	changed.Lines[0].Syllables[0].End++
+	if Hash(changed) == Hash(base) {
+		t.Fatal("timing change did not change the hash")
+	}
+}
@@ -0,0 +1,62 @@
+package qrc
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var ErrInvalidCiphertext = errors.New("qrc: ciphertext length is not a multiple of 8")
+
+// QQ 音乐接口返回的 QRC 使用 3DES-ECB 加密 zlib 压缩后的 XML，DES 为 QQ 音乐的变体（见 des.go）
+var tripleDESKey = []byte("!@#)(*$%123ZXC!@!@#)(NHL")
+
+// 解密 QRC 容器，返回 XML 文本
+func Decrypt(data []byte) (string, error) {
+	if len(data) == 0 || len(data)%blockSize != 0 {
+		return "", ErrInvalidCiphertext
+	}
+	block := newQRCCipher()
+	plain := make([]byte, len(data))
+	for i := 0; i < len(data); i += blockSize {
+		block.Decrypt(plain[i:i+blockSize], data[i:i+blockSize])
+	}
+
+	reader, err := zlib.NewReader(bytes.NewReader(plain))
+	if err != nil {
+		return "", fmt.Errorf("qrc: %w", err)
+	}
+	defer reader.Close()
+	// zlib 在校验和之后停止读取，末尾的块填充会被忽略
+	text, err := io.ReadAll(reader)
+	if err != nil {
+		return "", fmt.Errorf("qrc: %w", err)
+	}
+	return string(bytes.TrimPrefix(text, []byte("\ufeff"))), nil
+}
+
+// 加密 QRC 容器
+func Encrypt(xmlText []byte) ([]byte, error) {
+	var compressed bytes.Buffer
+	writer := zlib.NewWriter(&compressed)
+	if _, err := writer.Write(xmlText); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	// 零填充到块大小
+	if rem := compressed.Len() % blockSize; rem != 0 {
+		compressed.Write(make([]byte, blockSize-rem))
+	}
+
+	block := newQRCCipher()
+	plain := compressed.Bytes()
+	out := make([]byte, len(plain))
+	for i := 0; i < len(plain); i += blockSize {
+		block.Encrypt(out[i:i+blockSize], plain[i:i+blockSize])
+	}
+	return out, nil
+}
@@ -0,0 +1,215 @@
+package qrc
+
+import "encoding/binary"
+
+// QQ 音乐客户端使用的 DES 与 FIPS 46-3 有两处不同，crypto/des 无法解密 QRC：
+//   - 分组与密钥按两个小端 32 位整数读写，即每 4 字节内字节序相反
+//   - S2、S4 各有一个表项与标准不同（见 qqSBoxes）
+// 其余置换表、轮数与 3DES 的 EDE 组合方式均与标准一致。
+
+const blockSize = 8
+
+// 以下置换表均为 1 起始、高位在前的位序号
+var (
+	initialPerm = [64]uint8{
+		58, 50, 42, 34, 26, 18, 10, 2, 60, 52, 44, 36, 28, 20, 12, 4,
+		62, 54, 46, 38, 30, 22, 14, 6, 64, 56, 48, 40, 32, 24, 16, 8,
+		57, 49, 41, 33, 25, 17, 9, 1, 59, 51, 43, 35, 27, 19, 11, 3,
+		61, 53, 45, 37, 29, 21, 13, 5, 63, 55, 47, 39, 31, 23, 15, 7,
+	}
+	finalPerm = [64]uint8{
+		40, 8, 48, 16, 56, 24, 64, 32, 39, 7, 47, 15, 55, 23, 63, 31,
+		38, 6, 46, 14, 54, 22, 62, 30, 37, 5, 45, 13, 53, 21, 61, 29,
+		36, 4, 44, 12, 52, 20, 60, 28, 35, 3, 43, 11, 51, 19, 59, 27,
+		34, 2, 42, 10, 50, 18, 58, 26, 33, 1, 41, 9, 49, 17, 57, 25,
+	}
+	expansion = [48]uint8{
+		32, 1, 2, 3, 4, 5, 4, 5, 6, 7, 8, 9,
+		8, 9, 10, 11, 12, 13, 12, 13, 14, 15, 16, 17,
+		16, 17, 18, 19, 20, 21, 20, 21, 22, 23, 24, 25,
+		24, 25, 26, 27, 28, 29, 28, 29, 30, 31, 32, 1,
+	}
+	roundPerm = [32]uint8{
+		16, 7, 20, 21, 29, 12, 28, 17, 1, 15, 23, 26, 5, 18, 31, 10,
+		2, 8, 24, 14, 32, 27, 3, 9, 19, 13, 30, 6, 22, 11, 4, 25,
+	}
+	keyPerm1 = [56]uint8{
+		57, 49, 41, 33, 25, 17, 9, 1, 58, 50, 42, 34, 26, 18,
+		10, 2, 59, 51, 43, 35, 27, 19, 11, 3, 60, 52, 44, 36,
+		63, 55, 47, 39, 31, 23, 15, 7, 62, 54, 46, 38, 30, 22,
+		14, 6, 61, 53, 45, 37, 29, 21, 13, 5, 28, 20, 12, 4,
+	}
+	keyPerm2 = [48]uint8{
+		14, 17, 11, 24, 1, 5, 3, 28, 15, 6, 21, 10,
+		23, 19, 12, 4, 26, 8, 16, 7, 27, 20, 13, 2,
+		41, 52, 31, 37, 47, 55, 30, 40, 51, 45, 33, 48,
+		44, 49, 39, 56, 34, 53, 46, 42, 50, 36, 29, 32,
+	}
+	keyShifts = [16]uint8{1, 1, 2, 2, 2, 2, 2, 2, 1, 2, 2, 2, 2, 2, 2, 1}
+)
+
+type sBoxes [8][64]uint8
+
+// 标准 S 盒，按行（外侧两位）排列
+var standardSBoxes = sBoxes{
+	{
+		14, 4, 13, 1, 2, 15, 11, 8, 3, 10, 6, 12, 5, 9, 0, 7,
+		0, 15, 7, 4, 14, 2, 13, 1, 10, 6, 12, 11, 9, 5, 3, 8,
+		4, 1, 14, 8, 13, 6, 2, 11, 15, 12, 9, 7, 3, 10, 5, 0,
+		15, 12, 8, 2, 4, 9, 1, 7, 5, 11, 3, 14, 10, 0, 6, 13,
+	},
+	{
+		15, 1, 8, 14, 6, 11, 3, 4, 9, 7, 2, 13, 12, 0, 5, 10,
+		3, 13, 4, 7, 15, 2, 8, 14, 12, 0, 1, 10, 6, 9, 11, 5,
+		0, 14, 7, 11, 10, 4, 13, 1, 5, 8, 12, 6, 9, 3, 2, 15,
+		13, 8, 10, 1, 3, 15, 4, 2, 11, 6, 7, 12, 0, 5, 14, 9,
+	},
+	{
+		10, 0, 9, 14, 6, 3, 15, 5, 1, 13, 12, 7, 11, 4, 2, 8,
+		13, 7, 0, 9, 3, 4, 6, 10, 2, 8, 5, 14, 12, 11, 15, 1,
+		13, 6, 4, 9, 8, 15, 3, 0, 11, 1, 2, 12, 5, 10, 14, 7,
+		1, 10, 13, 0, 6, 9, 8, 7, 4, 15, 14, 3, 11, 5, 2, 12,
+	},
+	{
+		7, 13, 14, 3, 0, 6, 9, 10, 1, 2, 8, 5, 11, 12, 4, 15,
+		13, 8, 11, 5, 6, 15, 0, 3, 4, 7, 2, 12, 1, 10, 14, 9,
+		10, 6, 9, 0, 12, 11, 7, 13, 15, 1, 3, 14, 5, 2, 8, 4,
+		3, 15, 0, 6, 10, 1, 13, 8, 9, 4, 5, 11, 12, 7, 2, 14,
+	},
+	{
+		2, 12, 4, 1, 7, 10, 11, 6, 8, 5, 3, 15, 13, 0, 14, 9,
+		14, 11, 2, 12, 4, 7, 13, 1, 5, 0, 15, 10, 3, 9, 8, 6,
+		4, 2, 1, 11, 10, 13, 7, 8, 15, 9, 12, 5, 6, 3, 0, 14,
+		11, 8, 12, 7, 1, 14, 2, 13, 6, 15, 0, 9, 10, 4, 5, 3,
+	},
+	{
+		12, 1, 10, 15, 9, 2, 6, 8, 0, 13, 3, 4, 14, 7, 5, 11,
+		10, 15, 4, 2, 7, 12, 9, 5, 6, 1, 13, 14, 0, 11, 3, 8,
+		9, 14, 15, 5, 2, 8, 12, 3, 7, 0, 4, 10, 1, 13, 11, 6,
+		4, 3, 2, 12, 9, 5, 15, 10, 11, 14, 1, 7, 6, 0, 8, 13,
+	},
+	{
+		4, 11, 2, 14, 15, 0, 8, 13, 3, 12, 9, 7, 5, 10, 6, 1,
+		13, 0, 11, 7, 4, 9, 1, 10, 14, 3, 5, 12, 2, 15, 8, 6,
+		1, 4, 11, 13, 12, 3, 7, 14, 10, 15, 6, 8, 0, 5, 9, 2,
+		6, 11, 13, 8, 1, 4, 10, 7, 9, 5, 0, 15, 14, 2, 3, 12,
+	},
+	{
+		13, 2, 8, 4, 6, 15, 11, 1, 10, 9, 3, 14, 5, 0, 12, 7,
+		1, 15, 13, 8, 10, 3, 7, 4, 12, 5, 6, 11, 0, 14, 9, 2,
+		7, 11, 4, 1, 9, 12, 14, 2, 0, 6, 10, 13, 15, 3, 5, 8,
+		2, 1, 14, 7, 4, 10, 8, 13, 15, 12, 9, 0, 3, 5, 6, 11,
+	},
+}
+
+// QQ 音乐的 S 盒：S2 第 2 行第 8 列为 15（标准为 14），S4 第 4 行第 6 列为 10（标准为 1）
+var qqSBoxes = func() sBoxes {
+	boxes := standardSBoxes
+	boxes[1][1*16+7] = 15
+	boxes[3][3*16+5] = 10
+	return boxes
+}()
+
+// 单层 DES
+type desCipher struct {
+	boxes   *sBoxes
+	subkeys [16]uint64
+}
+
+func newDESCipher(key uint64, boxes *sBoxes) *desCipher {
+	c := &desCipher{boxes: boxes}
+	cd := permute(key, 64, keyPerm1[:])
+	left, right := cd>>28, cd&0x0fffffff
+	for i, shift := range keyShifts {
+		left = (left<<shift | left>>(28-shift)) & 0x0fffffff
+		right = (right<<shift | right>>(28-shift)) & 0x0fffffff
+		c.subkeys[i] = permute(left<<28|right, 56, keyPerm2[:])
+	}
+	return c
+}
+
+func (c *desCipher) crypt(block uint64, decrypt bool) uint64 {
+	block = permute(block, 64, initialPerm[:])
+	left, right := block>>32, block&0xffffffff
+	for i := 0; i < 16; i++ {
+		subkey := c.subkeys[i]
+		if decrypt {
+			subkey = c.subkeys[15-i]
+		}
+		left, right = right, left^c.feistel(right, subkey)
+	}
+	return permute(right<<32|left, 64, finalPerm[:])
+}
+
+func (c *desCipher) feistel(half, subkey uint64) uint64 {
+	expanded := permute(half, 32, expansion[:]) ^ subkey
+	var out uint64
+	for i := 0; i < 8; i++ {
+		six := expanded >> (42 - 6*i) & 0x3f
+		row := six>>4&0x02 | six&0x01
+		col := six >> 1 & 0x0f
+		out = out<<4 | uint64(c.boxes[i][row*16+col])
+	}
+	return permute(out, 32, roundPerm[:])
+}
+
+// 按置换表取位，in 的宽度为 width 位
+func permute(in uint64, width int, table []uint8) uint64 {
+	var out uint64
+	for _, pos := range table {
+		out = out<<1 | in>>(width-int(pos))&1
+	}
+	return out
+}
+
+// 3DES（EDE），密钥为 24 字节
+type tripleDES struct {
+	ciphers [3]*desCipher
+	// 分组按 QQ 音乐的字节序读写
+	littleEndian bool
+}
+
+func newTripleDES(key []byte, boxes *sBoxes, littleEndian bool) *tripleDES {
+	t := &tripleDES{littleEndian: littleEndian}
+	for i := range t.ciphers {
+		t.ciphers[i] = newDESCipher(t.load(key[i*blockSize:]), boxes)
+	}
+	return t
+}
+
+// QRC 使用的 3DES
+func newQRCCipher() *tripleDES {
+	return newTripleDES(tripleDESKey, &qqSBoxes, true)
+}
+
+func (t *tripleDES) Encrypt(dst, src []byte) {
+	block := t.load(src)
+	block = t.ciphers[0].crypt(block, false)
+	block = t.ciphers[1].crypt(block, true)
+	block = t.ciphers[2].crypt(block, false)
+	t.store(dst, block)
+}
+
+func (t *tripleDES) Decrypt(dst, src []byte) {
+	block := t.load(src)
+	block = t.ciphers[2].crypt(block, true)
+	block = t.ciphers[1].crypt(block, false)
+	block = t.ciphers[0].crypt(block, true)
+	t.store(dst, block)
+}
+
+func (t *tripleDES) load(b []byte) uint64 {
+	if t.littleEndian {
+		return uint64(binary.LittleEndian.Uint32(b))<<32 | uint64(binary.LittleEndian.Uint32(b[4:]))
+	}
+	return binary.BigEndian.Uint64(b)
+}
+
+func (t *tripleDES) store(b []byte, block uint64) {
+	if t.littleEndian {
+		binary.LittleEndian.PutUint32(b, uint32(block>>32))
+		binary.LittleEndian.PutUint32(b[4:], uint32(block))
+		return
+	}
+	binary.BigEndian.PutUint64(b, block)
+}
@@ -0,0 +1,67 @@
+package qrc
+
+import (
+	"bytes"
+	"crypto/des"
+	"math/rand"
+	"testing"
+)
+
+// 使用标准 S 盒与大端字节序时必须与 crypto/des 一致，以此校验全部置换表
+func TestTripleDESMatchesStandard(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 200; i++ {
+		key := make([]byte, 24)
+		src := make([]byte, blockSize)
+		rng.Read(key)
+		rng.Read(src)
+
+		want, err := des.NewTripleDESCipher(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := newTripleDES(key, &standardSBoxes, false)
+
+		wantOut, gotOut := make([]byte, blockSize), make([]byte, blockSize)
+		want.Encrypt(wantOut, src)
+		got.Encrypt(gotOut, src)
+		if !bytes.Equal(gotOut, wantOut) {
+			t.Fatalf("encrypt key=%x src=%x: got %x, want %x", key, src, gotOut, wantOut)
+		}
+		want.Decrypt(wantOut, src)
+		got.Decrypt(gotOut, src)
+		if !bytes.Equal(gotOut, wantOut) {
+			t.Fatalf("decrypt key=%x src=%x: got %x, want %x", key, src, gotOut, wantOut)
+		}
+	}
+}
+
+func TestQRCCipherRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	block := newQRCCipher()
+	src, enc, dec := make([]byte, blockSize), make([]byte, blockSize), make([]byte, blockSize)
+	for i := 0; i < 200; i++ {
+		rng.Read(src)
+		block.Encrypt(enc, src)
+		block.Decrypt(dec, enc)
+		if !bytes.Equal(dec, src) {
+			t.Fatalf("round trip %x: got %x", src, dec)
+		}
+	}
+}
+
+// QQ 音乐的变体与标准 3DES 的输出不同，用 crypto/des 解密 QRC 会得到乱码
+func TestQRCCipherDiffersFromStandard(t *testing.T) {
+	standard, err := des.NewTripleDESCipher(tripleDESKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block := newQRCCipher()
+	src := []byte("QrcInfos")
+	got, want := make([]byte, blockSize), make([]byte, blockSize)
+	block.Encrypt(got, src)
+	standard.Encrypt(want, src)
+	if bytes.Equal(got, want) {
+		t.Fatalf("QRC cipher matches standard 3DES for %q", src)
+	}
+}
@@ -0,0 +1,193 @@
+// Package qrc 在 QQ 音乐逐字歌词（QRC）与歌词 AST 之间转换。
+//
+// 解密后的 QRC 是一个 XML 容器，歌词位于 Lyric_1 的 LyricContent 属性中：
+// [行开始,行时长]字(字开始,字时长)字(字开始,字时长)...，时间均为绝对毫秒。
+package qrc
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/xiaowumin-mark/AMLX/lyrics"
+)
+
+var ErrNoLyricContent = errors.New("qrc: LyricContent not found")
+
+var (
+	linePattern = regexp.MustCompile(`^\[(\d+),(\d+)\]`)
+	wordPattern = regexp.MustCompile(`\((\d+),(\d+)\)`)
+	tagPattern  = regexp.MustCompile(`^\[([A-Za-z]+):(.*)\]$`)
+)
+
+// 解析 QRC，支持完整的 XML 容器或仅 LyricContent 文本
+func Parse(content string) (*lyrics.Document, error) {
+	text := content
+	if strings.Contains(content, "<QrcInfos") {
+		extracted, err := lyricContent(content)
+		if err != nil {
+			return nil, err
+		}
+		text = extracted
+	}
+
+	doc := &lyrics.Document{Timing: lyrics.TimingWord}
+	var offset int64
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	number := 0
+	for scanner.Scan() {
+		number++
+		raw := strings.TrimSpace(scanner.Text())
+		if raw == "" {
+			continue
+		}
+		match := linePattern.FindStringSubmatch(raw)
+		if match == nil {
+			if tag := tagPattern.FindStringSubmatch(raw); tag != nil {
+				applyTag(doc, &offset, tag[1], strings.TrimSpace(tag[2]))
+			}
+			continue
+		}
+		begin, _ := strconv.ParseInt(match[1], 10, 64)
+		duration, _ := strconv.ParseInt(match[2], 10, 64)
+		line := lyrics.Line{Begin: begin, End: begin + duration}
+
+		rest := raw[len(match[0]):]
+		words := wordPattern.FindAllStringSubmatchIndex(rest, -1)
+		if len(words) == 0 {
+			return nil, fmt.Errorf("qrc: line %d: no word timings", number)
+		}
+		// 单词文本位于其时间标记之前
+		start := 0
+		for _, word := range words {
+			wordBegin, _ := strconv.ParseInt(rest[word[2]:word[3]], 10, 64)
+			wordDuration, _ := strconv.ParseInt(rest[word[4]:word[5]], 10, 64)
+			text := rest[start:word[0]]
+			start = word[1]
+			if strings.TrimSpace(text) == "" {
+				// 空白单独带时间时并入上一个音节
+				if n := len(line.Syllables); n > 0 {
+					line.Syllables[n-1].Trailing += text
+				}
+				continue
+			}
+			line.Syllables = append(line.Syllables, lyrics.NewSyllable(text, wordBegin, wordBegin+wordDuration))
+		}
+		shiftLine(&line, offset)
+		doc.Lines = append(doc.Lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("qrc: %w", err)
+	}
+	if len(doc.Lines) == 0 {
+		doc.Timing = lyrics.TimingNone
+	}
+	return doc, nil
+}
+
+// 从 XML 容器中取出第一个 LyricContent 属性
+func lyricContent(content string) (string, error) {
+	decoder := xml.NewDecoder(strings.NewReader(content))
+	decoder.Strict = false
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return "", ErrNoLyricContent
+		}
+		if err != nil {
+			return "", fmt.Errorf("qrc: %w", err)
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		for _, attr := range start.Attr {
+			if attr.Name.Local == "LyricContent" {
+				return attr.Value, nil
+			}
+		}
+	}
+}
+
+func applyTag(doc *lyrics.Document, offset *int64, key, value string) {
+	switch strings.ToLower(key) {
+	case "ti":
+		doc.SetMeta(lyrics.MetaMusicName, value)
+	case "ar":
+		doc.SetMeta(lyrics.MetaArtists, strings.Split(value, "/")...)
+	case "al":
+		doc.SetMeta(lyrics.MetaAlbum, value)
+	case "offset":
+		if parsed, err := strconv.ParseInt(strings.TrimPrefix(value, "+"), 10, 64); err == nil {
+			*offset = parsed
+		}
+	}
+}
+
+// 正的 offset 表示歌词提前显示
+func shiftLine(line *lyrics.Line, offset int64) {
+	if offset == 0 {
+		return
+	}
+	shift := func(t int64) int64 { return max(t-offset, 0) }
+	line.Begin, line.End = shift(line.Begin), shift(line.End)
+	for i := range line.Syllables {
+		line.Syllables[i].Begin = shift(line.Syllables[i].Begin)
+		line.Syllables[i].End = shift(line.Syllables[i].End)
+	}
+}
+
+// 生成 QRC 的 LyricContent 文本
+func MarshalContent(doc *lyrics.Document) string {
+	var sb strings.Builder
+	writeTag(&sb, "ti", doc.MetaValue(lyrics.MetaMusicName))
+	writeTag(&sb, "ar", strings.Join(doc.MetaValues(lyrics.MetaArtists), "/"))
+	writeTag(&sb, "al", doc.MetaValue(lyrics.MetaAlbum))
+	sb.WriteString("[offset:0]\n")
+	for _, line := range doc.Lines {
+		if len(line.Syllables) == 0 {
+			continue
+		}
+		fmt.Fprintf(&sb, "[%d,%d]", line.Begin, max(line.End-line.Begin, 0))
+		for _, syllable := range line.Syllables {
+			fmt.Fprintf(&sb, "%s%s(%d,%d)", syllable.Text, syllable.Trailing, syllable.Begin, max(syllable.End-syllable.Begin, 0))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// 生成完整的 QRC XML 容器（未加密）
+func Marshal(doc *lyrics.Document) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(`<?xml version="1.0" encoding="utf-8"?>` + "\n")
+	buf.WriteString("<QrcInfos>\n")
+	buf.WriteString(`<QrcHeadInfo SaveTime="0" Version="100"/>` + "\n")
+	buf.WriteString(`<LyricInfo LyricCount="1">` + "\n")
+	buf.WriteString(`<Lyric_1 LyricType="1" LyricContent="`)
+	buf.WriteString(escapeAttr(MarshalContent(doc)))
+	buf.WriteString(`"/>` + "\n")
+	buf.WriteString("</LyricInfo>\n")
+	buf.WriteString("</QrcInfos>\n")
+	return buf.Bytes()
+}
+
+func writeTag(sb *strings.Builder, key, value string) {
+	if value = strings.TrimSpace(value); value != "" {
+		sb.WriteString("[" + key + ":" + value + "]\n")
+	}
+}
+
+// 转义属性值，保留换行以贴近 QQ 音乐的原始文件
+var attrEscaper = strings.NewReplacer(`&`, "&amp;", `<`, "&lt;", `>`, "&gt;", `"`, "&quot;")
+
+func escapeAttr(value string) string {
+	return attrEscaper.Replace(value)
+}
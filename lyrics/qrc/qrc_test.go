@@ -0,0 +1,65 @@
+package qrc
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/xiaowumin-mark/AMLX/lyrics/internal/golden"
+	"github.com/xiaowumin-mark/AMLX/lyrics/ttml"
+)
+
+// testdata/sample.xml 为解密后的 QRC 容器，sample.qrc 为对应的密文，sample.ttml 为转换结果。
+// sample.qrc 是固定文件，测试不会重写；它由本包生成而非客户端抓包，只能发现解密流程的回归
+func TestDecryptFixture(t *testing.T) {
+	plain := golden.Read(t, "sample.xml")
+	text, err := Decrypt(golden.Read(t, "sample.qrc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != string(plain) {
+		t.Fatalf("decrypted text differs from sample.xml:\n%s", text)
+	}
+}
+
+func TestEncryptRoundTrip(t *testing.T) {
+	plain := golden.Read(t, "sample.xml")
+	data, err := Encrypt(plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, err := Decrypt(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != string(plain) {
+		t.Fatalf("round trip differs from sample.xml:\n%s", text)
+	}
+}
+
+func TestDecryptRejectsPartialBlock(t *testing.T) {
+	if _, err := Decrypt(make([]byte, 12)); err != ErrInvalidCiphertext {
+		t.Fatalf("got %v, want ErrInvalidCiphertext", err)
+	}
+}
+
+func TestParseGolden(t *testing.T) {
+	doc, err := Parse(string(golden.Read(t, "sample.xml")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden.Check(t, "sample.ttml", ttml.Marshal(doc))
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	doc, err := Parse(string(golden.Read(t, "sample.xml")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := Parse(string(Marshal(doc)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(again, doc) {
+		t.Fatalf("round trip changed the document:\ngot  %+v\nwant %+v", again, doc)
+	}
+}
@@ -0,0 +1,376 @@
+// Package ttml 解析与生成 AMLL 风格的 TTML 歌词。
+package ttml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/xiaowumin-mark/AMLX/lyrics"
+)
+
+var ErrNotTTML = errors.New("ttml: root element is not <tt>")
+
+// ttm:role 取值
+const (
+	roleBackground  = "x-bg"
+	roleTranslation = "x-translation"
+	roleRoman       = "x-roman"
+)
+
+// 简单的 XML 节点树，便于按结构遍历
+type node struct {
+	name     xml.Name
+	attrs    []xml.Attr
+	children []*node
+	text     string
+	isText   bool
+}
+
+// 解析 TTML 文本
+func ParseString(content string) (*lyrics.Document, error) {
+	return Parse([]byte(content))
+}
+
+// 解析 TTML
+func Parse(data []byte) (*lyrics.Document, error) {
+	root, err := buildTree(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if root == nil || root.name.Local != "tt" {
+		return nil, ErrNotTTML
+	}
+
+	doc := &lyrics.Document{
+		Language: attr(root, "lang"),
+		Timing:   attr(root, "timing"),
+	}
+	translations := map[string][]lyrics.Annotation{}
+	romanizations := map[string][]lyrics.Annotation{}
+
+	if head := child(root, "head"); head != nil {
+		if metadata := child(head, "metadata"); metadata != nil {
+			parseMetadata(metadata, doc, translations, romanizations)
+		}
+	}
+	if body := child(root, "body"); body != nil {
+		if err := parseBody(body, doc); err != nil {
+			return nil, err
+		}
+	}
+
+	// iTunesMetadata 中按 itunes:key 关联的翻译 / 音译
+	for i := range doc.Lines {
+		line := &doc.Lines[i]
+		if line.Key == "" {
+			continue
+		}
+		line.Translations = mergeAnnotations(line.Translations, translations[line.Key])
+		line.Romanizations = mergeAnnotations(line.Romanizations, romanizations[line.Key])
+	}
+
+	if doc.Timing == "" {
+		doc.Timing = inferTiming(doc)
+	}
+	return doc, nil
+}
+
+func buildTree(r io.Reader) (*node, error) {
+	decoder := xml.NewDecoder(r)
+	decoder.Strict = true
+
+	var root *node
+	var stack []*node
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ttml: %w", err)
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			n := &node{name: t.Name, attrs: append([]xml.Attr(nil), t.Attr...)}
+			if len(stack) == 0 {
+				if root != nil {
+					return nil, fmt.Errorf("ttml: multiple root elements")
+				}
+				root = n
+			} else {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, n)
+			}
+			stack = append(stack, n)
+		case xml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			if len(stack) == 0 {
+				continue
+			}
+			parent := stack[len(stack)-1]
+			if last := len(parent.children) - 1; last >= 0 && parent.children[last].isText {
+				parent.children[last].text += string(t)
+			} else {
+				parent.children = append(parent.children, &node{isText: true, text: string(t)})
+			}
+		}
+	}
+	return root, nil
+}
+
+func parseMetadata(metadata *node, doc *lyrics.Document, translations, romanizations map[string][]lyrics.Annotation) {
+	for _, n := range metadata.children {
+		if n.isText {
+			continue
+		}
+		switch n.name.Local {
+		case "agent":
+			agent := lyrics.Agent{ID: attr(n, "id"), Type: attr(n, "type")}
+			if name := child(n, "name"); name != nil {
+				agent.Name = strings.TrimSpace(textContent(name))
+			}
+			doc.Agents = append(doc.Agents, agent)
+		case "meta":
+			doc.Metadata = append(doc.Metadata, lyrics.Meta{Key: attr(n, "key"), Value: attr(n, "value")})
+		case "iTunesMetadata":
+			parseITunesMetadata(n, doc, translations, romanizations)
+		}
+	}
+}
+
+func parseITunesMetadata(n *node, doc *lyrics.Document, translations, romanizations map[string][]lyrics.Annotation) {
+	for _, section := range n.children {
+		if section.isText {
+			continue
+		}
+		switch section.name.Local {
+		case "songwriters":
+			for _, writer := range children(section, "songwriter") {
+				if name := strings.TrimSpace(textContent(writer)); name != "" {
+					doc.Songwriters = append(doc.Songwriters, name)
+				}
+			}
+		case "translations":
+			collectKeyedTexts(section, "translation", translations)
+		case "transliterations":
+			collectKeyedTexts(section, "transliteration", romanizations)
+		}
+	}
+}
+
+func collectKeyedTexts(section *node, itemName string, out map[string][]lyrics.Annotation) {
+	for _, item := range children(section, itemName) {
+		lang := attr(item, "lang")
+		for _, text := range children(item, "text") {
+			key := attr(text, "for")
+			if key == "" {
+				continue
+			}
+			out[key] = append(out[key], lyrics.Annotation{Lang: lang, Text: strings.TrimSpace(textContent(text))})
+		}
+	}
+}
+
+func parseBody(body *node, doc *lyrics.Document) error {
+	for _, n := range body.children {
+		if n.isText {
+			continue
+		}
+		switch n.name.Local {
+		case "div":
+			part := attr(n, "songPart")
+			for _, p := range children(n, "p") {
+				if err := appendLine(doc, p, part); err != nil {
+					return err
+				}
+			}
+		case "p":
+			if err := appendLine(doc, n, ""); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func appendLine(doc *lyrics.Document, p *node, part string) error {
+	line := lyrics.Line{
+		Key:      attr(p, "key"),
+		Agent:    attr(p, "agent"),
+		SongPart: part,
+	}
+	if err := parseLine(p, &line); err != nil {
+		return fmt.Errorf("ttml: line %d: %w", len(doc.Lines)+1, err)
+	}
+	doc.Lines = append(doc.Lines, line)
+	return nil
+}
+
+// 解析 <p> 或 x-bg <span> 的内容
+func parseLine(n *node, line *lyrics.Line) error {
+	hasBegin, hasEnd := false, false
+	if value := attr(n, "begin"); value != "" {
+		begin, err := ParseTime(value)
+		if err != nil {
+			return err
+		}
+		line.Begin, hasBegin = begin, true
+	}
+	if value := attr(n, "end"); value != "" {
+		end, err := ParseTime(value)
+		if err != nil {
+			return err
+		}
+		line.End, hasEnd = end, true
+	}
+
+	for _, c := range n.children {
+		if c.isText {
+			appendText(line, c.text)
+			continue
+		}
+		if c.name.Local != "span" {
+			continue
+		}
+		switch attr(c, "role") {
+		case roleBackground:
+			background := &lyrics.Line{}
+			if err := parseLine(c, background); err != nil {
+				return err
+			}
+			line.Background = background
+		case roleTranslation:
+			line.Translations = append(line.Translations, lyrics.Annotation{Lang: attr(c, "lang"), Text: textContent(c)})
+		case roleRoman:
+			line.Romanizations = append(line.Romanizations, lyrics.Annotation{Lang: attr(c, "lang"), Text: textContent(c)})
+		default:
+			syllable := lyrics.Syllable{Text: textContent(c), Begin: line.Begin, End: line.End}
+			if value := attr(c, "begin"); value != "" {
+				begin, err := ParseTime(value)
+				if err != nil {
+					return err
+				}
+				syllable.Begin = begin
+			}
+			if value := attr(c, "end"); value != "" {
+				end, err := ParseTime(value)
+				if err != nil {
+					return err
+				}
+				syllable.End = end
+			}
+			line.Syllables = append(line.Syllables, syllable)
+		}
+	}
+
+	if !hasBegin || !hasEnd {
+		begin, end := line.Begin, line.End
+		line.RecomputeTiming()
+		if hasBegin {
+			line.Begin = begin
+		}
+		if hasEnd {
+			line.End = end
+		}
+	}
+	return nil
+}
+
+// 处理 span 之间的文本：换行缩进视为排版忽略，其余归入前一个音节的 Trailing；
+// 逐行歌词没有 span，整段文本作为一个音节
+func appendText(line *lyrics.Line, text string) {
+	if strings.TrimSpace(text) == "" {
+		if len(line.Syllables) == 0 || strings.ContainsAny(text, "\r\n") {
+			return
+		}
+		last := &line.Syllables[len(line.Syllables)-1]
+		last.Trailing += text
+		return
+	}
+	if len(line.Syllables) == 0 {
+		line.Syllables = append(line.Syllables, lyrics.Syllable{
+			Text:  strings.TrimSpace(text),
+			Begin: line.Begin,
+			End:   line.End,
+		})
+		return
+	}
+	last := &line.Syllables[len(line.Syllables)-1]
+	last.Trailing += text
+}
+
+// 没有 itunes:timing 时，根据是否存在逐字 span 推断
+func inferTiming(doc *lyrics.Document) string {
+	if len(doc.Lines) == 0 {
+		return lyrics.TimingNone
+	}
+	for _, line := range doc.Lines {
+		if len(line.Syllables) > 1 {
+			return lyrics.TimingWord
+		}
+	}
+	return lyrics.TimingLine
+}
+
+func mergeAnnotations(inline, keyed []lyrics.Annotation) []lyrics.Annotation {
+	for _, annotation := range keyed {
+		duplicate := false
+		for _, existing := range inline {
+			if strings.EqualFold(existing.Lang, annotation.Lang) {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			inline = append(inline, annotation)
+		}
+	}
+	return inline
+}
+
+// 按本地名读取属性，忽略命名空间前缀（xml:id / ttm:agent / itunes:key ...）
+func attr(n *node, local string) string {
+	for _, a := range n.attrs {
+		if a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+func child(n *node, local string) *node {
+	for _, c := range n.children {
+		if !c.isText && c.name.Local == local {
+			return c
+		}
+	}
+	return nil
+}
+
+func children(n *node, local string) []*node {
+	var result []*node
+	for _, c := range n.children {
+		if !c.isText && c.name.Local == local {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+func textContent(n *node) string {
+	if n.isText {
+		return n.text
+	}
+	var sb strings.Builder
+	for _, c := range n.children {
+		sb.WriteString(textContent(c))
+	}
+	return sb.String()
+}
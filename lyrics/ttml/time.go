@@ -0,0 +1,86 @@
+package ttml
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// 解析 TTML 时间表达式，返回毫秒。
+// 支持 hh:mm:ss.fff、mm:ss.fff、ss.fff 以及 12.5s / 1500ms 形式。
+func ParseTime(value string) (int64, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, fmt.Errorf("ttml: empty time")
+	}
+	switch {
+	case strings.HasSuffix(value, "ms"):
+		ms, err := strconv.ParseFloat(strings.TrimSuffix(value, "ms"), 64)
+		if err != nil || ms < 0 {
+			return 0, fmt.Errorf("ttml: invalid time %q", value)
+		}
+		return int64(ms + 0.5), nil
+	case strings.HasSuffix(value, "s") && !strings.Contains(value, ":"):
+		seconds, err := strconv.ParseFloat(strings.TrimSuffix(value, "s"), 64)
+		if err != nil || seconds < 0 {
+			return 0, fmt.Errorf("ttml: invalid time %q", value)
+		}
+		return int64(seconds*1000 + 0.5), nil
+	}
+
+	parts := strings.Split(value, ":")
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("ttml: invalid time %q", value)
+	}
+	seconds, err := parseSeconds(parts[len(parts)-1])
+	if err != nil {
+		return 0, fmt.Errorf("ttml: invalid time %q", value)
+	}
+	total := seconds
+	multiplier := int64(60_000)
+	for i := len(parts) - 2; i >= 0; i-- {
+		n, err := strconv.ParseInt(parts[i], 10, 64)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("ttml: invalid time %q", value)
+		}
+		total += n * multiplier
+		multiplier *= 60
+	}
+	return total, nil
+}
+
+// 解析 ss.fff 形式的秒数为毫秒，避免浮点误差
+func parseSeconds(value string) (int64, error) {
+	whole, frac, _ := strings.Cut(value, ".")
+	seconds, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || seconds < 0 {
+		return 0, fmt.Errorf("invalid seconds")
+	}
+	ms := int64(0)
+	if frac != "" {
+		for _, r := range frac {
+			if r < '0' || r > '9' {
+				return 0, fmt.Errorf("invalid seconds")
+			}
+		}
+		// 补齐或截断到 3 位
+		frac = (frac + "000")[:3]
+		ms, _ = strconv.ParseInt(frac, 10, 64)
+	}
+	return seconds*1000 + ms, nil
+}
+
+// 格式化毫秒为 AMLL 使用的 mm:ss.fff（超过一小时为 h:mm:ss.fff）
+func FormatTime(ms int64) string {
+	if ms < 0 {
+		ms = 0
+	}
+	hours := ms / 3_600_000
+	minutes := ms / 60_000 % 60
+	seconds := ms / 1000 % 60
+	millis := ms % 1000
+	if hours > 0 {
+		return fmt.Sprintf("%d:%02d:%02d.%03d", hours, minutes, seconds, millis)
+	}
+	return fmt.Sprintf("%02d:%02d.%03d", minutes, seconds, millis)
+}
@@ -0,0 +1,157 @@
+package ttml
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/xiaowumin-mark/AMLX/lyrics"
+	"github.com/xiaowumin-mark/AMLX/lyrics/internal/golden"
+)
+
+// testdata/amll.ttml 为按 AMLL TTML Tool 输出格式手写的逐字歌词，覆盖元数据、演唱者、背景人声、翻译与音译；
+// amll.canonical.ttml 为 Marshal 的规范输出，patch.Hash 与 CDN 的 VersionHash 依赖它逐字节稳定
+func parseFixture(t *testing.T) *lyrics.Document {
+	t.Helper()
+	doc, err := Parse(golden.Read(t, "amll.ttml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func TestParseHead(t *testing.T) {
+	doc := parseFixture(t)
+	if doc.Language != "ja" || doc.Timing != lyrics.TimingWord {
+		t.Fatalf("language %q, timing %q", doc.Language, doc.Timing)
+	}
+	metas := []struct {
+		key  string
+		want []string
+	}{
+		{lyrics.MetaMusicName, []string{"サクラ & Spring"}},
+		{lyrics.MetaArtists, []string{"Singer A", "Singer B"}},
+		{lyrics.MetaAlbum, []string{"Seasons"}},
+		{lyrics.MetaISRC, []string{"JPXX02400001"}},
+		{lyrics.MetaNCMMusicID, []string{"186016"}},
+		{lyrics.MetaQQMusicID, []string{"002Zkt5S2z8JZx"}},
+		{lyrics.MetaSpotifyID, []string{"3AJwUDP919kvQ9QcozQPxg"}},
+		{lyrics.MetaAppleMusicID, []string{"1440818839"}},
+		{lyrics.MetaTTMLAuthorGithubLogin, []string{"octocat"}},
+	}
+	for _, tt := range metas {
+		if got := doc.MetaValues(tt.key); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("meta %s: got %q, want %q", tt.key, got, tt.want)
+		}
+	}
+	wantAgents := []lyrics.Agent{
+		{ID: lyrics.AgentMain, Type: "person", Name: "Singer A"},
+		{ID: lyrics.AgentDuet, Type: "person", Name: "Singer B"},
+		{ID: lyrics.AgentGroup, Type: "group"},
+	}
+	if !reflect.DeepEqual(doc.Agents, wantAgents) {
+		t.Errorf("agents %+v", doc.Agents)
+	}
+	if !reflect.DeepEqual(doc.Songwriters, []string{"Writer One", "Writer Two"}) {
+		t.Errorf("songwriters %q", doc.Songwriters)
+	}
+}
+
+func TestParseLines(t *testing.T) {
+	doc := parseFixture(t)
+	if len(doc.Lines) != 3 {
+		t.Fatalf("got %d lines, want 3", len(doc.Lines))
+	}
+
+	first := doc.Lines[0]
+	if first.Key != "L1" || first.Agent != lyrics.AgentMain || first.SongPart != "Verse" || first.Begin != 1000 || first.End != 4000 {
+		t.Errorf("line 1 attributes %+v", first)
+	}
+	if first.Text() != "さくら 舞う" || len(first.Syllables) != 4 || first.Syllables[2].Trailing != " " {
+		t.Errorf("line 1 syllables %+v", first.Syllables)
+	}
+	if tr, ok := first.Translation("zh-CN"); !ok || tr.Text != "樱花飞舞" {
+		t.Errorf("line 1 translation %+v", first.Translations)
+	}
+	if roman, ok := first.Romanization("ja-Latn"); !ok || roman.Text != "sakura mau" {
+		t.Errorf("line 1 romanization %+v", first.Romanizations)
+	}
+
+	second := doc.Lines[1]
+	if second.Agent != lyrics.AgentDuet || second.Text() != "Spring is here" {
+		t.Errorf("line 2 %+v", second)
+	}
+	bg := second.Background
+	if bg == nil {
+		t.Fatal("line 2 has no background vocals")
+	}
+	if bg.Begin != 6500 || bg.End != 8200 || bg.Text() != "(oh yeah)" || len(bg.Translations) != 1 || bg.Translations[0].Text != "（哦 耶）" {
+		t.Errorf("background %+v", bg)
+	}
+	if len(second.Translations) != 1 || second.Translations[0].Text != "春天来了" {
+		t.Errorf("line 2 translations %+v", second.Translations)
+	}
+
+	// 合唱行的翻译与音译来自 iTunesMetadata，按 itunes:key 合并到行上
+	third := doc.Lines[2]
+	if third.Agent != lyrics.AgentGroup || third.SongPart != "Chorus" {
+		t.Errorf("line 3 attributes %+v", third)
+	}
+	wantTranslations := []lyrics.Annotation{{Lang: "zh-CN", Text: "一起歌唱"}, {Lang: "en", Text: "Together we sing"}}
+	if !reflect.DeepEqual(third.Translations, wantTranslations) {
+		t.Errorf("line 3 translations %+v", third.Translations)
+	}
+	if !reflect.DeepEqual(third.Romanizations, []lyrics.Annotation{{Lang: "ja-Latn", Text: "issho ni utau"}}) {
+		t.Errorf("line 3 romanizations %+v", third.Romanizations)
+	}
+}
+
+func TestMarshalGolden(t *testing.T) {
+	golden.Check(t, "amll.canonical.ttml", Marshal(parseFixture(t)))
+}
+
+// 解析 → 生成 → 解析得到同一个文档，再次生成的文本逐字节不变
+func TestRoundTrip(t *testing.T) {
+	doc := parseFixture(t)
+	canonical := Marshal(doc)
+	again, err := Parse(canonical)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(again, doc) {
+		t.Fatalf("round trip changed the document:\ngot  %+v\nwant %+v", again, doc)
+	}
+	if second := Marshal(again); !bytes.Equal(second, canonical) {
+		t.Fatalf("canonical output is not stable:\n%s\n%s", canonical, second)
+	}
+}
+
+func TestParseLineTiming(t *testing.T) {
+	doc, err := ParseString(`<tt xmlns="http://www.w3.org/ns/ttml"><body><div><p begin="1.5s" end="00:03.250">First line</p><p begin="4000ms" end="0:00:05">Second &amp; last</p></div></body></tt>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Timing != lyrics.TimingLine {
+		t.Fatalf("inferred timing %q", doc.Timing)
+	}
+	want := []lyrics.Line{
+		{Begin: 1500, End: 3250, Syllables: []lyrics.Syllable{{Text: "First line", Begin: 1500, End: 3250}}},
+		{Begin: 4000, End: 5000, Syllables: []lyrics.Syllable{{Text: "Second & last", Begin: 4000, End: 5000}}},
+	}
+	if !reflect.DeepEqual(doc.Lines, want) {
+		t.Fatalf("lines %+v", doc.Lines)
+	}
+	again, err := Parse(Marshal(doc))
+	if err != nil || !reflect.DeepEqual(again, doc) {
+		t.Fatalf("line timing round trip: %+v, %v", again, err)
+	}
+}
+
+func TestParseRejectsNonTTML(t *testing.T) {
+	if _, err := ParseString(`<html></html>`); err != ErrNotTTML {
+		t.Fatalf("got %v, want ErrNotTTML", err)
+	}
+	if _, err := ParseString(`<tt><body><p begin="x" end="1s">a</p></body></tt>`); err == nil {
+		t.Fatal("invalid time accepted")
+	}
+}
@@ -0,0 +1,197 @@
+package ttml
+
+import (
+	"encoding/xml"
+	"strings"
+
+	"github.com/xiaowumin-mark/AMLX/lyrics"
+)
+
+// 输出使用的命名空间
+const (
+	NamespaceTTML   = "http://www.w3.org/ns/ttml"
+	NamespaceTTM    = "http://www.w3.org/ns/ttml#metadata"
+	NamespaceITunes = "http://music.apple.com/lyric-ttml-internal"
+	NamespaceAMLL   = "http://www.example.com/ns/amll"
+)
+
+// 生成 TTML 文本
+func MarshalString(doc *lyrics.Document) string {
+	return string(Marshal(doc))
+}
+
+// 生成 AMLL 风格的单行 TTML，与 Parse 互为往返
+func Marshal(doc *lyrics.Document) []byte {
+	w := &writer{}
+	w.raw(`<tt xmlns="` + NamespaceTTML + `" xmlns:ttm="` + NamespaceTTM + `" xmlns:itunes="` + NamespaceITunes + `" xmlns:amll="` + NamespaceAMLL + `"`)
+	if doc.Language != "" {
+		w.attr("xml:lang", doc.Language)
+	}
+	if doc.Timing != "" {
+		w.attr("itunes:timing", doc.Timing)
+	}
+	w.raw(">")
+
+	w.writeHead(doc)
+
+	w.raw("<body")
+	if duration := doc.Duration(); duration > 0 {
+		w.attr("dur", FormatTime(duration))
+	}
+	w.raw(">")
+	// 相邻且 songPart 相同的行放在同一个 div 中
+	for i := 0; i < len(doc.Lines); {
+		part := doc.Lines[i].SongPart
+		j := i
+		for j < len(doc.Lines) && doc.Lines[j].SongPart == part {
+			j++
+		}
+		w.raw("<div")
+		if begin, end := spanOf(doc.Lines[i:j]); end > 0 {
+			w.attr("begin", FormatTime(begin))
+			w.attr("end", FormatTime(end))
+		}
+		if part != "" {
+			w.attr("itunes:songPart", part)
+		}
+		w.raw(">")
+		for k := i; k < j; k++ {
+			w.writeLine(doc, &doc.Lines[k])
+		}
+		w.raw("</div>")
+		i = j
+	}
+	w.raw("</body></tt>")
+	return []byte(w.sb.String())
+}
+
+type writer struct {
+	sb strings.Builder
+}
+
+func (w *writer) raw(s string) {
+	w.sb.WriteString(s)
+}
+
+func (w *writer) text(s string) {
+	_ = xml.EscapeText(&w.sb, []byte(s))
+}
+
+func (w *writer) attr(name, value string) {
+	w.raw(" " + name + `="`)
+	w.text(value)
+	w.raw(`"`)
+}
+
+func (w *writer) writeHead(doc *lyrics.Document) {
+	if len(doc.Agents) == 0 && len(doc.Metadata) == 0 && len(doc.Songwriters) == 0 {
+		return
+	}
+	w.raw("<head><metadata>")
+	for _, agent := range doc.Agents {
+		w.raw("<ttm:agent")
+		if agent.Type != "" {
+			w.attr("type", agent.Type)
+		}
+		w.attr("xml:id", agent.ID)
+		if agent.Name == "" {
+			w.raw("/>")
+			continue
+		}
+		w.raw(`><ttm:name type="full">`)
+		w.text(agent.Name)
+		w.raw("</ttm:name></ttm:agent>")
+	}
+	for _, meta := range doc.Metadata {
+		w.raw("<amll:meta")
+		w.attr("key", meta.Key)
+		w.attr("value", meta.Value)
+		w.raw("/>")
+	}
+	if len(doc.Songwriters) > 0 {
+		w.raw("<iTunesMetadata xmlns=\"http://music.apple.com/lyric-ttml-internal\"><songwriters>")
+		for _, name := range doc.Songwriters {
+			w.raw("<songwriter>")
+			w.text(name)
+			w.raw("</songwriter>")
+		}
+		w.raw("</songwriters></iTunesMetadata>")
+	}
+	w.raw("</metadata></head>")
+}
+
+func (w *writer) writeLine(doc *lyrics.Document, line *lyrics.Line) {
+	w.raw("<p")
+	w.attr("begin", FormatTime(line.Begin))
+	w.attr("end", FormatTime(line.End))
+	if line.Key != "" {
+		w.attr("itunes:key", line.Key)
+	}
+	if line.Agent != "" {
+		w.attr("ttm:agent", line.Agent)
+	}
+	w.raw(">")
+	w.writeContent(doc, line)
+	w.raw("</p>")
+}
+
+// 写出行内容：音节、背景人声、翻译与音译
+func (w *writer) writeContent(doc *lyrics.Document, line *lyrics.Line) {
+	if isPlainLine(doc, line) {
+		w.text(line.Syllables[0].Text)
+		w.text(line.Syllables[0].Trailing)
+	} else {
+		for _, syllable := range line.Syllables {
+			w.raw("<span")
+			w.attr("begin", FormatTime(syllable.Begin))
+			w.attr("end", FormatTime(syllable.End))
+			w.raw(">")
+			w.text(syllable.Text)
+			w.raw("</span>")
+			w.text(syllable.Trailing)
+		}
+	}
+	if bg := line.Background; bg != nil {
+		w.raw(`<span ttm:role="` + roleBackground + `"`)
+		w.attr("begin", FormatTime(bg.Begin))
+		w.attr("end", FormatTime(bg.End))
+		w.raw(">")
+		w.writeContent(doc, bg)
+		w.raw("</span>")
+	}
+	w.writeAnnotations(roleTranslation, line.Translations)
+	w.writeAnnotations(roleRoman, line.Romanizations)
+}
+
+func (w *writer) writeAnnotations(role string, annotations []lyrics.Annotation) {
+	for _, annotation := range annotations {
+		w.raw(`<span ttm:role="` + role + `"`)
+		if annotation.Lang != "" {
+			w.attr("xml:lang", annotation.Lang)
+		}
+		w.raw(">")
+		w.text(annotation.Text)
+		w.raw("</span>")
+	}
+}
+
+// 逐行歌词中与行时间一致的单个音节直接写成文本
+func isPlainLine(doc *lyrics.Document, line *lyrics.Line) bool {
+	if doc.Timing == lyrics.TimingWord || len(line.Syllables) != 1 {
+		return false
+	}
+	syllable := line.Syllables[0]
+	return syllable.Begin == line.Begin && syllable.End == line.End
+}
+
+func spanOf(lines []lyrics.Line) (int64, int64) {
+	if len(lines) == 0 {
+		return 0, 0
+	}
+	begin, end := lines[0].Begin, lines[0].End
+	for _, line := range lines[1:] {
+		begin = min(begin, line.Begin)
+		end = max(end, line.End)
+	}
+	return begin, end
+}
@@ -0,0 +1,119 @@
+// Package yrc 在网易云音乐逐字歌词（YRC）与歌词 AST 之间转换。
+//
+// 行格式：[行开始,行时长](字开始,字时长,0)字(字开始,字时长,0)字...，时间均为绝对毫秒。
+// 以 { 开头的行是 JSON 格式的制作信息。
+package yrc
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/xiaowumin-mark/AMLX/lyrics"
+)
+
+var (
+	linePattern = regexp.MustCompile(`^\[(\d+),(\d+)\]`)
+	wordPattern = regexp.MustCompile(`\((\d+),(\d+),(\d+)\)`)
+)
+
+// 制作信息行
+type creditLine struct {
+	Time    int64 `json:"t"`
+	Content []struct {
+		Text string `json:"tx"`
+	} `json:"c"`
+}
+
+// 制作信息中表示作词的前缀
+var lyricistPrefixes = []string{"作词", "作詞", "Lyricist", "Lyrics by"}
+
+// 解析 YRC
+func Parse(content string) (*lyrics.Document, error) {
+	doc := &lyrics.Document{Timing: lyrics.TimingWord}
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	number := 0
+	for scanner.Scan() {
+		number++
+		raw := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		switch {
+		case raw == "":
+			continue
+		case strings.HasPrefix(raw, "{"):
+			parseCredit(doc, raw)
+			continue
+		}
+
+		match := linePattern.FindStringSubmatch(raw)
+		if match == nil {
+			continue
+		}
+		begin, _ := strconv.ParseInt(match[1], 10, 64)
+		duration, _ := strconv.ParseInt(match[2], 10, 64)
+		line := lyrics.Line{Begin: begin, End: begin + duration}
+
+		rest := raw[len(match[0]):]
+		words := wordPattern.FindAllStringSubmatchIndex(rest, -1)
+		if len(words) == 0 {
+			return nil, fmt.Errorf("yrc: line %d: no word timings", number)
+		}
+		for i, word := range words {
+			wordBegin, _ := strconv.ParseInt(rest[word[2]:word[3]], 10, 64)
+			wordDuration, _ := strconv.ParseInt(rest[word[4]:word[5]], 10, 64)
+			end := len(rest)
+			if i+1 < len(words) {
+				end = words[i+1][0]
+			}
+			line.Syllables = append(line.Syllables, lyrics.NewSyllable(rest[word[1]:end], wordBegin, wordBegin+wordDuration))
+		}
+		doc.Lines = append(doc.Lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("yrc: %w", err)
+	}
+	if len(doc.Lines) == 0 {
+		doc.Timing = lyrics.TimingNone
+	}
+	return doc, nil
+}
+
+func parseCredit(doc *lyrics.Document, raw string) {
+	var credit creditLine
+	if err := json.Unmarshal([]byte(raw), &credit); err != nil || len(credit.Content) < 2 {
+		return
+	}
+	role := strings.TrimSpace(credit.Content[0].Text)
+	for _, prefix := range lyricistPrefixes {
+		if !strings.HasPrefix(role, prefix) {
+			continue
+		}
+		for _, item := range credit.Content[1:] {
+			for _, name := range strings.Split(item.Text, "/") {
+				if name = strings.TrimSpace(name); name != "" {
+					doc.Songwriters = append(doc.Songwriters, name)
+				}
+			}
+		}
+		return
+	}
+}
+
+// 生成 YRC
+func Marshal(doc *lyrics.Document) string {
+	var sb strings.Builder
+	for _, line := range doc.Lines {
+		if len(line.Syllables) == 0 {
+			continue
+		}
+		fmt.Fprintf(&sb, "[%d,%d]", line.Begin, max(line.End-line.Begin, 0))
+		for _, syllable := range line.Syllables {
+			fmt.Fprintf(&sb, "(%d,%d,0)%s%s", syllable.Begin, max(syllable.End-syllable.Begin, 0), syllable.Text, syllable.Trailing)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
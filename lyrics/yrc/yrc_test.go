@@ -0,0 +1,32 @@
+package yrc
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/xiaowumin-mark/AMLX/lyrics/internal/golden"
+	"github.com/xiaowumin-mark/AMLX/lyrics/ttml"
+)
+
+// testdata/sample.yrc 为网易云接口返回的 YRC，sample.ttml 为转换结果
+func TestParseGolden(t *testing.T) {
+	doc, err := Parse(string(golden.Read(t, "sample.yrc")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden.Check(t, "sample.ttml", ttml.Marshal(doc))
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	doc, err := Parse(string(golden.Read(t, "sample.yrc")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := Parse(Marshal(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(again.Lines, doc.Lines) {
+		t.Fatalf("round trip changed the lines:\ngot  %+v\nwant %+v", again.Lines, doc.Lines)
+	}
+}
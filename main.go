@@ -1,9 +1,14 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"slices"
 
 	"github.com/xiaowumin-mark/AMLX/app"
 	"github.com/xiaowumin-mark/AMLX/config"
@@ -26,6 +31,56 @@ func main() {
 		logger.Error("init app failed", "error", err)
 		return
 	}
+	defer application.Close()
+
+	if len(os.Args) > 1 { // 命令行子命令
+		switch os.Args[1] {
+		case "rebuild-search": // 从数据库重建搜索索引，需先停止服务
+			count, err := application.Search.Rebuild(context.Background())
+			if err != nil {
+				logger.Error("rebuild search index failed", "error", err)
+				return
+			}
+			logger.Info("search index rebuilt", "drafts", count)
+		case "rebuild-stats": // 从流转历史重建贡献统计，需先停止服务
+			count, err := application.Stats.Rebuild(context.Background())
+			if err != nil {
+				logger.Error("rebuild contributor stats failed", "error", err)
+				return
+			}
+			logger.Info("contributor stats rebuilt", "transitions", count)
+		case "link-songs": // 为尚未关联歌曲的已发布稿件关联歌曲目录
+			count, err := application.Songs.LinkAll(context.Background())
+			if err != nil {
+				logger.Error("link songs failed", "error", err)
+				return
+			}
+			logger.Info("songs linked", "drafts", count)
+		case "aggregate-usage": // 立即汇总尚未汇总的使用事件
+			count, err := application.Usage.Aggregate(context.Background())
+			if err != nil {
+				logger.Error("aggregate usage events failed", "error", err)
+				return
+			}
+			logger.Info("usage events aggregated", "events", count)
+		case "sync-import", "sync-export": // 与 amll-ttml-db 本地克隆同步，加 --dry-run 只输出报告
+			sync := application.Sync.Import
+			if os.Args[1] == "sync-export" {
+				sync = application.Sync.Export
+			}
+			dryRun := slices.Contains(os.Args[2:], "--dry-run")
+			report, err := sync(context.Background(), dryRun)
+			if err != nil {
+				logger.Error("sync failed", "command", os.Args[1], "error", err)
+				return
+			}
+			out, _ := json.MarshalIndent(report, "", "  ")
+			fmt.Println(string(out))
+		default:
+			logger.Error("unknown command", "command", os.Args[1])
+		}
+		return
+	}
 
 	logger.Info("server starting", "port", cfg.Server.Port)
 	if err := application.Run(); err != nil && !errors.Is(err, http.ErrServerClosed) {
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaowumin-mark/AMLX/service"
+)
+
+// CDN 节点访问控制接口的认证
+func RequireCDNToken(svc service.CDNNodeService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		err := svc.Authenticate(c.Request.Context(), extractBearerToken(c))
+		switch {
+		case err == nil:
+			c.Next()
+			return
+		case errors.Is(err, service.ErrCDNDisabled):
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		}
+		c.Abort()
+	}
+}
@@ -24,3 +24,117 @@ const (
 	RollbackKeepFiles RollbackMode = "KEEP_FILES"
 	RollbackDropFiles RollbackMode = "DROP_FILES"
 )
+
+type RollbackStatus string
+
+const (
+	RollbackPending  RollbackStatus = "PENDING"
+	RollbackApproved RollbackStatus = "APPROVED"
+	RollbackDenied   RollbackStatus = "DENIED"
+)
+
+// 发布目标（LyricsDraft.PublishTarget）
+const (
+	PublishTargetAMLX   = "AMLX"
+	PublishTargetGithub = "GITHUB"
+	PublishTargetBoth   = "BOTH"
+)
+
+// 稿件状态流转动作
+type TransitionAction string
+
+const (
+	ActionAdvance  TransitionAction = "ADVANCE"  // 预审核阶段前进
+	ActionSubmit   TransitionAction = "SUBMIT"   // CHECK -> IN_REVIEW
+	ActionApprove  TransitionAction = "APPROVE"  // IN_REVIEW -> REVIEW_DONE
+	ActionReject   TransitionAction = "REJECT"   // IN_REVIEW -> PRE_REVIEW
+	ActionRollback TransitionAction = "ROLLBACK" // 预审核阶段回退
+	ActionFork     TransitionAction = "FORK"     // 由已发布版本开启修正稿件（系统动作）
+	ActionImport   TransitionAction = "IMPORT"   // 从 amll-ttml-db 导入已发布稿件
+)
+
+// 审核结果（LyricsReview.Result）
+const (
+	ReviewApproved = "APPROVED"
+	ReviewRejected = "REJECTED"
+)
+
+// 同步方向（LyricsSyncRecord.Direction）
+type SyncDirection string
+
+const (
+	SyncImport SyncDirection = "IMPORT" // 从仓库导入
+	SyncExport SyncDirection = "EXPORT" // 导出到仓库
+)
+
+// 稿件 PR 状态（LyricsDraft.GithubPRState）
+type PullRequestState string
+
+const (
+	PullRequestOpen   PullRequestState = "OPEN"
+	PullRequestMerged PullRequestState = "MERGED"
+	PullRequestClosed PullRequestState = "CLOSED" // 关闭且未合并
+)
+
+// CDN 节点状态（CDNNode.Status）
+type CDNNodeStatus string
+
+const (
+	CDNNodeHealthy CDNNodeStatus = "HEALTHY" // 心跳正常且未落后
+	CDNNodeLagging CDNNodeStatus = "LAGGING" // 心跳正常但落后最新清单过多，正在分发旧歌词
+	CDNNodeStale   CDNNodeStatus = "STALE"   // 心跳超时
+)
+
+// 求歌词请求状态（LyricRequest.Status）
+type LyricRequestStatus string
+
+const (
+	LyricRequestOpen    LyricRequestStatus = "OPEN"    // 等待认领
+	LyricRequestClaimed LyricRequestStatus = "CLAIMED" // 已认领，稿件制作中
+	LyricRequestClosed  LyricRequestStatus = "CLOSED"  // 稿件已发布
+)
+
+// 艺术家在歌曲中的角色（SongArtist.Role）
+type ArtistRole string
+
+const (
+	ArtistPrimary  ArtistRole = "PRIMARY"  // 演唱者
+	ArtistFeatured ArtistRole = "FEATURED" // 合作演唱
+	ArtistComposer ArtistRole = "COMPOSER" // 作曲
+	ArtistLyricist ArtistRole = "LYRICIST" // 作词
+)
+
+// 歌曲的外部平台（SongExternalID.Platform），与 cdn.SongRef 一致
+const (
+	PlatformNCM     = "ncm"
+	PlatformQQ      = "qq"
+	PlatformSpotify = "spotify"
+	PlatformApple   = "apple"
+	PlatformISRC    = "isrc"
+)
+
+// 歌词使用事件类型（UsageEvent.Type）
+type UsageEventType string
+
+const (
+	UsageFetched   UsageEventType = "FETCHED"     // 获取了歌词
+	UsagePlayed    UsageEventType = "PLAYED"      // 播放时显示了歌词
+	UsageOutOfSync UsageEventType = "OUT_OF_SYNC" // 反馈歌词与音频不同步
+	UsageMissed    UsageEventType = "MISSED"      // 查询歌词返回 404
+)
+
+// 使用统计的汇总粒度（UsageRollup.Granularity）
+type UsageGranularity string
+
+const (
+	UsageHourly UsageGranularity = "HOUR"
+	UsageDaily  UsageGranularity = "DAY"
+)
+
+// 时间轴反馈类型（TimingReport.Kind）
+type TimingReportKind string
+
+const (
+	TimingReportOffset TimingReportKind = "OFFSET" // 整体偏早或偏晚
+	TimingReportLine   TimingReportKind = "LINE"   // 某一行有误
+)
@@ -15,6 +15,7 @@ type LyricsDraft struct {
 	Artists  string // 先 string(JSON) 或 text
 	Album    string
 	Language string
+	SongID   *uint `gorm:"index"` // 目录中的歌曲
 
 	// ===== 权限 =====
 	OwnerUserID uint
@@ -33,9 +34,14 @@ type LyricsDraft struct {
 	// ===== 冻结快照（进入审核时）=====
 	ReviewSnapshotID *uint
 
+	// ===== 修正 =====
+	SourceVersionID *uint `gorm:"index"` // 由听众反馈开启的修正稿件，指向被反馈的已发布版本
+
 	// ===== 发布 / 扩展 =====
-	GithubPRURL   string
-	PublishTarget string // AMLX / GITHUB / BOTH（先留）
+	GithubPRURL    string
+	GithubPRNumber int
+	GithubPRState  PullRequestState `gorm:"type:varchar(20);index"` // 未创建 PR 时为空
+	PublishTarget  string           // AMLX / GITHUB / BOTH
 }
 
 // 歌词审核
@@ -66,6 +72,9 @@ type StageRollback struct {
 	RollbackMode RollbackMode `gorm:"type:varchar(20)"`
 
 	Reason string
+
+	Status    RollbackStatus `gorm:"type:varchar(20);index"`
+	DecidedAt *time.Time
 }
 
 // 歌词版本
@@ -80,3 +89,235 @@ type LyricsVersion struct {
 
 	CreatedBy uint
 }
+
+// 稿件状态流转记录
+type DraftTransition struct {
+	gorm.Model
+	DraftID uint `gorm:"index"`
+
+	Action TransitionAction `gorm:"type:varchar(20)"`
+
+	FromStatus DraftStatus    `gorm:"type:varchar(20)"`
+	FromStage  *WorkflowStage `gorm:"type:varchar(30)"`
+	ToStatus   DraftStatus    `gorm:"type:varchar(20)"`
+	ToStage    *WorkflowStage `gorm:"type:varchar(30)"`
+
+	ActorUserID uint
+	Reason      string
+}
+
+// 稿件与 amll-ttml-db 仓库文件的对应关系
+type LyricsSyncRecord struct {
+	gorm.Model
+	DraftID uint `gorm:"uniqueIndex"`
+
+	Path        string `gorm:"type:varchar(255);uniqueIndex"` // 仓库内相对路径
+	ContentHash string `gorm:"type:varchar(64)"`              // 上次同步时文件内容的 SHA-256
+	Commit      string `gorm:"type:varchar(40)"`              // 导入时读取或导出时写入的提交
+
+	Direction SyncDirection `gorm:"type:varchar(10)"`
+}
+
+// 发布到 CDN 的清单，ID 即拉取游标
+type PublishManifest struct {
+	gorm.Model
+	DraftID   uint `gorm:"index"`
+	VersionID uint
+
+	VersionHash string `gorm:"type:varchar(64);index"` // 规范化 TTML 的 SHA-256
+	Title       string
+	Artists     string
+	SongID      *uint  `gorm:"index"`     // 目录中的歌曲，取自发布时的稿件
+	Formats     string `gorm:"type:text"` // JSON，[]cdn.Object
+	Diffs       string `gorm:"type:text"` // JSON，[]cdn.Diff
+}
+
+// 清单涉及的歌曲，用于查找同一首歌的上一次发布
+type PublishManifestSong struct {
+	ID         uint   `gorm:"primaryKey"`
+	ManifestID uint   `gorm:"index"`
+	Platform   string `gorm:"type:varchar(20);index:idx_publish_song"`
+	SongID     string `gorm:"type:varchar(64);index:idx_publish_song"`
+}
+
+// 按内容哈希寻址的发布对象
+type PublishObject struct {
+	Hash        string `gorm:"type:varchar(64);primaryKey"`
+	ContentType string `gorm:"type:varchar(100)"`
+	Size        int64
+	Data        []byte `gorm:"type:longblob"`
+	CreatedAt   time.Time
+}
+
+// CDN 节点
+type CDNNode struct {
+	gorm.Model
+	NodeID     string `gorm:"type:varchar(32);uniqueIndex"` // 对外的节点 ID
+	Name       string `gorm:"type:varchar(100);uniqueIndex"`
+	Region     string `gorm:"type:varchar(50)"`
+	Endpoint   string
+	Version    string `gorm:"type:varchar(50)"` // 节点软件版本，由心跳上报
+	SecretHash string `gorm:"type:varchar(64)"` // 节点凭据的 SHA-256
+
+	Status          CDNNodeStatus `gorm:"type:varchar(20);index"`
+	LastHeartbeatAt *time.Time
+	Cursor          uint // 节点已应用的最新清单 ID
+	Lag             uint // 落后最新清单的数量
+	ReportedErrors  uint // 节点上报的错误累计
+	PushErrors      uint // 控制面推送失败累计
+}
+
+// CDN 节点状态变化记录
+type CDNNodeEvent struct {
+	ID         uint          `gorm:"primaryKey"`
+	NodeID     uint          `gorm:"index"`
+	FromStatus CDNNodeStatus `gorm:"type:varchar(20)"`
+	ToStatus   CDNNodeStatus `gorm:"type:varchar(20)"`
+	Reason     string
+	CreatedAt  time.Time
+}
+
+// 求歌词请求
+type LyricRequest struct {
+	gorm.Model
+	Title         string
+	Artists       string // JSON 数组
+	Album         string
+	Links         string // 平台链接，JSON 数组
+	NormalizedKey string `gorm:"type:varchar(64);index"` // 归一化标题与艺术家的 SHA-256，用于查重
+	// 未关闭时等于 NormalizedKey，关闭后置空；唯一索引保证同一首歌只有一个未关闭的请求
+	ActiveKey *string `gorm:"type:varchar(64);uniqueIndex"`
+
+	RequesterUserID uint               `gorm:"index"`
+	Status          LyricRequestStatus `gorm:"type:varchar(20);index"`
+	Votes           uint               `gorm:"index"`
+
+	ClaimedBy uint
+	ClaimedAt *time.Time
+	DraftID   *uint `gorm:"index"` // 认领时创建的稿件，关闭时为发布的稿件
+	ClosedAt  *time.Time
+}
+
+// 求歌词请求的投票，每人每个请求一票
+type LyricRequestVote struct {
+	ID        uint `gorm:"primaryKey"`
+	RequestID uint `gorm:"uniqueIndex:idx_request_vote"`
+	UserID    uint `gorm:"uniqueIndex:idx_request_vote"`
+	CreatedAt time.Time
+}
+
+// 贡献者每日统计，随稿件流转增量累加
+type ContributorStat struct {
+	ID     uint      `gorm:"primaryKey"`
+	UserID uint      `gorm:"uniqueIndex:idx_contributor_day"`
+	Day    time.Time `gorm:"type:date;uniqueIndex:idx_contributor_day;index"`
+
+	// ===== 作为投稿者 =====
+	Submitted       uint // 提交审核次数
+	Approved        uint // 通过的稿件数
+	Rejected        uint // 被驳回次数
+	ApprovedRejects uint // 通过的稿件此前累计被驳回的次数
+	Lines           uint // 通过稿件中有时间轴的行数（含背景人声）
+	Syllables       uint // 通过稿件中有时间轴的音节数
+
+	// ===== 作为审核者 =====
+	Reviews       uint   // 审核次数（通过与驳回）
+	ReviewSeconds uint64 // 提交到审核的累计等待时间
+}
+
+// 歌曲目录
+type Song struct {
+	gorm.Model
+	Title         string `gorm:"type:varchar(255);index"`
+	AlbumID       *uint  `gorm:"index"`
+	Language      string `gorm:"type:varchar(20)"`
+	NormalizedKey string `gorm:"type:varchar(64);index"` // 归一化标题与演唱者的 SHA-256，用于查重
+}
+
+type Artist struct {
+	gorm.Model
+	Name string `gorm:"type:varchar(200);uniqueIndex"`
+}
+
+// 同名专辑按专辑艺术家区分
+type Album struct {
+	gorm.Model
+	Title    string `gorm:"type:varchar(255);uniqueIndex:idx_album_artist"`
+	ArtistID uint   `gorm:"uniqueIndex:idx_album_artist"` // 专辑艺术家，未知时为 0
+}
+
+// 歌曲与艺术家的多对多关联
+type SongArtist struct {
+	SongID   uint       `gorm:"primaryKey"`
+	ArtistID uint       `gorm:"primaryKey;index"`
+	Role     ArtistRole `gorm:"type:varchar(20);primaryKey"`
+	Position uint       // 同一角色内的显示顺序
+}
+
+// 歌曲在外部平台上的 ID，每个平台 ID 只属于一首歌
+type SongExternalID struct {
+	ID         uint   `gorm:"primaryKey"`
+	SongID     uint   `gorm:"index"`
+	Platform   string `gorm:"type:varchar(20);uniqueIndex:idx_song_external"`
+	ExternalID string `gorm:"type:varchar(100);uniqueIndex:idx_song_external"`
+	CreatedAt  time.Time
+}
+
+// 歌词使用事件，只追加不修改，由后台任务汇总到 UsageRollup
+type UsageEvent struct {
+	ID         uint           `gorm:"primaryKey"`
+	Type       UsageEventType `gorm:"type:varchar(20)"`
+	Platform   string         `gorm:"type:varchar(20)"`
+	ExternalID string         `gorm:"type:varchar(100)"`
+	Format     string         `gorm:"type:varchar(10)"`
+	Region     string         `gorm:"type:varchar(32)"`
+	OccurredAt time.Time      // 上报的发生时间，缺失或不合理时为接收时间
+	CreatedAt  time.Time
+}
+
+// 使用事件按小时与按天的汇总。MISSED 与不在目录中的歌曲按平台 ID 汇总，其余按歌曲汇总
+type UsageRollup struct {
+	ID          uint             `gorm:"primaryKey"`
+	Granularity UsageGranularity `gorm:"type:varchar(4);uniqueIndex:idx_usage_rollup"`
+	BucketStart time.Time        `gorm:"uniqueIndex:idx_usage_rollup;index"`
+	Type        UsageEventType   `gorm:"type:varchar(20);uniqueIndex:idx_usage_rollup"`
+	SongID      uint             `gorm:"uniqueIndex:idx_usage_rollup"` // 不在目录中时为 0
+	Platform    string           `gorm:"type:varchar(20);uniqueIndex:idx_usage_rollup"`
+	ExternalID  string           `gorm:"type:varchar(100);uniqueIndex:idx_usage_rollup"`
+	Format      string           `gorm:"type:varchar(10);uniqueIndex:idx_usage_rollup"`
+	Region      string           `gorm:"type:varchar(32);uniqueIndex:idx_usage_rollup"`
+	Count       uint64
+}
+
+// 后台汇总任务的进度
+type UsageCursor struct {
+	Name        string `gorm:"type:varchar(32);primaryKey"`
+	LastEventID uint   // 已汇总的最大事件 ID
+	UpdatedAt   time.Time
+}
+
+// 听众对已发布版本的时间轴反馈，同一用户对同一版本的整体偏移或同一行只保留最新一条
+type TimingReport struct {
+	ID         uint             `gorm:"primaryKey"`
+	VersionID  uint             `gorm:"uniqueIndex:idx_timing_report"` // 被反馈的审核快照
+	DraftID    uint             `gorm:"index"`
+	UserID     uint             `gorm:"uniqueIndex:idx_timing_report"`
+	Kind       TimingReportKind `gorm:"type:varchar(10);uniqueIndex:idx_timing_report"`
+	LineNumber uint             `gorm:"uniqueIndex:idx_timing_report"` // 从 1 开始，OFFSET 时为 0
+	OffsetMs   int              // 正数为歌词偏晚，负数为偏早
+	Comment    string           `gorm:"type:varchar(500)"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// 已发布版本的时间轴反馈汇总，随每次反馈重新计算
+type VersionTimingStat struct {
+	VersionID     uint  `gorm:"primaryKey;autoIncrement:false"`
+	DraftID       uint  `gorm:"index"`
+	Reporters     uint  // 反馈过的不同用户数
+	OffsetReports uint  // 整体偏移反馈数
+	OffsetSumMs   int64 // 整体偏移之和
+	LineReports   uint  // 单行反馈数
+	FixDraftID    *uint // 达到阈值后开启的修正稿件
+	UpdatedAt     time.Time
+}
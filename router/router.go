@@ -9,7 +9,28 @@ import (
 	"github.com/xiaowumin-mark/AMLX/service"
 )
 
-func New(cfg *config.Config, userHandler *handler.UserHandler, authHandler *handler.AuthHandler, permissionHandler *handler.PermissionHandler, authSvc service.AuthService, permSvc service.PermissionService) *gin.Engine {
+// 路由需要的全部处理器
+type Handlers struct {
+	User        *handler.UserHandler
+	Auth        *handler.AuthHandler
+	Permission  *handler.PermissionHandler
+	Draft       *handler.DraftHandler
+	Review      *handler.ReviewHandler
+	Rollback    *handler.RollbackHandler
+	Version     *handler.VersionHandler
+	Search      *handler.SearchHandler
+	Sync        *handler.SyncHandler
+	PullRequest *handler.PullRequestHandler
+	CDN         *handler.CDNHandler
+	Request     *handler.LyricRequestHandler
+	Stats       *handler.StatsHandler
+	Song        *handler.SongHandler
+	Lookup      *handler.LookupHandler
+	Usage       *handler.UsageHandler
+	Timing      *handler.TimingReportHandler
+}
+
+func New(cfg *config.Config, h Handlers, authSvc service.AuthService, permSvc service.PermissionService, cdnSvc service.CDNNodeService) *gin.Engine {
 	engine := gin.New()
 	if cfg.Server.Log {
 		engine.Use(gin.LoggerWithWriter(logx.Writer()))
@@ -18,7 +39,16 @@ func New(cfg *config.Config, userHandler *handler.UserHandler, authHandler *hand
 
 	api := engine.Group("/api/v1")
 	auth := middleware.NewAuth(authSvc)
-	authHandler.Register(api, auth.Required())
+	h.Auth.Register(api, auth.Required())
+	h.Search.Register(api)             // 搜索只返回已发布稿件，无需登录
+	h.Lookup.Register(api)             // 播放器按平台 ID 获取已发布歌词，无需登录
+	h.Usage.Register(api)              // 使用事件由 CDN 节点与客户端上报，无需登录
+	h.PullRequest.RegisterWebhook(api) // webhook 以签名认证
+
+	h.CDN.RegisterHeartbeat(api)  // 心跳以节点凭据认证
+	cdnGroup := api.Group("/cdn") // 其余节点接口以控制面 token 认证
+	cdnGroup.Use(middleware.RequireCDNToken(cdnSvc))
+	h.CDN.Register(cdnGroup)
 
 	adminOnly := middleware.RequirePermission(permSvc, "admin")
 	protected := api.Group("")
@@ -26,11 +56,31 @@ func New(cfg *config.Config, userHandler *handler.UserHandler, authHandler *hand
 
 	userGroup := protected.Group("")
 	userGroup.Use(adminOnly)
-	userHandler.Register(userGroup)
+	h.User.Register(userGroup)
 
 	permGroup := protected.Group("")
 	permGroup.Use(adminOnly)
-	permissionHandler.Register(permGroup)
+	h.Permission.Register(permGroup)
+
+	syncGroup := protected.Group("")
+	syncGroup.Use(adminOnly)
+	h.Sync.Register(syncGroup)
+	h.PullRequest.Register(syncGroup)
+	h.CDN.RegisterAdmin(syncGroup)
+	h.Song.RegisterAdmin(syncGroup)
+	h.Usage.RegisterAdmin(syncGroup)
+
+	h.Draft.Register(protected)
+	h.Rollback.Register(protected)
+	h.Version.Register(protected)
+	h.Request.Register(protected)
+	h.Stats.Register(protected)
+	h.Song.Register(protected)
+	h.Timing.Register(protected)
+
+	reviewGroup := protected.Group("")
+	reviewGroup.Use(middleware.RequirePermission(permSvc, "review"))
+	h.Review.Register(reviewGroup)
 
 	return engine
 }
@@ -0,0 +1,133 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/mozillazg/go-pinyin"
+)
+
+// 不带声调的全部拼音音节，ü 写作 v
+var pinyinSyllables = toSet(`
+a ai an ang ao
+ba bai ban bang bao bei ben beng bi bian biao bie bin bing bo bu
+ca cai can cang cao ce cen ceng cha chai chan chang chao che chen cheng chi chong chou chu chua chuai chuan chuang chui chun chuo ci cong cou cu cuan cui cun cuo
+da dai dan dang dao de dei den deng di dia dian diao die ding diu dong dou du duan dui dun duo
+e ei en eng er
+fa fan fang fei fen feng fo fou fu
+ga gai gan gang gao ge gei gen geng gong gou gu gua guai guan guang gui gun guo
+ha hai han hang hao he hei hen heng hong hou hu hua huai huan huang hui hun huo
+ji jia jian jiang jiao jie jin jing jiong jiu ju juan jue jun
+ka kai kan kang kao ke kei ken keng kong kou ku kua kuai kuan kuang kui kun kuo
+la lai lan lang lao le lei leng li lia lian liang liao lie lin ling liu lo long lou lu luan lun luo lv lve
+ma mai man mang mao me mei men meng mi mian miao mie min ming miu mo mou mu
+na nai nan nang nao ne nei nen neng ni nian niang niao nie nin ning niu nong nou nu nuan nuo nv nve
+o ou
+pa pai pan pang pao pei pen peng pi pian piao pie pin ping po pou pu
+qi qia qian qiang qiao qie qin qing qiong qiu qu quan que qun
+ran rang rao re ren reng ri rong rou ru rua ruan rui run ruo
+sa sai san sang sao se sen seng sha shai shan shang shao she shei shen sheng shi shou shu shua shuai shuan shuang shui shun shuo si song sou su suan sui sun suo
+ta tai tan tang tao te teng ti tian tiao tie ting tong tou tu tuan tui tun tuo
+wa wai wan wang wei wen weng wo wu
+xi xia xian xiang xiao xie xin xing xiong xiu xu xuan xue xun
+ya yan yang yao ye yi yin ying yo yong you yu yuan yue yun
+za zai zan zang zao ze zei zen zeng zha zhai zhan zhang zhao zhe zhei zhen zheng zhi zhong zhou zhu zhua zhuai zhuan zhuang zhui zhun zhuo zi zong zou zu zuan zui zun zuo
+`)
+
+// 最长的拼音音节长度（zhuang）
+const maxPinyinLen = 6
+
+// 超过该长度的词不尝试切分，避免回溯过多
+const maxSplitLen = 48
+
+var pinyinArgs = pinyin.NewArgs()
+
+// 文本转为空格分隔的拼音音节：汉字逐字转换，简繁体读音相同因而得到相同结果；
+// ASCII 字母与数字原样保留（小写），其余字符作为分隔
+func toPinyin(text string) string {
+	var b strings.Builder
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			appendToken(&b, strings.ToLower(string(word)))
+			word = word[:0]
+		}
+	}
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			flush()
+			if readings := pinyin.SinglePinyin(r, pinyinArgs); len(readings) > 0 {
+				appendToken(&b, readings[0])
+			}
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			word = append(word, r)
+		default:
+			flush()
+		}
+	}
+	flush()
+	return b.String()
+}
+
+// 查询文本转为拼音音节。拉丁字母的词尝试切分为拼音音节（"woaini" → "wo ai ni"），
+// 切分不了的词原样保留；没有汉字也没有可切分的词时返回空串
+func queryPinyin(text string) string {
+	converted := false
+	var tokens []string
+	for _, token := range strings.Fields(toPinyin(text)) {
+		if syllables := splitSyllables(token, pinyinSyllables, maxPinyinLen); syllables != nil {
+			tokens = append(tokens, syllables...)
+			converted = true
+			continue
+		}
+		tokens = append(tokens, token)
+	}
+	if !converted && !hasHan(text) {
+		return ""
+	}
+	return strings.Join(tokens, " ")
+}
+
+// 把连写的音节切开，优先取长音节，无法完整切分时返回 nil
+func splitSyllables(word string, syllables map[string]bool, maxLen int) []string {
+	if word == "" || len(word) > maxSplitLen {
+		return nil
+	}
+	if syllables[word] {
+		return []string{word}
+	}
+	for n := min(maxLen, len(word)-1); n > 0; n-- {
+		if !syllables[word[:n]] {
+			continue
+		}
+		if rest := splitSyllables(word[n:], syllables, maxLen); rest != nil {
+			return append([]string{word[:n]}, rest...)
+		}
+	}
+	return nil
+}
+
+func hasHan(text string) bool {
+	for _, r := range text {
+		if unicode.Is(unicode.Han, r) {
+			return true
+		}
+	}
+	return false
+}
+
+func appendToken(b *strings.Builder, token string) {
+	if b.Len() > 0 {
+		b.WriteByte(' ')
+	}
+	b.WriteString(token)
+}
+
+func toSet(list string) map[string]bool {
+	set := map[string]bool{}
+	for _, item := range strings.Fields(list) {
+		set[item] = true
+	}
+	return set
+}
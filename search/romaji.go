@@ -0,0 +1,195 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// 平假名（片假名先转为平假名）到平文式罗马字，拗音与外来语组合优先匹配两个假名
+var kanaRomaji = map[string]string{
+	"あ": "a", "い": "i", "う": "u", "え": "e", "お": "o",
+	"か": "ka", "き": "ki", "く": "ku", "け": "ke", "こ": "ko",
+	"さ": "sa", "し": "shi", "す": "su", "せ": "se", "そ": "so",
+	"た": "ta", "ち": "chi", "つ": "tsu", "て": "te", "と": "to",
+	"な": "na", "に": "ni", "ぬ": "nu", "ね": "ne", "の": "no",
+	"は": "ha", "ひ": "hi", "ふ": "fu", "へ": "he", "ほ": "ho",
+	"ま": "ma", "み": "mi", "む": "mu", "め": "me", "も": "mo",
+	"や": "ya", "ゆ": "yu", "よ": "yo",
+	"ら": "ra", "り": "ri", "る": "ru", "れ": "re", "ろ": "ro",
+	"わ": "wa", "ゐ": "i", "ゑ": "e", "を": "wo", "ん": "n",
+	"が": "ga", "ぎ": "gi", "ぐ": "gu", "げ": "ge", "ご": "go",
+	"ざ": "za", "じ": "ji", "ず": "zu", "ぜ": "ze", "ぞ": "zo",
+	"だ": "da", "ぢ": "ji", "づ": "zu", "で": "de", "ど": "do",
+	"ば": "ba", "び": "bi", "ぶ": "bu", "べ": "be", "ぼ": "bo",
+	"ぱ": "pa", "ぴ": "pi", "ぷ": "pu", "ぺ": "pe", "ぽ": "po",
+	"ゔ": "vu",
+	"ぁ": "a", "ぃ": "i", "ぅ": "u", "ぇ": "e", "ぉ": "o",
+	"ゃ": "ya", "ゅ": "yu", "ょ": "yo", "ゎ": "wa",
+
+	"きゃ": "kya", "きゅ": "kyu", "きょ": "kyo",
+	"しゃ": "sha", "しゅ": "shu", "しょ": "sho", "しぇ": "she",
+	"ちゃ": "cha", "ちゅ": "chu", "ちょ": "cho", "ちぇ": "che",
+	"にゃ": "nya", "にゅ": "nyu", "にょ": "nyo",
+	"ひゃ": "hya", "ひゅ": "hyu", "ひょ": "hyo",
+	"みゃ": "mya", "みゅ": "myu", "みょ": "myo",
+	"りゃ": "rya", "りゅ": "ryu", "りょ": "ryo",
+	"ぎゃ": "gya", "ぎゅ": "gyu", "ぎょ": "gyo",
+	"じゃ": "ja", "じゅ": "ju", "じょ": "jo", "じぇ": "je",
+	"びゃ": "bya", "びゅ": "byu", "びょ": "byo",
+	"ぴゃ": "pya", "ぴゅ": "pyu", "ぴょ": "pyo",
+	"ふぁ": "fa", "ふぃ": "fi", "ふぇ": "fe", "ふぉ": "fo",
+	"てぃ": "ti", "でぃ": "di", "でゅ": "dyu", "とぅ": "tu", "どぅ": "du",
+	"うぃ": "wi", "うぇ": "we", "うぉ": "wo", "つぁ": "tsa",
+	"ゔぁ": "va", "ゔぃ": "vi", "ゔぇ": "ve", "ゔぉ": "vo",
+}
+
+// 罗马字音节集合，用于切分查询
+var romajiSyllables = func() map[string]bool {
+	set := map[string]bool{}
+	for _, romaji := range kanaRomaji {
+		set[romaji] = true
+	}
+	return set
+}()
+
+// 最长的罗马字音节长度（shi、tsu 等）
+const maxRomajiLen = 3
+
+// 文本转为空格分隔的罗马字音节：每个假名（含拗音）一个音节，促音并入下一个音节（っと → tto），
+// 长音符省略；ASCII 字母与数字原样保留（小写），其余字符作为分隔
+func toRomaji(text string) string {
+	runes := []rune(text)
+	var b strings.Builder
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			appendToken(&b, strings.ToLower(string(word)))
+			word = word[:0]
+		}
+	}
+	geminate := false
+	for i := 0; i < len(runes); i++ {
+		r := toHiragana(runes[i])
+		switch {
+		case r == 'っ':
+			flush()
+			geminate = true
+			continue
+		case r == 'ー':
+			continue
+		case isKana(r):
+			flush()
+			romaji, ok := "", false
+			if i+1 < len(runes) {
+				romaji, ok = kanaRomaji[string([]rune{r, toHiragana(runes[i+1])})]
+			}
+			if ok {
+				i++
+			} else if romaji, ok = kanaRomaji[string(r)]; !ok {
+				geminate = false
+				continue
+			}
+			if geminate {
+				romaji = geminatePrefix(romaji) + romaji
+			}
+			appendToken(&b, romaji)
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			word = append(word, r)
+		default:
+			flush()
+		}
+		geminate = false
+	}
+	flush()
+	return b.String()
+}
+
+// 查询文本转为罗马字音节。拉丁字母的词尝试切分为音节（"sakura" → "sa ku ra"），
+// 切分不了的词原样保留；没有假名也没有可切分的词时返回空串
+func queryRomaji(text string) string {
+	converted := false
+	var tokens []string
+	for _, token := range strings.Fields(toRomaji(text)) {
+		if syllables := splitRomaji(token); syllables != nil {
+			tokens = append(tokens, syllables...)
+			converted = true
+			continue
+		}
+		tokens = append(tokens, token)
+	}
+	if !converted && !hasKana(text) {
+		return ""
+	}
+	return strings.Join(tokens, " ")
+}
+
+// 与 splitSyllables 相同，另外把促音（双写辅音、tch）并入后一个音节
+func splitRomaji(word string) []string {
+	if word == "" || len(word) > maxSplitLen {
+		return nil
+	}
+	if prefix := geminateOf(word); prefix > 0 {
+		if rest := splitRomaji(word[prefix:]); rest != nil {
+			rest[0] = word[:prefix] + rest[0]
+			return rest
+		}
+	}
+	if romajiSyllables[word] {
+		return []string{word}
+	}
+	for n := min(maxRomajiLen, len(word)-1); n > 0; n-- {
+		if !romajiSyllables[word[:n]] {
+			continue
+		}
+		if rest := splitRomaji(word[n:]); rest != nil {
+			return append([]string{word[:n]}, rest...)
+		}
+	}
+	return nil
+}
+
+// 词首促音的长度：kk、ss、tt、pp 等双写辅音为 1，tch 为 1
+func geminateOf(word string) int {
+	if len(word) < 3 {
+		return 0
+	}
+	if strings.HasPrefix(word, "tch") {
+		return 1
+	}
+	if c := word[0]; c == word[1] && strings.IndexByte("aiueon", c) < 0 {
+		return 1
+	}
+	return 0
+}
+
+// 促音的写法：重复下一个音节的首个辅音，ch 前写 t
+func geminatePrefix(romaji string) string {
+	switch {
+	case strings.HasPrefix(romaji, "ch"):
+		return "t"
+	case strings.IndexByte("aiueon", romaji[0]) >= 0:
+		return ""
+	default:
+		return romaji[:1]
+	}
+}
+
+func toHiragana(r rune) rune {
+	if r >= 'ァ' && r <= 'ヶ' {
+		return r - ('ァ' - 'ぁ')
+	}
+	return r
+}
+
+func isKana(r rune) bool {
+	return r >= 'ぁ' && r <= 'ゖ'
+}
+
+func hasKana(text string) bool {
+	for _, r := range text {
+		if isKana(toHiragana(r)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,459 @@
+// Package search 基于 Bleve 的已发布歌词全文索引。
+//
+// 每个已发布稿件对应一条文档，按稿件 ID 建立，索引歌名、歌手、专辑、语言与歌词文本。
+// 正文字段使用 CJK 二元切分；另有拼音与假名罗马字的旁路字段，
+// 使 "wo ai ni" 能找到「我爱你」、"sakura" 能找到「さくら」，简繁体的读音相同因而也能互相匹配。
+package search
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/custom"
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/keyword"
+	"github.com/blevesearch/bleve/v2/analysis/lang/cjk"
+	"github.com/blevesearch/bleve/v2/analysis/token/lowercase"
+	"github.com/blevesearch/bleve/v2/analysis/tokenizer/whitespace"
+	"github.com/blevesearch/bleve/v2/mapping"
+	bsearch "github.com/blevesearch/bleve/v2/search"
+	"github.com/blevesearch/bleve/v2/search/highlight/highlighter/html"
+	"github.com/blevesearch/bleve/v2/search/query"
+)
+
+// 字段名
+const (
+	FieldTitle       = "title"
+	FieldArtists     = "artists"
+	FieldArtist      = "artist" // 歌手原文，用于过滤与分面
+	FieldAlbum       = "album"
+	FieldLanguage    = "language"
+	FieldLyrics      = "lyrics"
+	FieldPublishedAt = "published_at"
+
+	// 旁路字段，只用于匹配，不存储也不高亮
+	FieldTitlePinyin   = "title_pinyin"
+	FieldArtistsPinyin = "artists_pinyin"
+	FieldLyricsPinyin  = "lyrics_pinyin"
+	FieldTitleRomaji   = "title_romaji"
+	FieldLyricsRomaji  = "lyrics_romaji"
+)
+
+// 映射版本，映射变化时递增；打开旧版本的索引会清空重建
+const mappingVersion = "2"
+
+// 保存映射版本的内部键
+var mappingVersionKey = []byte("amlx_mapping_version")
+
+// 旁路字段的分析器：按空白切分音节并转小写
+const syllableAnalyzer = "amlx_syllable"
+
+// 分面返回的最大词条数
+const facetSize = 20
+
+// 索引目录被其他进程占用时等待的时间
+const openTimeout = "5s"
+
+// 待索引的稿件
+type Document struct {
+	DraftID     uint
+	Title       string
+	Artists     []string
+	Album       string
+	Language    string
+	Lyrics      string
+	PublishedAt time.Time
+}
+
+// 查询条件，Text 为空时按发布时间倒序列出
+type Query struct {
+	Text     string
+	Language string
+	Artist   string
+	Offset   int
+	Limit    int
+}
+
+type Hit struct {
+	DraftID    uint                `json:"draft_id"`
+	Score      float64             `json:"score"`
+	Title      string              `json:"title"`
+	Artists    []string            `json:"artists"`
+	Album      string              `json:"album"`
+	Language   string              `json:"language"`
+	Highlights map[string][]string `json:"highlights,omitempty"`
+}
+
+type FacetTerm struct {
+	Term  string `json:"term"`
+	Count int    `json:"count"`
+}
+
+type Facets struct {
+	Languages []FacetTerm `json:"languages"`
+	Artists   []FacetTerm `json:"artists"`
+}
+
+type Result struct {
+	Total  uint64 `json:"total"`
+	Hits   []Hit  `json:"hits"`
+	Facets Facets `json:"facets"`
+}
+
+// 写入 Bleve 的文档结构，字段名与映射一致
+type indexDoc struct {
+	Title       string    `json:"title"`
+	Artists     []string  `json:"artists"`
+	Artist      []string  `json:"artist"`
+	Album       string    `json:"album"`
+	Language    string    `json:"language"`
+	Lyrics      string    `json:"lyrics"`
+	PublishedAt time.Time `json:"published_at"`
+
+	TitlePinyin   string   `json:"title_pinyin"`
+	ArtistsPinyin []string `json:"artists_pinyin"`
+	LyricsPinyin  string   `json:"lyrics_pinyin"`
+	TitleRomaji   string   `json:"title_romaji"`
+	LyricsRomaji  string   `json:"lyrics_romaji"`
+}
+
+// 磁盘上的索引
+type Index struct {
+	index   bleve.Index
+	created bool
+}
+
+// 打开索引，不存在或映射版本过旧时新建。同一索引同一时间只能被一个进程打开
+func Open(path string) (*Index, error) {
+	index, err := bleve.OpenUsing(path, map[string]any{"bolt_timeout": openTimeout})
+	if err == nil {
+		version, verr := index.GetInternal(mappingVersionKey)
+		if verr == nil && string(version) == mappingVersion {
+			return &Index{index: index}, nil
+		}
+		if err := index.Close(); err != nil {
+			return nil, err
+		}
+		if err := os.RemoveAll(path); err != nil {
+			return nil, err
+		}
+		err = bleve.ErrorIndexPathDoesNotExist
+	}
+	if !errors.Is(err, bleve.ErrorIndexPathDoesNotExist) {
+		return nil, err
+	}
+
+	m, err := newMapping()
+	if err != nil {
+		return nil, err
+	}
+	if index, err = bleve.New(path, m); err != nil {
+		return nil, err
+	}
+	if err := index.SetInternal(mappingVersionKey, []byte(mappingVersion)); err != nil {
+		index.Close()
+		return nil, err
+	}
+	return &Index{index: index, created: true}, nil
+}
+
+// 索引是否为本次新建（首次启动或映射版本变化），新建的索引需要从数据库重建
+func (i *Index) Created() bool {
+	return i.created
+}
+
+func (i *Index) Close() error {
+	return i.index.Close()
+}
+
+// 写入或覆盖文档
+func (i *Index) Put(docs ...Document) error {
+	batch := i.index.NewBatch()
+	for _, doc := range docs {
+		if err := batch.Index(docID(doc.DraftID), toIndexDoc(doc)); err != nil {
+			return err
+		}
+	}
+	return i.index.Batch(batch)
+}
+
+// 删除文档，不存在时忽略
+func (i *Index) Delete(draftIDs ...uint) error {
+	batch := i.index.NewBatch()
+	for _, id := range draftIDs {
+		batch.Delete(docID(id))
+	}
+	return i.index.Batch(batch)
+}
+
+// 索引中的全部稿件 ID
+func (i *Index) DraftIDs() ([]uint, error) {
+	count, err := i.index.DocCount()
+	if err != nil || count == 0 {
+		return nil, err
+	}
+	req := bleve.NewSearchRequestOptions(bleve.NewMatchAllQuery(), int(count), 0, false)
+	res, err := i.index.Search(req)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint, 0, len(res.Hits))
+	for _, hit := range res.Hits {
+		if id, err := strconv.ParseUint(hit.ID, 10, 64); err == nil {
+			ids = append(ids, uint(id))
+		}
+	}
+	return ids, nil
+}
+
+// 搜索
+func (i *Index) Search(q Query) (*Result, error) {
+	req := bleve.NewSearchRequestOptions(buildQuery(q), q.Limit, q.Offset, false)
+	req.Fields = []string{FieldTitle, FieldArtists, FieldAlbum, FieldLanguage}
+	req.Highlight = bleve.NewHighlightWithStyle(html.Name)
+	req.Highlight.Fields = []string{FieldTitle, FieldArtists, FieldAlbum, FieldLyrics}
+	req.AddFacet(FieldLanguage, bleve.NewFacetRequest(FieldLanguage, facetSize))
+	req.AddFacet(FieldArtist, bleve.NewFacetRequest(FieldArtist, facetSize))
+	if strings.TrimSpace(q.Text) == "" {
+		req.SortBy([]string{"-" + FieldPublishedAt, "-_id"})
+	}
+
+	res, err := i.index.Search(req)
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{
+		Total: res.Total,
+		Hits:  make([]Hit, 0, len(res.Hits)),
+		Facets: Facets{
+			Languages: facetTerms(res.Facets[FieldLanguage]),
+			Artists:   facetTerms(res.Facets[FieldArtist]),
+		},
+	}
+	for _, hit := range res.Hits {
+		id, err := strconv.ParseUint(hit.ID, 10, 64)
+		if err != nil {
+			continue
+		}
+		result.Hits = append(result.Hits, Hit{
+			DraftID:    uint(id),
+			Score:      hit.Score,
+			Title:      stringField(hit.Fields[FieldTitle]),
+			Artists:    stringsField(hit.Fields[FieldArtists]),
+			Album:      stringField(hit.Fields[FieldAlbum]),
+			Language:   stringField(hit.Fields[FieldLanguage]),
+			Highlights: matchedFragments(hit.Fragments),
+		})
+	}
+	return result, nil
+}
+
+func newMapping() (mapping.IndexMapping, error) {
+	text := bleve.NewTextFieldMapping()
+	text.Analyzer = cjk.AnalyzerName
+	text.IncludeTermVectors = true // 高亮与短语查询需要词向量
+
+	syllables := bleve.NewTextFieldMapping()
+	syllables.Analyzer = syllableAnalyzer
+	syllables.Store = false
+	syllables.IncludeInAll = false
+	syllables.IncludeTermVectors = true
+
+	exact := bleve.NewTextFieldMapping()
+	exact.Analyzer = keyword.Name
+	exact.Store = false
+	exact.IncludeInAll = false
+
+	lang := bleve.NewTextFieldMapping()
+	lang.Analyzer = keyword.Name
+	lang.IncludeInAll = false
+
+	published := bleve.NewDateTimeFieldMapping()
+	published.IncludeInAll = false
+
+	doc := bleve.NewDocumentStaticMapping()
+	doc.AddFieldMappingsAt(FieldTitle, text)
+	doc.AddFieldMappingsAt(FieldArtists, text)
+	doc.AddFieldMappingsAt(FieldArtist, exact)
+	doc.AddFieldMappingsAt(FieldAlbum, text)
+	doc.AddFieldMappingsAt(FieldLanguage, lang)
+	doc.AddFieldMappingsAt(FieldLyrics, text)
+	doc.AddFieldMappingsAt(FieldPublishedAt, published)
+	for _, field := range []string{FieldTitlePinyin, FieldArtistsPinyin, FieldLyricsPinyin, FieldTitleRomaji, FieldLyricsRomaji} {
+		doc.AddFieldMappingsAt(field, syllables)
+	}
+
+	m := bleve.NewIndexMapping()
+	err := m.AddCustomAnalyzer(syllableAnalyzer, map[string]any{
+		"type":          custom.Name,
+		"tokenizer":     whitespace.Name,
+		"token_filters": []string{lowercase.Name},
+	})
+	if err != nil {
+		return nil, err
+	}
+	m.DefaultMapping = doc
+	m.DefaultAnalyzer = cjk.AnalyzerName
+	return m, nil
+}
+
+func buildQuery(q Query) query.Query {
+	var main query.Query
+	if text := strings.TrimSpace(q.Text); text == "" {
+		main = bleve.NewMatchAllQuery()
+	} else {
+		queries := []query.Query{
+			fieldMatch(text, FieldTitle, 3),
+			fieldMatch(text, FieldArtists, 2),
+			fieldMatch(text, FieldAlbum, 1.5),
+			fieldMatch(text, FieldLyrics, 1),
+			fieldPhrase(text, FieldLyrics, 2),
+		}
+		// 歌名、歌手按拼音匹配，容忍简繁体差异与个别拼写错误
+		if py := queryPinyin(text); py != "" {
+			queries = append(queries,
+				fuzzyMatch(py, FieldTitlePinyin, 2),
+				fuzzyMatch(py, FieldArtistsPinyin, 1.5),
+				fieldPhrase(py, FieldLyricsPinyin, 0.8),
+			)
+		}
+		if romaji := queryRomaji(text); romaji != "" {
+			queries = append(queries,
+				fieldPhrase(romaji, FieldTitleRomaji, 2),
+				fieldPhrase(romaji, FieldLyricsRomaji, 0.8),
+			)
+		}
+		main = bleve.NewDisjunctionQuery(queries...)
+	}
+
+	filters := []query.Query{main}
+	if lang := normalizeLanguage(q.Language); lang != "" {
+		filters = append(filters, termQuery(lang, FieldLanguage))
+	}
+	if artist := strings.TrimSpace(q.Artist); artist != "" {
+		filters = append(filters, termQuery(artist, FieldArtist))
+	}
+	if len(filters) == 1 {
+		return main
+	}
+	return bleve.NewConjunctionQuery(filters...)
+}
+
+func fieldMatch(text, field string, boost float64) query.Query {
+	match := bleve.NewMatchQuery(text)
+	match.SetField(field)
+	match.SetBoost(boost)
+	return match
+}
+
+func fieldPhrase(text, field string, boost float64) query.Query {
+	phrase := bleve.NewMatchPhraseQuery(text)
+	phrase.SetField(field)
+	phrase.SetBoost(boost)
+	return phrase
+}
+
+// 所有音节都要出现，每个音节允许一处编辑距离
+func fuzzyMatch(text, field string, boost float64) query.Query {
+	match := bleve.NewMatchQuery(text)
+	match.SetField(field)
+	match.SetBoost(boost)
+	match.SetFuzziness(1)
+	match.SetOperator(query.MatchQueryOperatorAnd)
+	return match
+}
+
+func termQuery(term, field string) query.Query {
+	q := bleve.NewTermQuery(term)
+	q.SetField(field)
+	return q
+}
+
+func toIndexDoc(doc Document) indexDoc {
+	artists := make([]string, 0, len(doc.Artists))
+	for _, artist := range doc.Artists {
+		if artist = strings.TrimSpace(artist); artist != "" {
+			artists = append(artists, artist)
+		}
+	}
+	artistsPinyin := make([]string, len(artists))
+	for k, artist := range artists {
+		artistsPinyin[k] = toPinyin(artist)
+	}
+	return indexDoc{
+		Title:       doc.Title,
+		Artists:     artists,
+		Artist:      artists,
+		Album:       doc.Album,
+		Language:    normalizeLanguage(doc.Language),
+		Lyrics:      doc.Lyrics,
+		PublishedAt: doc.PublishedAt,
+
+		TitlePinyin:   toPinyin(doc.Title),
+		ArtistsPinyin: artistsPinyin,
+		LyricsPinyin:  toPinyin(doc.Lyrics),
+		TitleRomaji:   toRomaji(doc.Title),
+		LyricsRomaji:  toRomaji(doc.Lyrics),
+	}
+}
+
+// 语言标签不区分大小写
+func normalizeLanguage(lang string) string {
+	return strings.ToLower(strings.TrimSpace(lang))
+}
+
+func docID(draftID uint) string {
+	return strconv.FormatUint(uint64(draftID), 10)
+}
+
+func facetTerms(facet *bsearch.FacetResult) []FacetTerm {
+	terms := []FacetTerm{}
+	if facet == nil || facet.Terms == nil {
+		return terms
+	}
+	for _, term := range facet.Terms.Terms() {
+		terms = append(terms, FacetTerm{Term: term.Term, Count: term.Count})
+	}
+	return terms
+}
+
+// 只保留含命中词的片段；未命中的字段 Bleve 也会返回原文
+func matchedFragments(fragments map[string][]string) map[string][]string {
+	result := map[string][]string{}
+	for field, list := range fragments {
+		for _, fragment := range list {
+			if strings.Contains(fragment, "<mark>") {
+				result[field] = append(result[field], fragment)
+			}
+		}
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+func stringField(value any) string {
+	s, _ := value.(string)
+	return s
+}
+
+// 只有一个元素的数组字段会以单个值返回
+func stringsField(value any) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []any:
+		result := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				result = append(result, s)
+			}
+		}
+		return result
+	}
+	return []string{}
+}
@@ -0,0 +1,307 @@
+package search
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testPublishedAt = time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+var testDocuments = []Document{
+	{DraftID: 1, Title: "我爱你", Artists: []string{"卢广仲"}, Language: "zh-Hans", Lyrics: "我爱你 不是因为你是谁"},
+	{DraftID: 2, Title: "さくら", Artists: []string{"森山直太朗"}, Language: "ja", Lyrics: "さくら さくら 今 咲き誇る"},
+	{DraftID: 3, Title: "愛的代價", Artists: []string{"張艾嘉"}, Language: "zh-Hant", Lyrics: "還記得年少時的夢嗎"},
+	{DraftID: 4, Title: "Shape of You", Artists: []string{"Ed Sheeran"}, Language: "EN", Lyrics: "the club is not the best place to find a lover"},
+	{DraftID: 5, Title: "Perfect", Artists: []string{"Ed Sheeran"}, Language: "en", Lyrics: "I found a love for me"},
+}
+
+// 在临时目录新建索引并写入测试文档，发布时间按 DraftID 递增
+func openTestIndex(t *testing.T) *Index {
+	t.Helper()
+	index, err := Open(filepath.Join(t.TempDir(), "index"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { index.Close() })
+	if !index.Created() {
+		t.Fatal("a new index should report Created")
+	}
+	docs := make([]Document, len(testDocuments))
+	for k, doc := range testDocuments {
+		doc.PublishedAt = testPublishedAt.Add(time.Duration(doc.DraftID) * time.Hour)
+		docs[k] = doc
+	}
+	if err := index.Put(docs...); err != nil {
+		t.Fatal(err)
+	}
+	return index
+}
+
+func runSearch(t *testing.T, index *Index, q Query) *Result {
+	t.Helper()
+	if q.Limit == 0 {
+		q.Limit = 10
+	}
+	result, err := index.Search(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func hitIDs(result *Result) []uint {
+	ids := make([]uint, len(result.Hits))
+	for k, hit := range result.Hits {
+		ids[k] = hit.DraftID
+	}
+	return ids
+}
+
+func TestSearchText(t *testing.T) {
+	index := openTestIndex(t)
+	tests := []struct {
+		name string
+		text string
+		want uint
+	}{
+		{"title", "我爱你", 1},
+		{"lyrics", "咲き誇る", 2},
+		{"artist", "sheeran", 4},
+		{"english lyrics", "lover", 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := runSearch(t, index, Query{Text: tt.text})
+			if len(result.Hits) == 0 || result.Hits[0].DraftID != tt.want {
+				t.Fatalf("hits = %v, want %d first", hitIDs(result), tt.want)
+			}
+		})
+	}
+
+	if result := runSearch(t, index, Query{Text: "nothing matches this"}); result.Total != 0 {
+		t.Fatalf("unexpected hits %v", hitIDs(result))
+	}
+}
+
+func TestSearchHitFields(t *testing.T) {
+	index := openTestIndex(t)
+	result := runSearch(t, index, Query{Text: "Shape"})
+	if len(result.Hits) == 0 {
+		t.Fatal("no hits")
+	}
+	hit := result.Hits[0]
+	if hit.DraftID != 4 || hit.Title != "Shape of You" || !reflect.DeepEqual(hit.Artists, []string{"Ed Sheeran"}) || hit.Language != "en" {
+		t.Fatalf("hit = %+v", hit)
+	}
+}
+
+func TestSearchHighlights(t *testing.T) {
+	index := openTestIndex(t)
+	result := runSearch(t, index, Query{Text: "lover"})
+	if len(result.Hits) == 0 {
+		t.Fatal("no hits")
+	}
+	highlights := result.Hits[0].Highlights
+	if len(highlights[FieldLyrics]) == 0 || !strings.Contains(highlights[FieldLyrics][0], "<mark>lover</mark>") {
+		t.Fatalf("highlights = %v", highlights)
+	}
+	// 没有命中的字段不返回片段
+	if _, ok := highlights[FieldTitle]; ok {
+		t.Fatalf("unmatched title highlighted: %v", highlights)
+	}
+}
+
+func TestSearchFacets(t *testing.T) {
+	index := openTestIndex(t)
+	result := runSearch(t, index, Query{})
+	if result.Total != uint64(len(testDocuments)) {
+		t.Fatalf("total = %d", result.Total)
+	}
+	languages := map[string]int{}
+	for _, term := range result.Facets.Languages {
+		languages[term.Term] = term.Count
+	}
+	// 语言标签统一为小写
+	wantLanguages := map[string]int{"en": 2, "ja": 1, "zh-hans": 1, "zh-hant": 1}
+	if !reflect.DeepEqual(languages, wantLanguages) {
+		t.Fatalf("language facet = %v", languages)
+	}
+	if len(result.Facets.Artists) == 0 || result.Facets.Artists[0] != (FacetTerm{Term: "Ed Sheeran", Count: 2}) {
+		t.Fatalf("artist facet = %v", result.Facets.Artists)
+	}
+}
+
+func TestSearchFilters(t *testing.T) {
+	index := openTestIndex(t)
+	tests := []struct {
+		name string
+		q    Query
+		want []uint
+	}{
+		{"language", Query{Language: "EN"}, []uint{5, 4}},
+		{"artist", Query{Artist: "Ed Sheeran"}, []uint{5, 4}},
+		{"artist is exact", Query{Artist: "ed sheeran"}, []uint{}},
+		{"language and text", Query{Text: "love", Language: "ja"}, []uint{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hitIDs(runSearch(t, index, tt.q)); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("hits = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchPaging(t *testing.T) {
+	index := openTestIndex(t)
+	// 空查询按发布时间倒序
+	tests := []struct {
+		offset, limit int
+		want          []uint
+	}{
+		{0, 2, []uint{5, 4}},
+		{2, 2, []uint{3, 2}},
+		{4, 2, []uint{1}},
+		{6, 2, []uint{}},
+	}
+	for _, tt := range tests {
+		result := runSearch(t, index, Query{Offset: tt.offset, Limit: tt.limit})
+		if got := hitIDs(result); !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("offset %d limit %d: hits = %v, want %v", tt.offset, tt.limit, got, tt.want)
+		}
+		if result.Total != uint64(len(testDocuments)) {
+			t.Fatalf("offset %d: total = %d", tt.offset, result.Total)
+		}
+	}
+}
+
+func TestIndexDelete(t *testing.T) {
+	index := openTestIndex(t)
+	if err := index.Delete(4, 99); err != nil {
+		t.Fatal(err)
+	}
+	if got := hitIDs(runSearch(t, index, Query{Artist: "Ed Sheeran"})); !reflect.DeepEqual(got, []uint{5}) {
+		t.Fatalf("hits = %v", got)
+	}
+	ids, err := index.DraftIDs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != len(testDocuments)-1 {
+		t.Fatalf("draft ids = %v", ids)
+	}
+}
+
+func TestOpenExisting(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index")
+	index, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := index.Put(testDocuments[0]); err != nil {
+		t.Fatal(err)
+	}
+	index.Close()
+
+	index, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+	if index.Created() {
+		t.Fatal("reopened index should not report Created")
+	}
+	if ids, err := index.DraftIDs(); err != nil || !reflect.DeepEqual(ids, []uint{1}) {
+		t.Fatalf("draft ids = %v, %v", ids, err)
+	}
+}
+
+func TestSearchReadings(t *testing.T) {
+	index := openTestIndex(t)
+	tests := []struct {
+		name string
+		text string
+		want uint
+	}{
+		{"pinyin", "wo ai ni", 1},
+		{"pinyin without spaces", "woaini", 1},
+		{"pinyin typo", "wo ai mi", 1},
+		{"romaji", "sakura", 2},
+		{"katakana", "サクラ", 2},
+		{"simplified finds traditional", "爱的代价", 3},
+		{"traditional finds simplified", "我愛你", 1},
+		{"pinyin artist", "zhang ai jia", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := runSearch(t, index, Query{Text: tt.text})
+			if len(result.Hits) == 0 || result.Hits[0].DraftID != tt.want {
+				t.Fatalf("hits = %v, want %d first", hitIDs(result), tt.want)
+			}
+		})
+	}
+}
+
+func TestToPinyin(t *testing.T) {
+	tests := []struct{ text, want string }{
+		{"我爱你", "wo ai ni"},
+		{"愛的代價", "ai de dai jia"},
+		{"爱的代价", "ai de dai jia"},
+		{"Hello 世界!", "hello shi jie"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := toPinyin(tt.text); got != tt.want {
+			t.Errorf("toPinyin(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestQueryPinyin(t *testing.T) {
+	tests := []struct{ text, want string }{
+		{"woaini", "wo ai ni"},
+		{"wo ai ni", "wo ai ni"},
+		{"xian", "xian"},
+		{"爱 ni", "ai ni"},
+		{"rhythm", ""},
+	}
+	for _, tt := range tests {
+		if got := queryPinyin(tt.text); got != tt.want {
+			t.Errorf("queryPinyin(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestToRomaji(t *testing.T) {
+	tests := []struct{ text, want string }{
+		{"さくら", "sa ku ra"},
+		{"サクラ", "sa ku ra"},
+		{"きょう", "kyo u"},
+		{"ちょっと", "cho tto"},
+		{"まっちゃ", "ma tcha"},
+		{"ラーメン", "ra me n"},
+		{"君の名は", "no ha"},
+	}
+	for _, tt := range tests {
+		if got := toRomaji(tt.text); got != tt.want {
+			t.Errorf("toRomaji(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestQueryRomaji(t *testing.T) {
+	tests := []struct{ text, want string }{
+		{"sakura", "sa ku ra"},
+		{"chotto", "cho tto"},
+		{"matcha", "ma tcha"},
+		{"rhythm", ""},
+	}
+	for _, tt := range tests {
+		if got := queryRomaji(tt.text); got != tt.want {
+			t.Errorf("queryRomaji(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/xiaowumin-mark/AMLX/cdn"
+	"github.com/xiaowumin-mark/AMLX/config"
+	"github.com/xiaowumin-mark/AMLX/logx"
+	"github.com/xiaowumin-mark/AMLX/model"
+	"github.com/xiaowumin-mark/AMLX/store"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCDNDisabled     = errors.New("cdn control is not configured")
+	ErrCDNUnauthorized = errors.New("invalid cdn token")
+	ErrCDNNodeNotFound = errors.New("cdn node not found")
+)
+
+// 节点状态变化记录每次返回的数量
+const cdnNodeEventLimit = 100
+
+type CDNNodeService interface {
+	Authenticate(ctx context.Context, token string) error                                                           // 校验控制面 token
+	Register(ctx context.Context, req cdn.RegisterRequest) (*cdn.Registration, error)                               // 注册节点并下发凭据，同名节点轮换凭据
+	Heartbeat(ctx context.Context, nodeID, secret string, req cdn.HeartbeatRequest) (*cdn.HeartbeatResponse, error) // 节点心跳，以节点凭据认证
+	Nodes(ctx context.Context, status model.CDNNodeStatus) ([]model.CDNNode, error)                                 // 节点列表
+	ListEvents(ctx context.Context, nodeID string) ([]model.CDNNodeEvent, error)                                    // 节点状态变化记录
+	RecordPushError(ctx context.Context, node *model.CDNNode) error                                                 // 记录一次推送失败
+	Check(ctx context.Context) (int, error)                                                                         // 重新计算全部节点状态，返回状态变化的数量
+}
+
+type cdnNodeService struct {
+	cfg     config.CDNConfig
+	tx      store.Transactor
+	nodes   store.CDNNodeStore
+	publish store.PublishStore
+}
+
+func NewCDNNodeService(cfg config.CDNConfig, tx store.Transactor, nodes store.CDNNodeStore, publish store.PublishStore) CDNNodeService {
+	return &cdnNodeService{cfg: cfg, tx: tx, nodes: nodes, publish: publish}
+}
+
+func (s *cdnNodeService) Authenticate(ctx context.Context, token string) error {
+	if s.cfg.Token == "" {
+		return ErrCDNDisabled
+	}
+	if subtle.ConstantTimeCompare([]byte(token), []byte(s.cfg.Token)) != 1 {
+		return ErrCDNUnauthorized
+	}
+	return nil
+}
+
+// 注册视为一次心跳；游标保留，节点可带着本地缓存重新注册
+func (s *cdnNodeService) Register(ctx context.Context, req cdn.RegisterRequest) (*cdn.Registration, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || !validEndpoint(req.Endpoint) {
+		return nil, ErrInvalidInput
+	}
+	latest, err := s.publish.LatestID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	secret := randomHex(32)
+	var node *model.CDNNode
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		node, err = s.nodes.GetByName(ctx, name)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			node, err = &model.CDNNode{NodeID: randomHex(8), Name: name}, nil
+		}
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		node.Region = strings.TrimSpace(req.Region)
+		node.Endpoint = strings.TrimRight(req.Endpoint, "/")
+		node.SecretHash = hashSecret(secret)
+		node.LastHeartbeatAt = &now
+		if node.ID == 0 {
+			if err := s.nodes.Create(ctx, node); err != nil {
+				return err
+			}
+		}
+		return s.applyStatus(ctx, node, latest, "registered")
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &cdn.Registration{Node: toNodeInfo(node), Secret: secret}, nil
+}
+
+func (s *cdnNodeService) Heartbeat(ctx context.Context, nodeID, secret string, req cdn.HeartbeatRequest) (*cdn.HeartbeatResponse, error) {
+	if s.cfg.Token == "" {
+		return nil, ErrCDNDisabled
+	}
+	latest, err := s.publish.LatestID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var node *model.CDNNode
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		node, err = s.nodes.GetByNodeIDForUpdate(ctx, nodeID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrCDNUnauthorized // 不区分节点不存在与凭据错误
+		}
+		if err != nil {
+			return err
+		}
+		if subtle.ConstantTimeCompare([]byte(hashSecret(secret)), []byte(node.SecretHash)) != 1 {
+			return ErrCDNUnauthorized
+		}
+		now := time.Now()
+		node.Version = strings.TrimSpace(req.Version)
+		node.Cursor = req.Cursor
+		node.ReportedErrors += req.Errors
+		node.LastHeartbeatAt = &now
+		return s.applyStatus(ctx, node, latest, "heartbeat")
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &cdn.HeartbeatResponse{Status: string(node.Status), LatestManifestID: latest, Lag: node.Lag}, nil
+}
+
+func (s *cdnNodeService) Nodes(ctx context.Context, status model.CDNNodeStatus) ([]model.CDNNode, error) {
+	return s.nodes.List(ctx, status)
+}
+
+func (s *cdnNodeService) ListEvents(ctx context.Context, nodeID string) ([]model.CDNNodeEvent, error) {
+	node, err := s.nodes.GetByNodeID(ctx, nodeID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrCDNNodeNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.nodes.ListEvents(ctx, node.ID, cdnNodeEventLimit)
+}
+
+func (s *cdnNodeService) RecordPushError(ctx context.Context, node *model.CDNNode) error {
+	return s.nodes.AddPushError(ctx, node.ID)
+}
+
+// 后台巡检：按最新清单与心跳时间重新计算每个节点的落后数与状态
+func (s *cdnNodeService) Check(ctx context.Context) (int, error) {
+	latest, err := s.publish.LatestID(ctx)
+	if err != nil {
+		return 0, err
+	}
+	nodes, err := s.nodes.List(ctx, "")
+	if err != nil {
+		return 0, err
+	}
+	changed := 0
+	for i := range nodes {
+		status, _ := s.evaluate(&nodes[i], latest)
+		if status == nodes[i].Status && lagOf(&nodes[i], latest) == nodes[i].Lag {
+			continue
+		}
+		err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+			node, err := s.nodes.GetByNodeIDForUpdate(ctx, nodes[i].NodeID)
+			if err != nil {
+				return err
+			}
+			before := node.Status
+			if err := s.applyStatus(ctx, node, latest, ""); err != nil {
+				return err
+			}
+			if node.Status != before {
+				changed++
+			}
+			return nil
+		})
+		if err != nil {
+			return changed, err
+		}
+	}
+	return changed, nil
+}
+
+// 更新落后数与状态，状态变化时记录；reason 为空时使用判定原因
+func (s *cdnNodeService) applyStatus(ctx context.Context, node *model.CDNNode, latest uint, reason string) error {
+	status, why := s.evaluate(node, latest)
+	if reason == "" || status != model.CDNNodeHealthy {
+		reason = why
+	}
+	node.Lag = lagOf(node, latest)
+	from := node.Status
+	node.Status = status
+	if err := s.nodes.Update(ctx, node); err != nil {
+		return err
+	}
+	if from == status {
+		return nil
+	}
+	logx.L().Info("cdn node status changed", "node", node.Name, "from", from, "to", status, "reason", reason)
+	return s.nodes.CreateEvent(ctx, &model.CDNNodeEvent{
+		NodeID:     node.ID,
+		FromStatus: from,
+		ToStatus:   status,
+		Reason:     reason,
+	})
+}
+
+func (s *cdnNodeService) evaluate(node *model.CDNNode, latest uint) (model.CDNNodeStatus, string) {
+	if node.LastHeartbeatAt == nil || time.Since(*node.LastHeartbeatAt) > s.cfg.StaleAfter {
+		return model.CDNNodeStale, fmt.Sprintf("no heartbeat for %s", s.cfg.StaleAfter)
+	}
+	if lag := lagOf(node, latest); lag > s.cfg.MaxLag {
+		return model.CDNNodeLagging, fmt.Sprintf("%d manifests behind", lag)
+	}
+	return model.CDNNodeHealthy, "up to date"
+}
+
+func lagOf(node *model.CDNNode, latest uint) uint {
+	if node.Cursor >= latest {
+		return 0
+	}
+	return latest - node.Cursor
+}
+
+func toNodeInfo(node *model.CDNNode) cdn.Node {
+	return cdn.Node{
+		ID:           node.NodeID,
+		Name:         node.Name,
+		Region:       node.Region,
+		Endpoint:     node.Endpoint,
+		RegisteredAt: node.CreatedAt,
+	}
+}
+
+// 推送地址可为空，否则必须是 http(s) 绝对地址
+func validEndpoint(endpoint string) bool {
+	if endpoint == "" {
+		return true
+	}
+	u, err := url.Parse(endpoint)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex(n int) string {
+	buf := make([]byte, n)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
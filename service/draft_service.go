@@ -0,0 +1,590 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/xiaowumin-mark/AMLX/logx"
+	"github.com/xiaowumin-mark/AMLX/lyrics"
+	"github.com/xiaowumin-mark/AMLX/lyrics/lint"
+	"github.com/xiaowumin-mark/AMLX/lyrics/ttml"
+	"github.com/xiaowumin-mark/AMLX/model"
+	"github.com/xiaowumin-mark/AMLX/store"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrDraftNotFound  = errors.New("draft not found")
+	ErrDraftForbidden = errors.New("draft access denied")
+	ErrLintFailed     = errors.New("lyrics content has lint errors")
+)
+
+// LintError 提交审核时内容检查未通过，附带检查报告
+type LintError struct {
+	Report *lint.Report
+}
+
+func (e *LintError) Error() string {
+	return fmt.Sprintf("%s: %d error(s)", ErrLintFailed, e.Report.Errors)
+}
+
+func (e *LintError) Unwrap() error {
+	return ErrLintFailed
+}
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+type CreateDraftRequest struct {
+	Title              string
+	Artists            []string
+	Album              string
+	Language           string
+	AllowStageRollback *bool
+	PublishTarget      string
+	SongID             *uint         // 可选，目录中的歌曲，补齐空缺的标题、艺术家与专辑
+	Import             *ImportLyrics // 可选，导入已有歌词作为第一个版本
+}
+
+type UpdateDraftRequest struct {
+	Title              *string
+	Artists            *[]string
+	Album              *string
+	Language           *string
+	AllowStageRollback *bool
+	PublishTarget      *string
+	SongID             *uint // 为 0 时取消关联
+}
+
+type ListDraftsRequest struct {
+	Status   string
+	Page     int
+	PageSize int
+}
+
+type DraftService interface {
+	Create(ctx context.Context, userID uint, req CreateDraftRequest) (*model.LyricsDraft, error)                               // 创建稿件
+	Get(ctx context.Context, userID, id uint) (*model.LyricsDraft, error)                                                      // 获取稿件
+	List(ctx context.Context, userID uint, req ListDraftsRequest) ([]model.LyricsDraft, int64, error)                          // 列出稿件
+	Update(ctx context.Context, userID, id uint, req UpdateDraftRequest) (*model.LyricsDraft, error)                           // 更新稿件，仅限预审核中
+	Delete(ctx context.Context, userID, id uint) error                                                                         // 删除稿件
+	Advance(ctx context.Context, userID, id uint) (*model.LyricsDraft, error)                                                  // 前进到下一阶段
+	Submit(ctx context.Context, userID, id uint) (*model.LyricsDraft, error)                                                   // 提交审核
+	ListTransitions(ctx context.Context, userID, id uint) ([]model.DraftTransition, error)                                     // 流转历史
+	Lint(ctx context.Context, userID, id uint) (*lint.Report, error)                                                           // 检查最新版本内容
+	Fork(ctx context.Context, source *model.LyricsDraft, stage model.WorkflowStage, reason string) (*model.LyricsDraft, error) // 由已发布稿件的审核快照开启修正稿件
+}
+
+// DraftChangeHook 稿件信息修改、删除或回退后调用（如更新搜索索引），失败只记录日志
+type DraftChangeHook func(ctx context.Context, draft *model.LyricsDraft) error
+
+func runChangeHook(ctx context.Context, hook DraftChangeHook, draft *model.LyricsDraft) {
+	if hook == nil {
+		return
+	}
+	if err := hook(ctx, draft); err != nil {
+		logx.L().Warn("draft change hook failed", "draft_id", draft.ID, "error", err)
+	}
+}
+
+type draftService struct {
+	tx          store.Transactor
+	drafts      store.DraftStore
+	transitions store.DraftTransitionStore
+	versions    store.VersionStore
+	requests    store.LyricRequestStore
+	songs       SongService
+	flow        *draftTransitioner
+	onChange    DraftChangeHook
+}
+
+func NewDraftService(tx store.Transactor, drafts store.DraftStore, transitions store.DraftTransitionStore, versions store.VersionStore, requests store.LyricRequestStore, songs SongService, onTransition TransitionHook, onChange DraftChangeHook) DraftService {
+	return &draftService{
+		tx:          tx,
+		drafts:      drafts,
+		transitions: transitions,
+		versions:    versions,
+		requests:    requests,
+		songs:       songs,
+		flow:        &draftTransitioner{tx: tx, drafts: drafts, transitions: transitions, hook: onTransition},
+		onChange:    onChange,
+	}
+}
+
+// 创建稿件
+func (s *draftService) Create(ctx context.Context, userID uint, req CreateDraftRequest) (*model.LyricsDraft, error) {
+	if userID == 0 {
+		return nil, ErrInvalidInput
+	}
+	// 导入的歌词可以补齐标题、艺术家与专辑
+	var doc *lyrics.Document
+	if req.Import != nil {
+		decoded, err := DecodeLyrics(req.Import.Format, req.Import.Content)
+		if err != nil {
+			return nil, err
+		}
+		doc = decoded
+		if strings.TrimSpace(req.Title) == "" {
+			req.Title = doc.MetaValue(lyrics.MetaMusicName)
+		}
+		if len(req.Artists) == 0 {
+			req.Artists = doc.MetaValues(lyrics.MetaArtists)
+		}
+		if strings.TrimSpace(req.Album) == "" {
+			req.Album = doc.MetaValue(lyrics.MetaAlbum)
+		}
+	}
+	song, err := s.draftSong(ctx, req.SongID, doc)
+	if err != nil {
+		return nil, err
+	}
+	if song != nil {
+		if strings.TrimSpace(req.Title) == "" {
+			req.Title = song.Song.Title
+		}
+		if len(req.Artists) == 0 {
+			req.Artists = song.Performers()
+		}
+		if strings.TrimSpace(req.Album) == "" && song.Album != nil {
+			req.Album = song.Album.Title
+		}
+	}
+
+	title := strings.TrimSpace(req.Title)
+	if title == "" {
+		return nil, ErrInvalidInput
+	}
+	artists, err := EncodeArtists(req.Artists)
+	if err != nil {
+		return nil, err
+	}
+	target, err := normalizePublishTarget(req.PublishTarget)
+	if err != nil {
+		return nil, err
+	}
+	allowRollback := true
+	if req.AllowStageRollback != nil {
+		allowRollback = *req.AllowStageRollback
+	}
+
+	stage := model.StageLyricRequest
+	draft := &model.LyricsDraft{
+		Title:              title,
+		Artists:            artists,
+		Album:              strings.TrimSpace(req.Album),
+		Language:           strings.TrimSpace(req.Language),
+		OwnerUserID:        userID,
+		Status:             model.DraftPreReview,
+		WorkflowStage:      &stage,
+		AllowStageRollback: allowRollback,
+		PublishTarget:      target,
+	}
+	if song != nil {
+		draft.SongID = &song.Song.ID
+	}
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.drafts.Create(ctx, draft); err != nil {
+			return err
+		}
+		if doc == nil {
+			return nil
+		}
+		fillDocumentMeta(doc, draft)
+		return s.versions.Create(ctx, &model.LyricsVersion{
+			DraftID:       draft.ID,
+			WorkflowStage: stage,
+			Content:       ttml.MarshalString(doc),
+			CreatedBy:     userID,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return draft, nil
+}
+
+// 修正稿件复制原稿件的信息与所有者，以审核快照内容作为指定阶段的第一个版本，
+// 并以系统身份记录一条 FORK 流转，reason 说明开启原因
+func (s *draftService) Fork(ctx context.Context, source *model.LyricsDraft, stage model.WorkflowStage, reason string) (*model.LyricsDraft, error) {
+	if !isPublished(source) {
+		return nil, ErrInvalidDraftState
+	}
+	snapshot, err := s.versions.GetByID(ctx, *source.ReviewSnapshotID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrSnapshotNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	draft := &model.LyricsDraft{
+		Title:              source.Title,
+		Artists:            source.Artists,
+		Album:              source.Album,
+		Language:           source.Language,
+		SongID:             source.SongID,
+		OwnerUserID:        source.OwnerUserID,
+		Status:             model.DraftPreReview,
+		WorkflowStage:      &stage,
+		AllowStageRollback: source.AllowStageRollback,
+		SourceVersionID:    &snapshot.ID,
+		PublishTarget:      source.PublishTarget,
+	}
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.drafts.Create(ctx, draft); err != nil {
+			return err
+		}
+		err := s.versions.Create(ctx, &model.LyricsVersion{
+			DraftID:       draft.ID,
+			WorkflowStage: stage,
+			Content:       snapshot.Content,
+			CreatedBy:     source.OwnerUserID,
+		})
+		if err != nil {
+			return err
+		}
+		return s.transitions.Create(ctx, &model.DraftTransition{
+			DraftID:     draft.ID,
+			Action:      model.ActionFork,
+			ToStatus:    draft.Status,
+			ToStage:     draft.WorkflowStage,
+			ActorUserID: systemActorID,
+			Reason:      reason,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return draft, nil
+}
+
+// 获取稿件
+func (s *draftService) Get(ctx context.Context, userID, id uint) (*model.LyricsDraft, error) {
+	return s.getOwned(ctx, userID, id)
+}
+
+// 列出稿件
+func (s *draftService) List(ctx context.Context, userID uint, req ListDraftsRequest) ([]model.LyricsDraft, int64, error) {
+	if userID == 0 {
+		return nil, 0, ErrInvalidInput
+	}
+	status, err := normalizeDraftStatus(req.Status)
+	if err != nil {
+		return nil, 0, err
+	}
+	offset, limit := pagination(req.Page, req.PageSize)
+	return s.drafts.List(ctx, store.DraftFilter{
+		OwnerUserID: userID,
+		Status:      status,
+		Offset:      offset,
+		Limit:       limit,
+	})
+}
+
+// 更新稿件，与删除一样只允许在预审核中修改；审核中的稿件信息已随快照冻结
+func (s *draftService) Update(ctx context.Context, userID, id uint, req UpdateDraftRequest) (*model.LyricsDraft, error) {
+	if req.Title == nil && req.Artists == nil && req.Album == nil && req.Language == nil &&
+		req.AllowStageRollback == nil && req.PublishTarget == nil && req.SongID == nil {
+		return nil, ErrInvalidInput
+	}
+	if userID == 0 || id == 0 {
+		return nil, ErrInvalidInput
+	}
+	var draft *model.LyricsDraft
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		draft, err = s.drafts.GetByIDForUpdate(ctx, id)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrDraftNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if draft.OwnerUserID != userID {
+			return ErrDraftForbidden
+		}
+		if draft.Status != model.DraftPreReview {
+			return ErrInvalidDraftState
+		}
+
+		if req.Title != nil {
+			value := strings.TrimSpace(*req.Title)
+			if value == "" {
+				return ErrInvalidInput
+			}
+			draft.Title = value
+		}
+		if req.Artists != nil {
+			value, err := EncodeArtists(*req.Artists)
+			if err != nil {
+				return err
+			}
+			draft.Artists = value
+		}
+		if req.Album != nil {
+			draft.Album = strings.TrimSpace(*req.Album)
+		}
+		if req.Language != nil {
+			draft.Language = strings.TrimSpace(*req.Language)
+		}
+		if req.AllowStageRollback != nil {
+			draft.AllowStageRollback = *req.AllowStageRollback
+		}
+		if req.PublishTarget != nil {
+			value, err := normalizePublishTarget(*req.PublishTarget)
+			if err != nil {
+				return err
+			}
+			draft.PublishTarget = value
+		}
+		if req.SongID != nil {
+			draft.SongID = nil
+			if *req.SongID != 0 {
+				if _, err := s.songs.Get(ctx, *req.SongID); err != nil {
+					return err
+				}
+				draft.SongID = req.SongID
+			}
+		}
+		return s.drafts.UpdateInfo(ctx, draft)
+	})
+	if err != nil {
+		return nil, err
+	}
+	runChangeHook(ctx, s.onChange, draft)
+	return draft, nil
+}
+
+// 稿件关联的歌曲：指定时必须存在；未指定时按导入歌词中的平台 ID 查找，找不到时不关联
+func (s *draftService) draftSong(ctx context.Context, songID *uint, doc *lyrics.Document) (*SongDetail, error) {
+	if songID != nil {
+		return s.songs.Get(ctx, *songID)
+	}
+	if doc == nil {
+		return nil, nil
+	}
+	for _, ref := range songRefs(doc) {
+		song, err := s.songs.Lookup(ctx, ref.Platform, ref.ID)
+		if err == nil {
+			return song, nil
+		}
+		if !errors.Is(err, ErrSongNotFound) && !errors.Is(err, ErrInvalidInput) {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+// 删除稿件，只允许删除预审核中的稿件；审核中或已发布的稿件关联着快照、索引与发布记录。
+// 稿件认领的求歌词请求在同一事务中重新开放
+func (s *draftService) Delete(ctx context.Context, userID, id uint) error {
+	if userID == 0 || id == 0 {
+		return ErrInvalidInput
+	}
+	var draft *model.LyricsDraft
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		draft, err = s.drafts.GetByIDForUpdate(ctx, id)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrDraftNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if draft.OwnerUserID != userID {
+			return ErrDraftForbidden
+		}
+		if draft.Status != model.DraftPreReview {
+			return ErrInvalidDraftState
+		}
+		if err := s.requests.ReopenByDraft(ctx, id); err != nil {
+			return err
+		}
+		return s.drafts.Delete(ctx, id)
+	})
+	if err != nil {
+		return err
+	}
+	runChangeHook(ctx, s.onChange, draft)
+	return nil
+}
+
+// 前进到下一阶段
+func (s *draftService) Advance(ctx context.Context, userID, id uint) (*model.LyricsDraft, error) {
+	return s.flow.transition(ctx, transitionRequest{
+		DraftID: id,
+		ActorID: userID,
+		Action:  model.ActionAdvance,
+		Check:   requireOwner(userID),
+	})
+}
+
+// 提交审核，同时冻结当前内容为审核快照
+func (s *draftService) Submit(ctx context.Context, userID, id uint) (*model.LyricsDraft, error) {
+	return s.flow.transition(ctx, transitionRequest{
+		DraftID: id,
+		ActorID: userID,
+		Action:  model.ActionSubmit,
+		Check:   requireOwner(userID),
+		Apply: func(ctx context.Context, draft *model.LyricsDraft) error {
+			content, err := s.headContent(ctx, draft.ID)
+			if err != nil {
+				return err
+			}
+			// 内容检查存在错误时不允许进入审核
+			if report := lint.CheckTTML(content); report.HasErrors() {
+				return &LintError{Report: report}
+			}
+			snapshot, err := s.createSnapshot(ctx, draft.ID, userID, content)
+			if err != nil {
+				return err
+			}
+			draft.ReviewSnapshotID = &snapshot.ID
+			return nil
+		},
+	})
+}
+
+// 最新版本内容，尚无版本时为空
+func (s *draftService) headContent(ctx context.Context, draftID uint) (string, error) {
+	head, err := s.versions.GetHead(ctx, draftID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return head.Content, nil
+}
+
+// 以给定内容创建审核快照
+func (s *draftService) createSnapshot(ctx context.Context, draftID, userID uint, content string) (*model.LyricsVersion, error) {
+	snapshot := &model.LyricsVersion{
+		DraftID:       draftID,
+		WorkflowStage: model.StageCheck,
+		Content:       content,
+		IsSnapshot:    true,
+		CreatedBy:     userID,
+	}
+	if err := s.versions.Create(ctx, snapshot); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// 检查最新版本内容
+func (s *draftService) Lint(ctx context.Context, userID, id uint) (*lint.Report, error) {
+	if _, err := s.getOwned(ctx, userID, id); err != nil {
+		return nil, err
+	}
+	content, err := s.headContent(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return lint.CheckTTML(content), nil
+}
+
+// 流转历史
+func (s *draftService) ListTransitions(ctx context.Context, userID, id uint) ([]model.DraftTransition, error) {
+	if _, err := s.getOwned(ctx, userID, id); err != nil {
+		return nil, err
+	}
+	return s.transitions.ListByDraft(ctx, id)
+}
+
+// 流转前校验稿件所有者
+func requireOwner(userID uint) func(ctx context.Context, draft *model.LyricsDraft) error {
+	return func(ctx context.Context, draft *model.LyricsDraft) error {
+		if draft.OwnerUserID != userID {
+			return ErrDraftForbidden
+		}
+		return nil
+	}
+}
+
+// 获取稿件并校验所有者
+func (s *draftService) getOwned(ctx context.Context, userID, id uint) (*model.LyricsDraft, error) {
+	if userID == 0 || id == 0 {
+		return nil, ErrInvalidInput
+	}
+	draft, err := s.drafts.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrDraftNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if draft.OwnerUserID != userID {
+		return nil, ErrDraftForbidden
+	}
+	return draft, nil
+}
+
+// 艺术家列表编码为 JSON 字符串
+func EncodeArtists(artists []string) (string, error) {
+	cleaned := make([]string, 0, len(artists))
+	for _, artist := range artists {
+		artist = strings.TrimSpace(artist)
+		if artist != "" {
+			cleaned = append(cleaned, artist)
+		}
+	}
+	data, err := json.Marshal(cleaned)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// 解析艺术家 JSON 字符串，兼容纯文本
+func DecodeArtists(value string) []string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return []string{}
+	}
+	var artists []string
+	if err := json.Unmarshal([]byte(value), &artists); err == nil {
+		return artists
+	}
+	return []string{value}
+}
+
+func normalizePublishTarget(value string) (string, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	switch value {
+	case "":
+		return model.PublishTargetAMLX, nil
+	case model.PublishTargetAMLX, model.PublishTargetGithub, model.PublishTargetBoth:
+		return value, nil
+	default:
+		return "", ErrInvalidInput
+	}
+}
+
+func normalizeDraftStatus(value string) (model.DraftStatus, error) {
+	status := model.DraftStatus(strings.ToUpper(strings.TrimSpace(value)))
+	switch status {
+	case "", model.DraftPreReview, model.DraftInReview, model.DraftReviewDone:
+		return status, nil
+	default:
+		return "", ErrInvalidInput
+	}
+}
+
+// 分页参数转换为 offset / limit
+func pagination(page, pageSize int) (int, int) {
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+	return (page - 1) * pageSize, pageSize
+}
@@ -0,0 +1,265 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/xiaowumin-mark/AMLX/logx"
+	"github.com/xiaowumin-mark/AMLX/model"
+	"github.com/xiaowumin-mark/AMLX/store"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidTransition = errors.New("invalid draft transition")
+	ErrInvalidDraftState = errors.New("invalid draft state")
+)
+
+// 稿件状态：Status + WorkflowStage（仅 PRE_REVIEW 时存在）
+type DraftState struct {
+	Status model.DraftStatus
+	Stage  *model.WorkflowStage
+}
+
+func (s DraftState) String() string {
+	if s.Stage == nil {
+		return string(s.Status)
+	}
+	return fmt.Sprintf("%s/%s", s.Status, *s.Stage)
+}
+
+// TransitionError 描述一次被拒绝的状态流转
+type TransitionError struct {
+	Action model.TransitionAction
+	From   DraftState
+	Target *model.WorkflowStage
+	Reason string
+}
+
+func (e *TransitionError) Error() string {
+	msg := fmt.Sprintf("cannot %s draft in %s", e.Action, e.From)
+	if e.Target != nil {
+		msg += fmt.Sprintf(" to %s", *e.Target)
+	}
+	if e.Reason != "" {
+		msg += ": " + e.Reason
+	}
+	return msg
+}
+
+func (e *TransitionError) Unwrap() error {
+	return ErrInvalidTransition
+}
+
+// 预审核阶段顺序
+var stageOrder = []model.WorkflowStage{
+	model.StageLyricRequest,
+	model.StageLyricCompleted,
+	model.StageRough,
+	model.StageFine,
+	model.StageCheck,
+}
+
+// 阶段序号，未知阶段返回 -1
+func StageIndex(stage model.WorkflowStage) int {
+	for i, s := range stageOrder {
+		if s == stage {
+			return i
+		}
+	}
+	return -1
+}
+
+// 下一个预审核阶段
+func NextStage(stage model.WorkflowStage) (model.WorkflowStage, bool) {
+	idx := StageIndex(stage)
+	if idx < 0 || idx+1 >= len(stageOrder) {
+		return "", false
+	}
+	return stageOrder[idx+1], true
+}
+
+func StateOf(draft *model.LyricsDraft) DraftState {
+	return DraftState{Status: draft.Status, Stage: draft.WorkflowStage}
+}
+
+// 校验状态自身是否合法
+func validateState(state DraftState) error {
+	switch state.Status {
+	case model.DraftPreReview:
+		if state.Stage == nil || StageIndex(*state.Stage) < 0 {
+			return ErrInvalidDraftState
+		}
+	case model.DraftInReview, model.DraftReviewDone:
+		if state.Stage != nil {
+			return ErrInvalidDraftState
+		}
+	default:
+		return ErrInvalidDraftState
+	}
+	return nil
+}
+
+// ApplyTransition 计算稿件在指定动作下的目标状态。
+// target 仅 ADVANCE（可选）、REJECT、ROLLBACK 使用；离开 PRE_REVIEW 时清空阶段。
+func ApplyTransition(from DraftState, action model.TransitionAction, target *model.WorkflowStage) (DraftState, error) {
+	reject := func(reason string) (DraftState, error) {
+		return DraftState{}, &TransitionError{Action: action, From: from, Target: target, Reason: reason}
+	}
+	if err := validateState(from); err != nil {
+		return reject("source state is invalid")
+	}
+	if target != nil && StageIndex(*target) < 0 {
+		return reject("unknown target stage")
+	}
+
+	switch action {
+	case model.ActionAdvance:
+		if from.Status != model.DraftPreReview {
+			return reject("draft is not in pre-review")
+		}
+		next, ok := NextStage(*from.Stage)
+		if !ok {
+			return reject("CHECK must be submitted for review")
+		}
+		if target != nil && *target != next {
+			return reject("stages can only advance one step")
+		}
+		return DraftState{Status: model.DraftPreReview, Stage: &next}, nil
+
+	case model.ActionSubmit:
+		if from.Status != model.DraftPreReview || *from.Stage != model.StageCheck {
+			return reject("only drafts in CHECK can be submitted")
+		}
+		if target != nil {
+			return reject("submit does not take a target stage")
+		}
+		return DraftState{Status: model.DraftInReview}, nil
+
+	case model.ActionApprove:
+		if from.Status != model.DraftInReview {
+			return reject("draft is not in review")
+		}
+		if target != nil {
+			return reject("approve does not take a target stage")
+		}
+		return DraftState{Status: model.DraftReviewDone}, nil
+
+	case model.ActionReject:
+		if from.Status != model.DraftInReview {
+			return reject("draft is not in review")
+		}
+		if target == nil {
+			return reject("reject requires a target stage")
+		}
+		stage := *target
+		return DraftState{Status: model.DraftPreReview, Stage: &stage}, nil
+
+	case model.ActionRollback:
+		if from.Status != model.DraftPreReview {
+			return reject("draft is not in pre-review")
+		}
+		if target == nil {
+			return reject("rollback requires a target stage")
+		}
+		if StageIndex(*target) >= StageIndex(*from.Stage) {
+			return reject("rollback target must be an earlier stage")
+		}
+		stage := *target
+		return DraftState{Status: model.DraftPreReview, Stage: &stage}, nil
+	}
+	return reject("unknown action")
+}
+
+// 状态流转请求
+type transitionRequest struct {
+	DraftID uint
+	ActorID uint
+	Action  model.TransitionAction
+	Target  *model.WorkflowStage
+	Reason  string
+
+	// 在流转前校验权限等前置条件，draft 已加锁
+	Check func(ctx context.Context, draft *model.LyricsDraft) error
+	// 在保存前修改稿件或写入关联数据，与流转处于同一事务。
+	// 只有 UpdateState 涵盖的字段会随流转保存，其他字段需自行写入
+	Apply func(ctx context.Context, draft *model.LyricsDraft) error
+}
+
+// 系统动作（如自动开启修正稿件）记录的操作者 ID
+const systemActorID = 0
+
+// 流转回调：流转事务提交后调用（如累加统计），失败只记录日志，不影响流转
+type TransitionHook func(ctx context.Context, draft *model.LyricsDraft, transition *model.DraftTransition) error
+
+// 在事务中执行稿件状态流转，并记录流转历史
+type draftTransitioner struct {
+	tx          store.Transactor
+	drafts      store.DraftStore
+	transitions store.DraftTransitionStore
+	hook        TransitionHook // 可为空
+}
+
+func (t *draftTransitioner) transition(ctx context.Context, req transitionRequest) (*model.LyricsDraft, error) {
+	if req.DraftID == 0 || req.ActorID == 0 {
+		return nil, ErrInvalidInput
+	}
+	var result *model.LyricsDraft
+	var recorded *model.DraftTransition
+	err := t.tx.WithinTx(ctx, func(ctx context.Context) error {
+		draft, err := t.drafts.GetByIDForUpdate(ctx, req.DraftID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrDraftNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if req.Check != nil {
+			if err := req.Check(ctx, draft); err != nil {
+				return err
+			}
+		}
+
+		from := StateOf(draft)
+		to, err := ApplyTransition(from, req.Action, req.Target)
+		if err != nil {
+			return err
+		}
+		draft.Status = to.Status
+		draft.WorkflowStage = to.Stage
+		if req.Apply != nil {
+			if err := req.Apply(ctx, draft); err != nil {
+				return err
+			}
+		}
+		if err := t.drafts.UpdateState(ctx, draft); err != nil {
+			return err
+		}
+
+		transition := &model.DraftTransition{
+			DraftID:     draft.ID,
+			Action:      req.Action,
+			FromStatus:  from.Status,
+			FromStage:   from.Stage,
+			ToStatus:    to.Status,
+			ToStage:     to.Stage,
+			ActorUserID: req.ActorID,
+			Reason:      req.Reason,
+		}
+		if err := t.transitions.Create(ctx, transition); err != nil {
+			return err
+		}
+		result, recorded = draft, transition
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if t.hook != nil {
+		if err := t.hook(ctx, result, recorded); err != nil {
+			logx.L().Warn("transition hook failed", "draft_id", result.ID, "action", recorded.Action, "error", err)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/xiaowumin-mark/AMLX/model"
+)
+
+var (
+	testStatuses = []model.DraftStatus{model.DraftPreReview, model.DraftInReview, model.DraftReviewDone}
+	testActions  = []model.TransitionAction{model.ActionAdvance, model.ActionSubmit, model.ActionApprove, model.ActionReject, model.ActionRollback}
+)
+
+// 阶段取值：nil 加上全部预审核阶段
+func testStages() []*model.WorkflowStage {
+	stages := []*model.WorkflowStage{nil}
+	for _, stage := range stageOrder {
+		stage := stage
+		stages = append(stages, &stage)
+	}
+	return stages
+}
+
+func stageName(stage *model.WorkflowStage) string {
+	if stage == nil {
+		return "-"
+	}
+	return string(*stage)
+}
+
+type transitionKey struct {
+	status model.DraftStatus
+	stage  string
+	action model.TransitionAction
+	target string
+}
+
+const (
+	tLR = string(model.StageLyricRequest)
+	tLC = string(model.StageLyricCompleted)
+	tRO = string(model.StageRough)
+	tFI = string(model.StageFine)
+	tCH = string(model.StageCheck)
+)
+
+// 全部允许的流转及目标状态，不在表中的组合都必须返回 ErrInvalidTransition
+var allowedTransitions = map[transitionKey]string{
+	{model.DraftPreReview, tLR, model.ActionAdvance, "-"}: "PRE_REVIEW/LYRIC_COMPLETED",
+	{model.DraftPreReview, tLR, model.ActionAdvance, tLC}: "PRE_REVIEW/LYRIC_COMPLETED",
+	{model.DraftPreReview, tLC, model.ActionAdvance, "-"}: "PRE_REVIEW/ROUGH",
+	{model.DraftPreReview, tLC, model.ActionAdvance, tRO}: "PRE_REVIEW/ROUGH",
+	{model.DraftPreReview, tRO, model.ActionAdvance, "-"}: "PRE_REVIEW/FINE",
+	{model.DraftPreReview, tRO, model.ActionAdvance, tFI}: "PRE_REVIEW/FINE",
+	{model.DraftPreReview, tFI, model.ActionAdvance, "-"}: "PRE_REVIEW/CHECK",
+	{model.DraftPreReview, tFI, model.ActionAdvance, tCH}: "PRE_REVIEW/CHECK",
+
+	{model.DraftPreReview, tCH, model.ActionSubmit, "-"}: "IN_REVIEW",
+
+	{model.DraftInReview, "-", model.ActionApprove, "-"}: "REVIEW_DONE",
+
+	{model.DraftInReview, "-", model.ActionReject, tLR}: "PRE_REVIEW/LYRIC_REQUEST",
+	{model.DraftInReview, "-", model.ActionReject, tLC}: "PRE_REVIEW/LYRIC_COMPLETED",
+	{model.DraftInReview, "-", model.ActionReject, tRO}: "PRE_REVIEW/ROUGH",
+	{model.DraftInReview, "-", model.ActionReject, tFI}: "PRE_REVIEW/FINE",
+	{model.DraftInReview, "-", model.ActionReject, tCH}: "PRE_REVIEW/CHECK",
+
+	{model.DraftPreReview, tLC, model.ActionRollback, tLR}: "PRE_REVIEW/LYRIC_REQUEST",
+	{model.DraftPreReview, tRO, model.ActionRollback, tLR}: "PRE_REVIEW/LYRIC_REQUEST",
+	{model.DraftPreReview, tRO, model.ActionRollback, tLC}: "PRE_REVIEW/LYRIC_COMPLETED",
+	{model.DraftPreReview, tFI, model.ActionRollback, tLR}: "PRE_REVIEW/LYRIC_REQUEST",
+	{model.DraftPreReview, tFI, model.ActionRollback, tLC}: "PRE_REVIEW/LYRIC_COMPLETED",
+	{model.DraftPreReview, tFI, model.ActionRollback, tRO}: "PRE_REVIEW/ROUGH",
+	{model.DraftPreReview, tCH, model.ActionRollback, tLR}: "PRE_REVIEW/LYRIC_REQUEST",
+	{model.DraftPreReview, tCH, model.ActionRollback, tLC}: "PRE_REVIEW/LYRIC_COMPLETED",
+	{model.DraftPreReview, tCH, model.ActionRollback, tRO}: "PRE_REVIEW/ROUGH",
+	{model.DraftPreReview, tCH, model.ActionRollback, tFI}: "PRE_REVIEW/FINE",
+}
+
+func TestApplyTransition(t *testing.T) {
+	matched := 0
+	for _, status := range testStatuses {
+		for _, stage := range testStages() {
+			for _, action := range testActions {
+				for _, target := range testStages() {
+					key := transitionKey{status, stageName(stage), action, stageName(target)}
+					name := fmt.Sprintf("%s/%s %s to %s", key.status, key.stage, key.action, key.target)
+					want, allowed := allowedTransitions[key]
+
+					to, err := ApplyTransition(DraftState{Status: status, Stage: stage}, action, target)
+					if !allowed {
+						if !errors.Is(err, ErrInvalidTransition) {
+							t.Errorf("%s: got %v, %v; want ErrInvalidTransition", name, to, err)
+						}
+						continue
+					}
+					matched++
+					if err != nil {
+						t.Errorf("%s: unexpected error %v", name, err)
+						continue
+					}
+					if to.String() != want {
+						t.Errorf("%s: got %s, want %s", name, to, want)
+					}
+					if err := validateState(to); err != nil {
+						t.Errorf("%s: target state %s is invalid", name, to)
+					}
+				}
+			}
+		}
+	}
+	if matched != len(allowedTransitions) {
+		t.Errorf("checked %d allowed transitions, table has %d", matched, len(allowedTransitions))
+	}
+}
+
+func TestApplyTransitionRejectsUnknownValues(t *testing.T) {
+	check := model.StageCheck
+	unknown := model.WorkflowStage("MASTERING")
+	tests := []struct {
+		name   string
+		from   DraftState
+		action model.TransitionAction
+		target *model.WorkflowStage
+	}{
+		{"unknown status", DraftState{Status: "ARCHIVED"}, model.ActionApprove, nil},
+		{"unknown source stage", DraftState{Status: model.DraftPreReview, Stage: &unknown}, model.ActionAdvance, nil},
+		{"unknown target stage", DraftState{Status: model.DraftInReview}, model.ActionReject, &unknown},
+		{"unknown action", DraftState{Status: model.DraftPreReview, Stage: &check}, "PUBLISH", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ApplyTransition(tt.from, tt.action, tt.target)
+			if !errors.Is(err, ErrInvalidTransition) {
+				t.Fatalf("got %v, want ErrInvalidTransition", err)
+			}
+			var transitionErr *TransitionError
+			if !errors.As(err, &transitionErr) || transitionErr.Action != tt.action {
+				t.Fatalf("got %v, want *TransitionError for %s", err, tt.action)
+			}
+		})
+	}
+}
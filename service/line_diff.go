@@ -0,0 +1,158 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xiaowumin-mark/AMLX/lyrics"
+	"github.com/xiaowumin-mark/AMLX/lyrics/ttml"
+)
+
+// 行级 diff 操作
+type DiffOp string
+
+const (
+	DiffEqual  DiffOp = "EQUAL"
+	DiffInsert DiffOp = "INSERT"
+	DiffDelete DiffOp = "DELETE"
+)
+
+// 超过该规模时不再求最长公共子序列，直接按整段替换输出
+const maxDiffCells = 4_000_000
+
+type DiffLine struct {
+	Op      DiffOp `json:"op"`
+	OldLine int    `json:"old_line,omitempty"` // 从 1 开始，INSERT 时为 0
+	NewLine int    `json:"new_line,omitempty"` // 从 1 开始，DELETE 时为 0
+	Text    string `json:"text"`
+}
+
+// 按解析后的歌词行计算 diff。TTML 序列化结果通常只有一行，逐文本行比较没有意义，
+// 因此每个歌词行渲染为一行可读文本；比较时额外带上逐字时间，只改时间轴的行也会被标出
+func DiffLyrics(from, to *lyrics.Document) []DiffLine {
+	a := diffEntries(from)
+	b := diffEntries(to)
+
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix].key == b[prefix].key {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix].key == b[len(b)-1-suffix].key {
+		suffix++
+	}
+
+	result := make([]DiffLine, 0, len(a)+len(b))
+	for i := 0; i < prefix; i++ {
+		result = append(result, DiffLine{Op: DiffEqual, OldLine: i + 1, NewLine: i + 1, Text: b[i].text})
+	}
+	result = append(result, diffMiddle(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix], prefix, prefix)...)
+	for i := 0; i < suffix; i++ {
+		oldIdx := len(a) - suffix + i
+		newIdx := len(b) - suffix + i
+		result = append(result, DiffLine{Op: DiffEqual, OldLine: oldIdx + 1, NewLine: newIdx + 1, Text: b[newIdx].text})
+	}
+	return result
+}
+
+// 参与 diff 的一行：key 用于比较，text 用于展示
+type diffEntry struct {
+	key  string
+	text string
+}
+
+func diffEntries(doc *lyrics.Document) []diffEntry {
+	if doc == nil {
+		return nil
+	}
+	entries := make([]diffEntry, len(doc.Lines))
+	for i := range doc.Lines {
+		line := &doc.Lines[i]
+		text := lineDiffText(line)
+		entries[i] = diffEntry{key: text + "\x00" + syllableTiming(line), text: text}
+	}
+	return entries
+}
+
+// 形如 "[00:01.000-00:04.500] v1: 歌词 (bg: 和声) {zh-CN: 翻译} <ja-Latn: romaji>"
+func lineDiffText(line *lyrics.Line) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "[%s-%s]", ttml.FormatTime(line.Begin), ttml.FormatTime(line.End))
+	if line.Agent != "" {
+		sb.WriteString(" " + line.Agent + ":")
+	}
+	sb.WriteString(" " + line.Text())
+	if line.Background != nil {
+		sb.WriteString(" (bg: " + line.Background.Text() + ")")
+	}
+	for _, translation := range line.Translations {
+		fmt.Fprintf(&sb, " {%s: %s}", translation.Lang, translation.Text)
+	}
+	for _, romanization := range line.Romanizations {
+		fmt.Fprintf(&sb, " <%s: %s>", romanization.Lang, romanization.Text)
+	}
+	return sb.String()
+}
+
+func syllableTiming(line *lyrics.Line) string {
+	var sb strings.Builder
+	for _, syllable := range line.Syllables {
+		fmt.Fprintf(&sb, "%d,%d;", syllable.Begin, syllable.End)
+	}
+	if line.Background != nil {
+		sb.WriteString("|" + syllableTiming(line.Background))
+	}
+	return sb.String()
+}
+
+// 对去掉公共前后缀的部分求 LCS
+func diffMiddle(a, b []diffEntry, oldOffset, newOffset int) []DiffLine {
+	var result []DiffLine
+	if len(a)*len(b) > maxDiffCells {
+		for i, line := range a {
+			result = append(result, DiffLine{Op: DiffDelete, OldLine: oldOffset + i + 1, Text: line.text})
+		}
+		for j, line := range b {
+			result = append(result, DiffLine{Op: DiffInsert, NewLine: newOffset + j + 1, Text: line.text})
+		}
+		return result
+	}
+
+	// lcs[i][j] 为 a[i:] 与 b[j:] 的最长公共子序列长度
+	width := len(b) + 1
+	lcs := make([]int32, (len(a)+1)*width)
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i].key == b[j].key {
+				lcs[i*width+j] = lcs[(i+1)*width+j+1] + 1
+			} else if lcs[(i+1)*width+j] >= lcs[i*width+j+1] {
+				lcs[i*width+j] = lcs[(i+1)*width+j]
+			} else {
+				lcs[i*width+j] = lcs[i*width+j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i].key == b[j].key:
+			result = append(result, DiffLine{Op: DiffEqual, OldLine: oldOffset + i + 1, NewLine: newOffset + j + 1, Text: a[i].text})
+			i++
+			j++
+		case lcs[(i+1)*width+j] >= lcs[i*width+j+1]:
+			result = append(result, DiffLine{Op: DiffDelete, OldLine: oldOffset + i + 1, Text: a[i].text})
+			i++
+		default:
+			result = append(result, DiffLine{Op: DiffInsert, NewLine: newOffset + j + 1, Text: b[j].text})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		result = append(result, DiffLine{Op: DiffDelete, OldLine: oldOffset + i + 1, Text: a[i].text})
+	}
+	for ; j < len(b); j++ {
+		result = append(result, DiffLine{Op: DiffInsert, NewLine: newOffset + j + 1, Text: b[j].text})
+	}
+	return result
+}
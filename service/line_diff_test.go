@@ -0,0 +1,119 @@
+package service
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/xiaowumin-mark/AMLX/lyrics"
+	"github.com/xiaowumin-mark/AMLX/lyrics/ttml"
+)
+
+func diffTestLine(begin int64, words ...string) lyrics.Line {
+	line := lyrics.Line{Agent: "v1", Begin: begin}
+	at := begin
+	for i, word := range words {
+		syllable := lyrics.Syllable{Text: word, Begin: at, End: at + 500}
+		if i < len(words)-1 {
+			syllable.Trailing = " "
+		}
+		line.Syllables = append(line.Syllables, syllable)
+		at += 500
+	}
+	line.End = at
+	return line
+}
+
+// 两个版本都按 ttml.Marshal 存储，再按 DecodeLyrics 读回后比较
+func diffTestVersions(t *testing.T, from, to *lyrics.Document) []DiffLine {
+	t.Helper()
+	decode := func(doc *lyrics.Document) *lyrics.Document {
+		content := ttml.MarshalString(doc)
+		if strings.Contains(strings.TrimSpace(content), "\n") {
+			t.Fatalf("canonical TTML is expected to be a single line")
+		}
+		parsed, err := DecodeLyrics(FormatTTML, content)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsed
+	}
+	return DiffLyrics(decode(from), decode(to))
+}
+
+func diffOps(lines []DiffLine) []DiffOp {
+	ops := make([]DiffOp, len(lines))
+	for i, line := range lines {
+		ops[i] = line.Op
+	}
+	return ops
+}
+
+func TestDiffLyrics(t *testing.T) {
+	base := func() *lyrics.Document {
+		return &lyrics.Document{
+			Timing: lyrics.TimingWord,
+			Lines: []lyrics.Line{
+				diffTestLine(0, "hello", "world"),
+				diffTestLine(5000, "second", "line"),
+				diffTestLine(10000, "third", "line"),
+			},
+		}
+	}
+
+	t.Run("one line changed", func(t *testing.T) {
+		to := base()
+		to.Lines[1] = diffTestLine(5000, "changed", "line")
+		got := diffTestVersions(t, base(), to)
+		want := []DiffLine{
+			{Op: DiffEqual, OldLine: 1, NewLine: 1, Text: "[00:00.000-00:01.000] v1: hello world"},
+			{Op: DiffDelete, OldLine: 2, Text: "[00:05.000-00:06.000] v1: second line"},
+			{Op: DiffInsert, NewLine: 2, Text: "[00:05.000-00:06.000] v1: changed line"},
+			{Op: DiffEqual, OldLine: 3, NewLine: 3, Text: "[00:10.000-00:11.000] v1: third line"},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("diff = %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("line inserted", func(t *testing.T) {
+		to := base()
+		to.Lines = append(to.Lines[:1], append([]lyrics.Line{diffTestLine(3000, "new")}, to.Lines[1:]...)...)
+		got := diffTestVersions(t, base(), to)
+		if want := []DiffOp{DiffEqual, DiffInsert, DiffEqual, DiffEqual}; !reflect.DeepEqual(diffOps(got), want) {
+			t.Fatalf("ops = %v, want %v", diffOps(got), want)
+		}
+		if got[1].NewLine != 2 || got[3].OldLine != 3 || got[3].NewLine != 4 {
+			t.Fatalf("line numbers = %+v", got)
+		}
+	})
+
+	t.Run("syllable timing only", func(t *testing.T) {
+		to := base()
+		to.Lines[0].Syllables[0].End = 400
+		to.Lines[0].Syllables[1].Begin = 400
+		got := diffTestVersions(t, base(), to)
+		if want := []DiffOp{DiffDelete, DiffInsert, DiffEqual, DiffEqual}; !reflect.DeepEqual(diffOps(got), want) {
+			t.Fatalf("ops = %v, want %v", diffOps(got), want)
+		}
+	})
+
+	t.Run("translation and background", func(t *testing.T) {
+		to := base()
+		background := diffTestLine(10200, "ooh")
+		to.Lines[2].Background = &background
+		to.Lines[2].Translations = []lyrics.Annotation{{Lang: "zh-CN", Text: "第三行"}}
+		got := diffTestVersions(t, base(), to)
+		last := got[len(got)-1]
+		if last.Op != DiffInsert || last.Text != "[00:10.000-00:11.000] v1: third line (bg: ooh) {zh-CN: 第三行}" {
+			t.Fatalf("last = %+v", last)
+		}
+	})
+
+	t.Run("identical", func(t *testing.T) {
+		got := diffTestVersions(t, base(), base())
+		if want := []DiffOp{DiffEqual, DiffEqual, DiffEqual}; !reflect.DeepEqual(diffOps(got), want) {
+			t.Fatalf("ops = %v, want %v", diffOps(got), want)
+		}
+	})
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/xiaowumin-mark/AMLX/cdn"
+	"github.com/xiaowumin-mark/AMLX/lyrics"
+	"github.com/xiaowumin-mark/AMLX/lyrics/pack"
+	"github.com/xiaowumin-mark/AMLX/lyrics/ttml"
+	"github.com/xiaowumin-mark/AMLX/store"
+	"gorm.io/gorm"
+)
+
+var ErrLyricsNotFound = errors.New("lyrics not found")
+
+// 公开查询额外支持的格式，其余格式与导出相同
+const (
+	FormatPack = "pack" // Binary Pack
+	FormatJSON = "json" // 歌词 AST
+)
+
+// 一首歌最新发布的歌词
+type PublishedLyrics struct {
+	SongID      uint
+	DraftID     uint
+	VersionID   uint
+	VersionHash string // 规范化 TTML 的 SHA-256，与发布清单一致，不随格式变化
+	Format      string
+	ContentType string // 以下由 Render 填充
+	Content     []byte
+
+	doc       *lyrics.Document
+	canonical []byte
+}
+
+// 查询未找到歌词时的回调
+type LookupMissHook func(ctx context.Context, platform, externalID, format string)
+
+type LookupService interface {
+	Lyrics(ctx context.Context, platform, externalID, format string) (*PublishedLyrics, error) // 按平台 ID 查找最新发布的歌词，不生成内容
+	Render(lyrics *PublishedLyrics) error                                                      // 按查找时的格式生成内容
+}
+
+type lookupService struct {
+	songs    SongService
+	drafts   store.DraftStore
+	versions store.VersionStore
+	onMiss   LookupMissHook
+}
+
+func NewLookupService(songs SongService, drafts store.DraftStore, versions store.VersionStore, onMiss LookupMissHook) LookupService {
+	return &lookupService{songs: songs, drafts: drafts, versions: versions, onMiss: onMiss}
+}
+
+func (s *lookupService) Lyrics(ctx context.Context, platform, externalID, format string) (*PublishedLyrics, error) {
+	result, err := s.lyrics(ctx, platform, externalID, format)
+	if (errors.Is(err, ErrSongNotFound) || errors.Is(err, ErrLyricsNotFound)) && s.onMiss != nil {
+		s.onMiss(ctx, platform, externalID, format)
+	}
+	return result, err
+}
+
+func (s *lookupService) lyrics(ctx context.Context, platform, externalID, format string) (*PublishedLyrics, error) {
+	format = strings.ToLower(strings.TrimSpace(format))
+	switch format {
+	case FormatTTML, FormatLRC, FormatEnhancedLRC, FormatPack, FormatJSON:
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+	song, err := s.songs.Lookup(ctx, platform, externalID)
+	if err != nil {
+		return nil, err
+	}
+	draft, err := s.drafts.LatestPublishedBySong(ctx, song.Song.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrLyricsNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	doc, err := snapshotDocument(ctx, s.versions, draft)
+	if err != nil {
+		return nil, err
+	}
+
+	canonical := ttml.Marshal(doc)
+	return &PublishedLyrics{
+		SongID:      song.Song.ID,
+		DraftID:     draft.ID,
+		VersionID:   *draft.ReviewSnapshotID,
+		VersionHash: cdn.Hash(canonical),
+		Format:      format,
+		doc:         doc,
+		canonical:   canonical,
+	}, nil
+}
+
+func (s *lookupService) Render(result *PublishedLyrics) error {
+	switch result.Format {
+	case FormatTTML:
+		result.ContentType, result.Content = "application/ttml+xml; charset=utf-8", result.canonical
+	case FormatPack:
+		result.ContentType, result.Content = "application/vnd.amlx.pack", pack.Encode(result.doc)
+	case FormatJSON:
+		data, err := json.Marshal(result.doc)
+		if err != nil {
+			return err
+		}
+		result.ContentType, result.Content = "application/json; charset=utf-8", data
+	default:
+		exported, err := EncodeLyrics(result.Format, result.doc)
+		if err != nil {
+			return err
+		}
+		result.ContentType, result.Content = exported.ContentType, exported.Content
+	}
+	return nil
+}
@@ -0,0 +1,332 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/xiaowumin-mark/AMLX/model"
+	"github.com/xiaowumin-mark/AMLX/store"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrLyricRequestNotFound   = errors.New("lyric request not found")
+	ErrLyricRequestDuplicate  = errors.New("lyric request already exists")
+	ErrLyricRequestNotOpen    = errors.New("lyric request is not open")
+	ErrLyricRequestNotClaimed = errors.New("lyric request is not claimed")
+	ErrLyricRequestForbidden  = errors.New("lyric request access denied")
+)
+
+// 每个请求最多附带的平台链接数
+const maxLyricRequestLinks = 10
+
+// DuplicateLyricRequestError 已有同一首歌的未关闭请求，附带该请求
+type DuplicateLyricRequestError struct {
+	Existing *model.LyricRequest
+}
+
+func (e *DuplicateLyricRequestError) Error() string {
+	return ErrLyricRequestDuplicate.Error()
+}
+
+func (e *DuplicateLyricRequestError) Unwrap() error {
+	return ErrLyricRequestDuplicate
+}
+
+type CreateLyricRequestRequest struct {
+	Title   string
+	Artists []string
+	Album   string
+	Links   []string
+}
+
+type ListLyricRequestsRequest struct {
+	Status   string
+	Sort     string // votes / new
+	Page     int
+	PageSize int
+}
+
+type LyricRequestService interface {
+	Create(ctx context.Context, userID uint, req CreateLyricRequestRequest) (*model.LyricRequest, error) // 发布请求，同一首歌已有未关闭请求时返回 DuplicateLyricRequestError
+	Get(ctx context.Context, id uint) (*model.LyricRequest, error)                                       // 获取请求
+	List(ctx context.Context, req ListLyricRequestsRequest) ([]model.LyricRequest, int64, error)         // 列出请求
+	Voted(ctx context.Context, userID uint, requests []model.LyricRequest) (map[uint]bool, error)        // 用户投过票的请求
+	Vote(ctx context.Context, userID, id uint) (*model.LyricRequest, error)                              // 投票
+	Unvote(ctx context.Context, userID, id uint) (*model.LyricRequest, error)                            // 取消投票
+	Claim(ctx context.Context, userID, id uint) (*model.LyricRequest, *model.LyricsDraft, error)         // 认领，创建 LYRIC_REQUEST 阶段的稿件
+	Release(ctx context.Context, userID, id uint) (*model.LyricRequest, error)                           // 放弃认领，稿件保留
+	Close(ctx context.Context, draft *model.LyricsDraft) error                                           // 发布钩子：关闭稿件认领的请求及同名请求
+}
+
+type lyricRequestService struct {
+	tx       store.Transactor
+	requests store.LyricRequestStore
+	drafts   DraftService
+}
+
+func NewLyricRequestService(tx store.Transactor, requests store.LyricRequestStore, drafts DraftService) LyricRequestService {
+	return &lyricRequestService{tx: tx, requests: requests, drafts: drafts}
+}
+
+func (s *lyricRequestService) Create(ctx context.Context, userID uint, req CreateLyricRequestRequest) (*model.LyricRequest, error) {
+	title := strings.TrimSpace(req.Title)
+	if userID == 0 || title == "" {
+		return nil, ErrInvalidInput
+	}
+	artists, err := EncodeArtists(req.Artists)
+	if err != nil {
+		return nil, err
+	}
+	links, err := encodeLinks(req.Links)
+	if err != nil {
+		return nil, err
+	}
+	key := songKey(title, req.Artists)
+	request := &model.LyricRequest{
+		Title:           title,
+		Artists:         artists,
+		Album:           strings.TrimSpace(req.Album),
+		Links:           links,
+		NormalizedKey:   key,
+		ActiveKey:       &key,
+		RequesterUserID: userID,
+		Status:          model.LyricRequestOpen,
+	}
+	// 由 ActiveKey 的唯一索引查重，并发发布同一首歌时只有一个能写入
+	created, err := s.requests.Create(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		existing, err := s.requests.GetActiveByKey(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		return nil, &DuplicateLyricRequestError{Existing: existing}
+	}
+	return request, nil
+}
+
+func (s *lyricRequestService) Get(ctx context.Context, id uint) (*model.LyricRequest, error) {
+	request, err := s.requests.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrLyricRequestNotFound
+	}
+	return request, err
+}
+
+func (s *lyricRequestService) List(ctx context.Context, req ListLyricRequestsRequest) ([]model.LyricRequest, int64, error) {
+	status := model.LyricRequestStatus(strings.ToUpper(strings.TrimSpace(req.Status)))
+	switch status {
+	case "", model.LyricRequestOpen, model.LyricRequestClaimed, model.LyricRequestClosed:
+	default:
+		return nil, 0, ErrInvalidInput
+	}
+	var byVote bool
+	switch strings.ToLower(strings.TrimSpace(req.Sort)) {
+	case "", "votes":
+		byVote = true
+	case "new":
+	default:
+		return nil, 0, ErrInvalidInput
+	}
+	offset, limit := pagination(req.Page, req.PageSize)
+	return s.requests.List(ctx, store.LyricRequestFilter{
+		Status: status,
+		ByVote: byVote,
+		Offset: offset,
+		Limit:  limit,
+	})
+}
+
+func (s *lyricRequestService) Voted(ctx context.Context, userID uint, requests []model.LyricRequest) (map[uint]bool, error) {
+	ids := make([]uint, 0, len(requests))
+	for i := range requests {
+		ids = append(ids, requests[i].ID)
+	}
+	voted, err := s.requests.VotedIDs(ctx, userID, ids)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[uint]bool, len(voted))
+	for _, id := range voted {
+		result[id] = true
+	}
+	return result, nil
+}
+
+// 只能给未关闭的请求投票，重复投票不报错
+func (s *lyricRequestService) Vote(ctx context.Context, userID, id uint) (*model.LyricRequest, error) {
+	return s.vote(ctx, userID, id, func(ctx context.Context) error {
+		_, err := s.requests.AddVote(ctx, id, userID)
+		return err
+	})
+}
+
+func (s *lyricRequestService) Unvote(ctx context.Context, userID, id uint) (*model.LyricRequest, error) {
+	return s.vote(ctx, userID, id, func(ctx context.Context) error {
+		_, err := s.requests.RemoveVote(ctx, id, userID)
+		return err
+	})
+}
+
+func (s *lyricRequestService) vote(ctx context.Context, userID, id uint, apply func(ctx context.Context) error) (*model.LyricRequest, error) {
+	if userID == 0 {
+		return nil, ErrInvalidInput
+	}
+	var request *model.LyricRequest
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		current, err := s.lockRequest(ctx, id)
+		if err != nil {
+			return err
+		}
+		if current.Status == model.LyricRequestClosed {
+			return ErrLyricRequestNotOpen
+		}
+		if err := apply(ctx); err != nil {
+			return err
+		}
+		request, err = s.requests.GetByID(ctx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+func (s *lyricRequestService) Claim(ctx context.Context, userID, id uint) (*model.LyricRequest, *model.LyricsDraft, error) {
+	if userID == 0 {
+		return nil, nil, ErrInvalidInput
+	}
+	var request *model.LyricRequest
+	var draft *model.LyricsDraft
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		request, err = s.lockRequest(ctx, id)
+		if err != nil {
+			return err
+		}
+		if request.Status != model.LyricRequestOpen {
+			return ErrLyricRequestNotOpen
+		}
+		// 稿件创建时处于 LYRIC_REQUEST 阶段，复用同一事务
+		draft, err = s.drafts.Create(ctx, userID, CreateDraftRequest{
+			Title:   request.Title,
+			Artists: DecodeArtists(request.Artists),
+			Album:   request.Album,
+		})
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		request.Status = model.LyricRequestClaimed
+		request.ClaimedBy = userID
+		request.ClaimedAt = &now
+		request.DraftID = &draft.ID
+		return s.requests.Update(ctx, request)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return request, draft, nil
+}
+
+func (s *lyricRequestService) Release(ctx context.Context, userID, id uint) (*model.LyricRequest, error) {
+	var request *model.LyricRequest
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		request, err = s.lockRequest(ctx, id)
+		if err != nil {
+			return err
+		}
+		if request.Status != model.LyricRequestClaimed {
+			return ErrLyricRequestNotClaimed
+		}
+		if request.ClaimedBy != userID {
+			return ErrLyricRequestForbidden
+		}
+		request.Status = model.LyricRequestOpen
+		request.ClaimedBy = 0
+		request.ClaimedAt = nil
+		request.DraftID = nil
+		return s.requests.Update(ctx, request)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+// 稿件发布后关闭它认领的请求，以及同一首歌仍在等待认领的请求
+func (s *lyricRequestService) Close(ctx context.Context, draft *model.LyricsDraft) error {
+	if !isPublished(draft) {
+		return nil
+	}
+	key := songKey(draft.Title, DecodeArtists(draft.Artists))
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		requests, err := s.requests.ListActiveForDraft(ctx, draft.ID, key)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		for i := range requests {
+			request := &requests[i]
+			request.Status = model.LyricRequestClosed
+			request.ActiveKey = nil
+			request.ClosedAt = &now
+			request.DraftID = &draft.ID
+			if err := s.requests.Update(ctx, request); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *lyricRequestService) lockRequest(ctx context.Context, id uint) (*model.LyricRequest, error) {
+	request, err := s.requests.GetByIDForUpdate(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrLyricRequestNotFound
+	}
+	return request, err
+}
+
+// DecodeLinks 解析请求的平台链接
+func DecodeLinks(value string) []string {
+	var links []string
+	if err := json.Unmarshal([]byte(value), &links); err != nil || links == nil {
+		return []string{}
+	}
+	return links
+}
+
+// 平台链接必须是 http(s) 绝对地址，重复的只保留一个
+func encodeLinks(links []string) (string, error) {
+	cleaned := make([]string, 0, len(links))
+	for _, link := range links {
+		link = strings.TrimSpace(link)
+		if link == "" || slices.Contains(cleaned, link) {
+			continue
+		}
+		u, err := url.Parse(link)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return "", ErrInvalidInput
+		}
+		cleaned = append(cleaned, link)
+	}
+	if len(cleaned) > maxLyricRequestLinks {
+		return "", ErrInvalidInput
+	}
+	data, err := json.Marshal(cleaned)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/xiaowumin-mark/AMLX/lyrics"
+	"github.com/xiaowumin-mark/AMLX/lyrics/krc"
+	"github.com/xiaowumin-mark/AMLX/lyrics/lrc"
+	"github.com/xiaowumin-mark/AMLX/lyrics/qrc"
+	"github.com/xiaowumin-mark/AMLX/lyrics/ttml"
+	"github.com/xiaowumin-mark/AMLX/lyrics/yrc"
+	"github.com/xiaowumin-mark/AMLX/model"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported lyrics format")
+	ErrInvalidLyrics     = errors.New("invalid lyrics content")
+)
+
+// 歌词格式
+const (
+	FormatTTML        = "ttml"
+	FormatLRC         = "lrc"
+	FormatEnhancedLRC = "elrc" // 带 <mm:ss.xx> 逐字时间的 LRC
+	FormatQRC         = "qrc"  // QQ 音乐
+	FormatYRC         = "yrc"  // 网易云音乐
+	FormatKRC         = "krc"  // 酷狗音乐
+)
+
+// 导入的歌词内容
+type ImportLyrics struct {
+	Format  string
+	Content string
+}
+
+// 导出结果
+type ExportedLyrics struct {
+	Format      string
+	ContentType string
+	Extension   string
+	Content     []byte
+}
+
+// 按格式解析歌词
+func DecodeLyrics(format, content string) (*lyrics.Document, error) {
+	var (
+		doc *lyrics.Document
+		err error
+	)
+	switch normalizeFormat(format) {
+	case FormatTTML:
+		doc, err = ttml.ParseString(content)
+	case FormatLRC, FormatEnhancedLRC:
+		doc, err = lrc.Parse(content)
+	case FormatQRC:
+		var text string
+		if text, err = decryptQRC(content); err == nil {
+			doc, err = qrc.Parse(text)
+		}
+	case FormatYRC:
+		doc, err = yrc.Parse(content)
+	case FormatKRC:
+		var text string
+		if text, err = decryptKRC(content); err == nil {
+			doc, err = krc.Parse(text)
+		}
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidLyrics, err)
+	}
+	return doc, nil
+}
+
+// 按格式生成歌词
+func EncodeLyrics(format string, doc *lyrics.Document) (*ExportedLyrics, error) {
+	switch format = normalizeFormat(format); format {
+	case FormatTTML:
+		return &ExportedLyrics{Format: format, ContentType: "application/ttml+xml; charset=utf-8", Extension: "ttml", Content: ttml.Marshal(doc)}, nil
+	case FormatLRC:
+		return &ExportedLyrics{Format: format, ContentType: "text/plain; charset=utf-8", Extension: "lrc", Content: []byte(lrc.Marshal(doc, false))}, nil
+	case FormatEnhancedLRC:
+		return &ExportedLyrics{Format: format, ContentType: "text/plain; charset=utf-8", Extension: "lrc", Content: []byte(lrc.Marshal(doc, true))}, nil
+	case FormatQRC:
+		return &ExportedLyrics{Format: format, ContentType: "application/xml; charset=utf-8", Extension: "qrc", Content: qrc.Marshal(doc)}, nil
+	case FormatYRC:
+		return &ExportedLyrics{Format: format, ContentType: "text/plain; charset=utf-8", Extension: "yrc", Content: []byte(yrc.Marshal(doc))}, nil
+	case FormatKRC:
+		return &ExportedLyrics{Format: format, ContentType: "text/plain; charset=utf-8", Extension: "krc", Content: []byte(krc.Marshal(doc))}, nil
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+// QRC 可以是解密后的文本，也可以是接口返回的 hex 或 base64 密文
+func decryptQRC(content string) (string, error) {
+	trimmed := strings.TrimSpace(content)
+	if isPlainLyrics(trimmed) {
+		return content, nil
+	}
+	data, err := hex.DecodeString(trimmed)
+	if err != nil {
+		if data, err = base64.StdEncoding.DecodeString(trimmed); err != nil {
+			return "", errors.New("qrc content is neither plain text, hex nor base64")
+		}
+	}
+	return qrc.Decrypt(data)
+}
+
+// KRC 可以是解密后的文本，也可以是 base64 编码的 .krc 文件
+func decryptKRC(content string) (string, error) {
+	trimmed := strings.TrimSpace(content)
+	if isPlainLyrics(trimmed) {
+		return content, nil
+	}
+	data, err := base64.StdEncoding.DecodeString(trimmed)
+	if err != nil {
+		return "", errors.New("krc content is neither plain text nor base64")
+	}
+	return krc.Decrypt(data)
+}
+
+func isPlainLyrics(content string) bool {
+	content = strings.TrimPrefix(content, "\ufeff")
+	return strings.HasPrefix(content, "[") || strings.HasPrefix(content, "<")
+}
+
+func normalizeFormat(format string) string {
+	return strings.ToLower(strings.TrimSpace(format))
+}
+
+// 用稿件信息补齐文档元数据，已有的值保持不变
+func fillDocumentMeta(doc *lyrics.Document, draft *model.LyricsDraft) {
+	if doc.MetaValue(lyrics.MetaMusicName) == "" {
+		doc.SetMeta(lyrics.MetaMusicName, draft.Title)
+	}
+	if doc.MetaValue(lyrics.MetaArtists) == "" {
+		doc.SetMeta(lyrics.MetaArtists, DecodeArtists(draft.Artists)...)
+	}
+	if doc.MetaValue(lyrics.MetaAlbum) == "" {
+		doc.SetMeta(lyrics.MetaAlbum, draft.Album)
+	}
+	if doc.Language == "" {
+		doc.Language = draft.Language
+	}
+}
@@ -3,8 +3,10 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"strings"
 
+	"github.com/xiaowumin-mark/AMLX/config"
 	"github.com/xiaowumin-mark/AMLX/model"
 	"github.com/xiaowumin-mark/AMLX/store"
 	"gorm.io/gorm"
@@ -15,26 +17,58 @@ var (
 	ErrRoleExists         = errors.New("role already exists")
 	ErrPermissionNotFound = errors.New("permission not found")
 	ErrPermissionExists   = errors.New("permission already exists")
+	ErrRoleInUse          = errors.New("role is still assigned to users")
+	ErrBuiltinRole        = errors.New("built-in role cannot be renamed or deleted")
+	ErrBuiltinPermission  = errors.New("built-in permission cannot be renamed or deleted")
+	ErrDefaultRole        = errors.New("default role for registration cannot be deleted")
 )
 
+// 启动时自动创建并由路由按名称检查，不允许改名或删除
+const adminRoleName = "admin"
+
+var builtinPermissions = map[string]bool{"admin": true, "review": true}
+
+type UpdateRoleRequest struct {
+	Name        *string
+	Description *string
+}
+
+type UpdatePermissionRequest struct {
+	Name        *string
+	Description *string
+}
+
 type PermissionService interface {
-	CreateRole(ctx context.Context, name, description string) (*model.Roles, error)             // 创建规则
-	CreatePermission(ctx context.Context, name, description string) (*model.Permissions, error) // 创建权限
-	AddPermissionToRole(ctx context.Context, roleID, permID uint) error                         // 添加权限
-	RemovePermissionFromRole(ctx context.Context, roleID, permID uint) error                    // 移除权限
-	ListPermissionsByRole(ctx context.Context, roleID uint) ([]model.Permissions, error)        // 列出权限
-	HasPermission(ctx context.Context, roleID uint, permName string) (bool, error)              // 检查权限
-	EnsureAdminRole(ctx context.Context) (uint, error)                                          // 确保管理员角色
+	CreateRole(ctx context.Context, name, description string) (*model.Roles, error)                         // 创建规则
+	CreatePermission(ctx context.Context, name, description string) (*model.Permissions, error)             // 创建权限
+	AddPermissionToRole(ctx context.Context, roleID, permID uint) error                                     // 添加权限
+	RemovePermissionFromRole(ctx context.Context, roleID, permID uint) error                                // 移除权限
+	ListPermissionsByRole(ctx context.Context, roleID uint) ([]model.Permissions, error)                    // 列出权限
+	ListRoles(ctx context.Context) ([]model.Roles, error)                                                   // 列出角色
+	ListPermissions(ctx context.Context) ([]model.Permissions, error)                                       // 列出全部权限
+	UpdateRole(ctx context.Context, id uint, req UpdateRoleRequest) (*model.Roles, error)                   // 修改角色名称或描述
+	UpdatePermission(ctx context.Context, id uint, req UpdatePermissionRequest) (*model.Permissions, error) // 修改权限名称或描述
+	DeleteRole(ctx context.Context, id, reassignTo uint) (int64, error)                                     // 删除角色，仍有用户时需指定转移到的角色，返回转移的用户数
+	DeletePermission(ctx context.Context, id uint) error                                                    // 删除权限及其全部授权
+	HasPermission(ctx context.Context, roleID uint, permName string) (bool, error)                          // 检查权限
+	EnsureAdminRole(ctx context.Context) (uint, error)                                                      // 确保管理员角色
+	EnsureRolePermission(ctx context.Context, roleID uint, name, description string) error                  // 确保角色拥有权限
 }
 
 type permissionService struct {
+	cfg             config.AuthConfig
+	tx              store.Transactor
+	users           store.UserStore
 	roles           store.RoleStore
 	permissions     store.PermissionStore
 	rolePermissions store.RolePermissionStore
 }
 
-func NewPermissionService(roles store.RoleStore, permissions store.PermissionStore, rolePermissions store.RolePermissionStore) PermissionService {
+func NewPermissionService(cfg config.AuthConfig, tx store.Transactor, users store.UserStore, roles store.RoleStore, permissions store.PermissionStore, rolePermissions store.RolePermissionStore) PermissionService {
 	return &permissionService{
+		cfg:             cfg,
+		tx:              tx,
+		users:           users,
 		roles:           roles,
 		permissions:     permissions,
 		rolePermissions: rolePermissions,
@@ -126,6 +160,159 @@ func (s *permissionService) ListPermissionsByRole(ctx context.Context, roleID ui
 	return s.permissions.ListByIDs(ctx, ids)
 }
 
+// 列出角色
+func (s *permissionService) ListRoles(ctx context.Context) ([]model.Roles, error) {
+	return s.roles.List(ctx)
+}
+
+// 列出全部权限
+func (s *permissionService) ListPermissions(ctx context.Context) ([]model.Permissions, error) {
+	return s.permissions.List(ctx)
+}
+
+// 修改角色名称或描述
+func (s *permissionService) UpdateRole(ctx context.Context, id uint, req UpdateRoleRequest) (*model.Roles, error) {
+	if id == 0 {
+		return nil, ErrInvalidInput
+	}
+	role, err := s.roles.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrRoleNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	if req.Name != nil {
+		value := strings.TrimSpace(*req.Name)
+		if value == "" {
+			return nil, ErrInvalidInput
+		}
+		if value != role.Name {
+			if role.Name == adminRoleName {
+				return nil, ErrBuiltinRole
+			}
+			if _, err := s.roles.GetByName(ctx, value); err == nil {
+				return nil, ErrRoleExists
+			} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, err
+			}
+		}
+		role.Name = value
+	}
+	if req.Description != nil {
+		role.Description = strings.TrimSpace(*req.Description)
+	}
+
+	if err := s.roles.Update(ctx, role); err != nil {
+		return nil, err
+	}
+	return role, nil
+}
+
+// 修改权限名称或描述
+func (s *permissionService) UpdatePermission(ctx context.Context, id uint, req UpdatePermissionRequest) (*model.Permissions, error) {
+	if id == 0 {
+		return nil, ErrInvalidInput
+	}
+	permission, err := s.permissions.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrPermissionNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	if req.Name != nil {
+		value := strings.TrimSpace(*req.Name)
+		if value == "" {
+			return nil, ErrInvalidInput
+		}
+		if value != permission.Name {
+			if builtinPermissions[permission.Name] {
+				return nil, ErrBuiltinPermission
+			}
+			if _, err := s.permissions.GetByName(ctx, value); err == nil {
+				return nil, ErrPermissionExists
+			} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, err
+			}
+		}
+		permission.Name = value
+	}
+	if req.Description != nil {
+		permission.Description = strings.TrimSpace(*req.Description)
+	}
+
+	if err := s.permissions.Update(ctx, permission); err != nil {
+		return nil, err
+	}
+	return permission, nil
+}
+
+// 删除角色。仍有用户持有时拒绝，除非指定 reassignTo，此时在同一事务中把这些用户转移到该角色
+func (s *permissionService) DeleteRole(ctx context.Context, id, reassignTo uint) (int64, error) {
+	if id == 0 || reassignTo == id {
+		return 0, ErrInvalidInput
+	}
+	// 注册时使用的角色
+	if id == s.cfg.DefaultRoleID {
+		return 0, ErrDefaultRole
+	}
+	var moved int64
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		// 先锁定角色，避免并发删除或转移时用户被留在已删除的角色上
+		role, err := s.roles.GetByIDForUpdate(ctx, id)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrRoleNotFound
+		} else if err != nil {
+			return err
+		}
+		if role.Name == adminRoleName {
+			return ErrBuiltinRole
+		}
+
+		if reassignTo != 0 {
+			if _, err := s.roles.GetByIDForUpdate(ctx, reassignTo); errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("reassign target: %w", ErrRoleNotFound)
+			} else if err != nil {
+				return err
+			}
+			if moved, err = s.users.ReassignRole(ctx, id, reassignTo); err != nil {
+				return err
+			}
+		} else if count, err := s.users.CountByRole(ctx, id); err != nil {
+			return err
+		} else if count > 0 {
+			return ErrRoleInUse
+		}
+
+		if err := s.rolePermissions.DeleteByRole(ctx, id); err != nil {
+			return err
+		}
+		return s.roles.Delete(ctx, id)
+	})
+	return moved, err
+}
+
+// 删除权限，授权记录在同一事务中一并删除
+func (s *permissionService) DeletePermission(ctx context.Context, id uint) error {
+	if id == 0 {
+		return ErrInvalidInput
+	}
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		permission, err := s.permissions.GetByID(ctx, id)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrPermissionNotFound
+		} else if err != nil {
+			return err
+		}
+		if builtinPermissions[permission.Name] {
+			return ErrBuiltinPermission
+		}
+		if err := s.rolePermissions.DeleteByPermission(ctx, id); err != nil {
+			return err
+		}
+		return s.permissions.Delete(ctx, id)
+	})
+}
+
 // 检查权限
 func (s *permissionService) HasPermission(ctx context.Context, roleID uint, permName string) (bool, error) {
 	permName = strings.TrimSpace(permName)
@@ -137,9 +324,9 @@ func (s *permissionService) HasPermission(ctx context.Context, roleID uint, perm
 
 // 确保管理员角色
 func (s *permissionService) EnsureAdminRole(ctx context.Context) (uint, error) {
-	role, err := s.roles.GetByName(ctx, "admin")
+	role, err := s.roles.GetByName(ctx, adminRoleName)
 	if errors.Is(err, gorm.ErrRecordNotFound) {
-		role, err = s.CreateRole(ctx, "admin", "System administrator")
+		role, err = s.CreateRole(ctx, adminRoleName, "System administrator")
 		if err != nil {
 			return 0, err
 		}
@@ -160,3 +347,25 @@ func (s *permissionService) EnsureAdminRole(ctx context.Context) (uint, error) {
 	_ = s.rolePermissions.AddPermission(ctx, role.ID, perm.ID)
 	return role.ID, nil
 }
+
+// 确保角色拥有权限，权限不存在时自动创建
+func (s *permissionService) EnsureRolePermission(ctx context.Context, roleID uint, name, description string) error {
+	if roleID == 0 {
+		return ErrInvalidInput
+	}
+	perm, err := s.permissions.GetByName(ctx, name)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		perm, err = s.CreatePermission(ctx, name, description)
+		if err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+
+	has, err := s.rolePermissions.HasPermission(ctx, roleID, perm.Name)
+	if err != nil || has {
+		return err
+	}
+	return s.rolePermissions.AddPermission(ctx, roleID, perm.ID)
+}
@@ -0,0 +1,354 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/xiaowumin-mark/AMLX/cdn"
+	"github.com/xiaowumin-mark/AMLX/config"
+	"github.com/xiaowumin-mark/AMLX/logx"
+	"github.com/xiaowumin-mark/AMLX/lyrics"
+	"github.com/xiaowumin-mark/AMLX/lyrics/pack"
+	"github.com/xiaowumin-mark/AMLX/lyrics/patch"
+	"github.com/xiaowumin-mark/AMLX/lyrics/ttml"
+	"github.com/xiaowumin-mark/AMLX/model"
+	"github.com/xiaowumin-mark/AMLX/store"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNotCDNTarget   = errors.New("draft is not published to AMLX")
+	ErrObjectNotFound = errors.New("object not found")
+)
+
+// 每次拉取清单的数量
+const (
+	defaultManifestLimit = 100
+	maxManifestLimit     = 500
+)
+
+// 歌曲平台与对应的 TTML 元数据
+var songPlatforms = []struct {
+	Platform string
+	Meta     string
+}{
+	{"ncm", lyrics.MetaNCMMusicID},
+	{"qq", lyrics.MetaQQMusicID},
+	{"spotify", lyrics.MetaSpotifyID},
+	{"apple", lyrics.MetaAppleMusicID},
+	{"isrc", lyrics.MetaISRC},
+}
+
+type PublishService interface {
+	Publish(ctx context.Context, draft *model.LyricsDraft) error                     // 发布回调：生成清单并推送到节点
+	PublishByID(ctx context.Context, draftID uint) (*cdn.Manifest, error)            // 手动发布已发布稿件
+	Manifests(ctx context.Context, after uint, limit int) (*cdn.ManifestPage, error) // 按游标拉取清单
+	Object(ctx context.Context, hash string) (*model.PublishObject, error)           // 按哈希获取对象
+}
+
+type publishService struct {
+	cfg      config.CDNConfig
+	tx       store.Transactor
+	drafts   store.DraftStore
+	versions store.VersionStore
+	publish  store.PublishStore
+	nodes    CDNNodeService
+	client   *http.Client
+}
+
+func NewPublishService(cfg config.CDNConfig, tx store.Transactor, drafts store.DraftStore, versions store.VersionStore, publish store.PublishStore, nodes CDNNodeService) PublishService {
+	return &publishService{
+		cfg:      cfg,
+		tx:       tx,
+		drafts:   drafts,
+		versions: versions,
+		publish:  publish,
+		nodes:    nodes,
+		client:   &http.Client{Timeout: cfg.PushTimeout},
+	}
+}
+
+// 发布回调：未启用或只发布到 GitHub 时跳过
+func (s *publishService) Publish(ctx context.Context, draft *model.LyricsDraft) error {
+	if s.cfg.Token == "" || !isCDNTarget(draft) {
+		return nil
+	}
+	_, err := s.build(ctx, draft)
+	return err
+}
+
+func (s *publishService) PublishByID(ctx context.Context, draftID uint) (*cdn.Manifest, error) {
+	if s.cfg.Token == "" {
+		return nil, ErrCDNDisabled
+	}
+	if draftID == 0 {
+		return nil, ErrInvalidInput
+	}
+	draft, err := s.drafts.GetByID(ctx, draftID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrDraftNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !isPublished(draft) {
+		return nil, ErrInvalidDraftState
+	}
+	if !isCDNTarget(draft) {
+		return nil, ErrNotCDNTarget
+	}
+	return s.build(ctx, draft)
+}
+
+// 由审核快照生成清单与对象并推送；快照未变化时返回已有清单，不重复推送
+func (s *publishService) build(ctx context.Context, draft *model.LyricsDraft) (*cdn.Manifest, error) {
+	doc, err := snapshotDocument(ctx, s.versions, draft)
+	if err != nil {
+		return nil, err
+	}
+	content := ttml.Marshal(doc)
+	versionHash := cdn.Hash(content)
+	latest, err := s.publish.LatestByDraft(ctx, draft.ID)
+	switch {
+	case err == nil && latest.VersionHash == versionHash:
+		return s.toManifest(ctx, latest)
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, err
+	}
+
+	// 规范 TTML 总是保存，作为后续补丁的基础版本
+	objects := []*model.PublishObject{newPublishObject(cdn.FormatTTML, content)}
+	var formats []cdn.Object
+	for _, format := range s.cfg.Formats {
+		switch format {
+		case cdn.FormatTTML:
+			formats = append(formats, toCDNObject(cdn.FormatTTML, objects[0]))
+		case cdn.FormatBinary:
+			object := newPublishObject(cdn.FormatBinary, pack.Encode(doc))
+			objects = append(objects, object)
+			formats = append(formats, toCDNObject(cdn.FormatBinary, object))
+		}
+	}
+
+	songs := songRefs(doc)
+	rows := songRows(songs)
+	var diffs []cdn.Diff
+	if slices.Contains(s.cfg.Formats, cdn.FormatDiff) {
+		diffs, objects, err = s.diffChain(ctx, rows, doc, versionHash, objects)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	formatsJSON, _ := json.Marshal(formats)
+	diffsJSON, _ := json.Marshal(diffs)
+	manifest := &model.PublishManifest{
+		DraftID:     draft.ID,
+		VersionID:   *draft.ReviewSnapshotID,
+		VersionHash: versionHash,
+		Title:       draft.Title,
+		Artists:     draft.Artists,
+		SongID:      draft.SongID,
+		Formats:     string(formatsJSON),
+		Diffs:       string(diffsJSON),
+	}
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		for _, object := range objects {
+			if err := s.publish.PutObject(ctx, object); err != nil {
+				return err
+			}
+		}
+		return s.publish.CreateManifest(ctx, manifest, rows)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := toCDNManifest(manifest, songs)
+	go s.push(result)
+	return result, nil
+}
+
+// 在同一首歌上一次发布的补丁链后追加一环，只保留最近 DiffDepth 环
+func (s *publishService) diffChain(ctx context.Context, songs []model.PublishManifestSong, doc *lyrics.Document, versionHash string, objects []*model.PublishObject) ([]cdn.Diff, []*model.PublishObject, error) {
+	prev, err := s.publish.LatestBySongs(ctx, songs)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, objects, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if prev.VersionHash == versionHash {
+		return decodeDiffs(prev.Diffs), objects, nil
+	}
+	base, err := s.publish.GetObject(ctx, prev.VersionHash)
+	if err != nil {
+		return nil, nil, err
+	}
+	baseDoc, err := ttml.Parse(base.Data)
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := json.Marshal(patch.Diff(baseDoc, doc))
+	if err != nil {
+		return nil, nil, err
+	}
+	object := newPublishObject(cdn.FormatDiff, data)
+	chain := append(decodeDiffs(prev.Diffs), cdn.Diff{
+		FromHash: prev.VersionHash,
+		ToHash:   versionHash,
+		Object:   toCDNObject(cdn.FormatDiff, object),
+	})
+	if len(chain) > s.cfg.DiffDepth {
+		chain = chain[len(chain)-s.cfg.DiffDepth:]
+	}
+	return chain, append(objects, object), nil
+}
+
+// 推送到登记了地址且未失联的节点，失败计入节点的推送错误，节点可通过拉取补齐
+func (s *publishService) push(manifest *cdn.Manifest) {
+	ctx := context.Background()
+	nodes, err := s.nodes.Nodes(ctx, "")
+	if err != nil {
+		logx.L().Warn("list cdn nodes failed", "error", err)
+		return
+	}
+	for i := range nodes {
+		node := &nodes[i]
+		if node.Endpoint == "" || node.Status == model.CDNNodeStale {
+			continue
+		}
+		if err := cdn.Push(ctx, s.client, node.Endpoint, s.cfg.Token, manifest); err != nil {
+			logx.L().Warn("push manifest failed", "node", node.Name, "manifest_id", manifest.ID, "error", err)
+			if err := s.nodes.RecordPushError(ctx, node); err != nil {
+				logx.L().Warn("record push error failed", "node", node.Name, "error", err)
+			}
+		}
+	}
+}
+
+func (s *publishService) Manifests(ctx context.Context, after uint, limit int) (*cdn.ManifestPage, error) {
+	if limit <= 0 {
+		limit = defaultManifestLimit
+	}
+	limit = min(limit, maxManifestLimit)
+	rows, err := s.publish.ListManifests(ctx, after, limit)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint, len(rows))
+	for i := range rows {
+		ids[i] = rows[i].ID
+	}
+	refs, err := s.publish.ListSongs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	songs := map[uint][]cdn.SongRef{}
+	for _, row := range refs {
+		songs[row.ManifestID] = append(songs[row.ManifestID], cdn.SongRef{Platform: row.Platform, ID: row.SongID})
+	}
+
+	page := &cdn.ManifestPage{Manifests: make([]cdn.Manifest, 0, len(rows)), Cursor: after}
+	for i := range rows {
+		page.Manifests = append(page.Manifests, *toCDNManifest(&rows[i], songs[rows[i].ID]))
+		page.Cursor = rows[i].ID
+	}
+	return page, nil
+}
+
+func (s *publishService) Object(ctx context.Context, hash string) (*model.PublishObject, error) {
+	object, err := s.publish.GetObject(ctx, strings.ToLower(hash))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrObjectNotFound
+	}
+	return object, err
+}
+
+func (s *publishService) toManifest(ctx context.Context, manifest *model.PublishManifest) (*cdn.Manifest, error) {
+	rows, err := s.publish.ListSongs(ctx, []uint{manifest.ID})
+	if err != nil {
+		return nil, err
+	}
+	songs := make([]cdn.SongRef, len(rows))
+	for i, row := range rows {
+		songs[i] = cdn.SongRef{Platform: row.Platform, ID: row.SongID}
+	}
+	return toCDNManifest(manifest, songs), nil
+}
+
+// 发布目标为空时按 AMLX 处理
+func isCDNTarget(draft *model.LyricsDraft) bool {
+	return draft.PublishTarget != model.PublishTargetGithub
+}
+
+// 文档元数据中的平台 ID
+func songRefs(doc *lyrics.Document) []cdn.SongRef {
+	var refs []cdn.SongRef
+	for _, platform := range songPlatforms {
+		for _, value := range doc.MetaValues(platform.Meta) {
+			if value = strings.TrimSpace(value); value != "" {
+				refs = append(refs, cdn.SongRef{Platform: platform.Platform, ID: value})
+			}
+		}
+	}
+	return refs
+}
+
+func songRows(songs []cdn.SongRef) []model.PublishManifestSong {
+	rows := make([]model.PublishManifestSong, len(songs))
+	for i, song := range songs {
+		rows[i] = model.PublishManifestSong{Platform: song.Platform, SongID: song.ID}
+	}
+	return rows
+}
+
+func newPublishObject(format string, data []byte) *model.PublishObject {
+	return &model.PublishObject{
+		Hash:        cdn.Hash(data),
+		ContentType: cdn.ContentTypes[format],
+		Size:        int64(len(data)),
+		Data:        data,
+	}
+}
+
+func toCDNObject(format string, object *model.PublishObject) cdn.Object {
+	return cdn.Object{Format: format, Hash: object.Hash, Size: object.Size}
+}
+
+func decodeDiffs(value string) []cdn.Diff {
+	var diffs []cdn.Diff
+	json.Unmarshal([]byte(value), &diffs)
+	return diffs
+}
+
+func toCDNManifest(manifest *model.PublishManifest, songs []cdn.SongRef) *cdn.Manifest {
+	var formats []cdn.Object
+	json.Unmarshal([]byte(manifest.Formats), &formats)
+	if formats == nil {
+		formats = []cdn.Object{}
+	}
+	diffs := decodeDiffs(manifest.Diffs)
+	if diffs == nil {
+		diffs = []cdn.Diff{}
+	}
+	if songs == nil {
+		songs = []cdn.SongRef{}
+	}
+	return &cdn.Manifest{
+		ID:          manifest.ID,
+		DraftID:     manifest.DraftID,
+		VersionID:   manifest.VersionID,
+		VersionHash: manifest.VersionHash,
+		Title:       manifest.Title,
+		Artists:     DecodeArtists(manifest.Artists),
+		Songs:       songs,
+		Formats:     formats,
+		Diffs:       diffs,
+		PublishedAt: manifest.CreatedAt,
+	}
+}
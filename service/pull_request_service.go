@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/xiaowumin-mark/AMLX/config"
+	"github.com/xiaowumin-mark/AMLX/gitprovider"
+	"github.com/xiaowumin-mark/AMLX/logx"
+	"github.com/xiaowumin-mark/AMLX/lyrics/ttml"
+	"github.com/xiaowumin-mark/AMLX/model"
+	"github.com/xiaowumin-mark/AMLX/store"
+	"github.com/xiaowumin-mark/AMLX/ttmldb"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrPullRequestDisabled = errors.New("pull requests are not configured")
+	ErrNotGithubTarget     = errors.New("draft is not published to GitHub")
+	ErrPullRequestExists   = errors.New("draft already has a pull request")
+	ErrWebhookSignature    = errors.New("invalid webhook signature")
+)
+
+type PullRequestService interface {
+	Open(ctx context.Context, draft *model.LyricsDraft) error                         // 发布回调：发布到 GitHub 的稿件创建 PR
+	OpenByID(ctx context.Context, draftID uint) (*model.LyricsDraft, error)           // 为已发布稿件手动创建 PR
+	Refresh(ctx context.Context) (int, error)                                         // 轮询未合并的 PR，返回状态有变化的数量
+	HandleWebhook(ctx context.Context, event, signature string, payload []byte) error // 处理 GitHub pull_request webhook
+}
+
+type pullRequestService struct {
+	cfg      config.GitConfig
+	provider gitprovider.GitProvider
+	drafts   store.DraftStore
+	versions store.VersionStore
+}
+
+func NewPullRequestService(cfg config.GitConfig, drafts store.DraftStore, versions store.VersionStore) PullRequestService {
+	s := &pullRequestService{cfg: cfg, drafts: drafts, versions: versions}
+	switch cfg.Provider {
+	case "github":
+		s.provider = gitprovider.NewGitHub(cfg.APIURL, cfg.Owner, cfg.Repo, cfg.BaseBranch, cfg.Token)
+	}
+	return s
+}
+
+// 发布回调：未启用、不发布到 GitHub 或已有 PR 时跳过
+func (s *pullRequestService) Open(ctx context.Context, draft *model.LyricsDraft) error {
+	if s.provider == nil || !isGithubTarget(draft) || hasPullRequest(draft) {
+		return nil
+	}
+	return s.open(ctx, draft)
+}
+
+// 为已发布稿件手动创建 PR，用于回调失败后重试或 PR 被关闭后重新提交
+func (s *pullRequestService) OpenByID(ctx context.Context, draftID uint) (*model.LyricsDraft, error) {
+	if s.provider == nil {
+		return nil, ErrPullRequestDisabled
+	}
+	if draftID == 0 {
+		return nil, ErrInvalidInput
+	}
+	draft, err := s.drafts.GetByID(ctx, draftID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrDraftNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case !isPublished(draft):
+		return nil, ErrInvalidDraftState
+	case !isGithubTarget(draft):
+		return nil, ErrNotGithubTarget
+	case hasPullRequest(draft):
+		return nil, ErrPullRequestExists
+	}
+	if err := s.open(ctx, draft); err != nil {
+		return nil, err
+	}
+	return draft, nil
+}
+
+// 提交审核快照并打开 PR，每个稿件使用独立分支
+func (s *pullRequestService) open(ctx context.Context, draft *model.LyricsDraft) error {
+	doc, err := snapshotDocument(ctx, s.versions, draft)
+	if err != nil {
+		return err
+	}
+	content := ttml.Marshal(doc)
+	artists := strings.Join(DecodeArtists(draft.Artists), " / ")
+	pr, err := s.provider.OpenPullRequest(ctx, gitprovider.NewPullRequest{
+		Branch: fmt.Sprintf("amlx/draft-%d", draft.ID),
+		Title:  fmt.Sprintf("Add lyrics: %s - %s", draft.Title, artists),
+		Body:   fmt.Sprintf("AMLX draft #%d", draft.ID),
+		Files:  []gitprovider.File{{Path: ttmldb.RawPath(draft.UpdatedAt, s.cfg.ContributorID, content), Content: content}},
+	})
+	if err != nil {
+		return err
+	}
+	draft.GithubPRURL = pr.URL
+	draft.GithubPRNumber = pr.Number
+	draft.GithubPRState = model.PullRequestState(pr.State)
+	if err := s.drafts.UpdatePullRequest(ctx, draft); err != nil {
+		return err
+	}
+	logx.L().Info("pull request opened", "draft_id", draft.ID, "url", pr.URL)
+	return nil
+}
+
+// 轮询所有 OPEN 状态的 PR
+func (s *pullRequestService) Refresh(ctx context.Context) (int, error) {
+	if s.provider == nil {
+		return 0, ErrPullRequestDisabled
+	}
+	var open []model.LyricsDraft
+	for offset := 0; ; offset += rebuildBatchSize {
+		drafts, _, err := s.drafts.List(ctx, store.DraftFilter{
+			PRState: model.PullRequestOpen,
+			Offset:  offset,
+			Limit:   rebuildBatchSize,
+		})
+		if err != nil {
+			return 0, err
+		}
+		open = append(open, drafts...)
+		if len(drafts) < rebuildBatchSize {
+			break
+		}
+	}
+
+	// 单个 PR 查询失败不影响其他 PR，下次轮询重试
+	changed := 0
+	for i := range open {
+		ok, err := s.sync(ctx, &open[i])
+		if err != nil {
+			logx.L().Warn("pull request refresh failed", "draft_id", open[i].ID, "error", err)
+			continue
+		}
+		if ok {
+			changed++
+		}
+	}
+	return changed, nil
+}
+
+type pullRequestEvent struct {
+	Action      string `json:"action"`
+	PullRequest struct {
+		Number int `json:"number"`
+	} `json:"pull_request"`
+	Repository struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+}
+
+// 校验签名后按 PR 编号刷新对应稿件；状态以平台查询结果为准，其他事件与仓库忽略
+func (s *pullRequestService) HandleWebhook(ctx context.Context, event, signature string, payload []byte) error {
+	if s.provider == nil || s.cfg.WebhookSecret == "" {
+		return ErrPullRequestDisabled
+	}
+	if !validSignature(s.cfg.WebhookSecret, signature, payload) {
+		return ErrWebhookSignature
+	}
+	if event != "pull_request" {
+		return nil
+	}
+	var body pullRequestEvent
+	if err := json.Unmarshal(payload, &body); err != nil {
+		return ErrInvalidInput
+	}
+	if !strings.EqualFold(body.Repository.FullName, s.cfg.Owner+"/"+s.cfg.Repo) || body.PullRequest.Number == 0 {
+		return nil
+	}
+	draft, err := s.drafts.GetByPullRequest(ctx, body.PullRequest.Number)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	_, err = s.sync(ctx, draft)
+	return err
+}
+
+// 从平台读取 PR 状态写回稿件，返回是否有变化
+func (s *pullRequestService) sync(ctx context.Context, draft *model.LyricsDraft) (bool, error) {
+	pr, err := s.provider.GetPullRequest(ctx, draft.GithubPRNumber)
+	if err != nil {
+		return false, fmt.Errorf("pull request #%d: %w", draft.GithubPRNumber, err)
+	}
+	state := model.PullRequestState(pr.State)
+	if state == draft.GithubPRState {
+		return false, nil
+	}
+	draft.GithubPRState = state
+	if err := s.drafts.UpdatePullRequest(ctx, draft); err != nil {
+		return false, err
+	}
+	logx.L().Info("pull request state changed", "draft_id", draft.ID, "state", state)
+	return true, nil
+}
+
+func isGithubTarget(draft *model.LyricsDraft) bool {
+	return draft.PublishTarget == model.PublishTargetGithub || draft.PublishTarget == model.PublishTargetBoth
+}
+
+// 已有未关闭或已合并的 PR
+func hasPullRequest(draft *model.LyricsDraft) bool {
+	return draft.GithubPRState == model.PullRequestOpen || draft.GithubPRState == model.PullRequestMerged
+}
+
+// GitHub 签名格式：sha256=<hex(HMAC-SHA256(secret, payload))>
+func validSignature(secret, signature string, payload []byte) bool {
+	hexSum, ok := strings.CutPrefix(signature, "sha256=")
+	if !ok {
+		return false
+	}
+	sum, err := hex.DecodeString(hexSum)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hmac.Equal(sum, mac.Sum(nil))
+}
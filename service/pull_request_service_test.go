@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/xiaowumin-mark/AMLX/config"
+	"github.com/xiaowumin-mark/AMLX/gitprovider/gitprovidertest"
+	"github.com/xiaowumin-mark/AMLX/lyrics/ttml"
+	"github.com/xiaowumin-mark/AMLX/model"
+	"github.com/xiaowumin-mark/AMLX/store/storetest"
+	"github.com/xiaowumin-mark/AMLX/ttmldb"
+)
+
+type pullRequestFixture struct {
+	server   *gitprovidertest.Server
+	drafts   *storetest.DraftStore
+	versions *storetest.VersionStore
+	service  *pullRequestService
+}
+
+func newPullRequestFixture() *pullRequestFixture {
+	cfg := config.GitConfig{Owner: "owner", Repo: "repo", BaseBranch: "main", ContributorID: "42", WebhookSecret: "secret"}
+	f := &pullRequestFixture{
+		server:   gitprovidertest.NewServer(cfg.Owner, cfg.Repo, cfg.BaseBranch),
+		drafts:   storetest.NewDraftStore(),
+		versions: storetest.NewVersionStore(),
+	}
+	f.service = &pullRequestService{cfg: cfg, provider: f.server.Provider(), drafts: f.drafts, versions: f.versions}
+	return f
+}
+
+// 写入一个已发布稿件，target 为发布目标
+func (f *pullRequestFixture) publish(t *testing.T, id uint, target string) *model.LyricsDraft {
+	t.Helper()
+	title := fmt.Sprintf("Song %d", id)
+	draft := model.LyricsDraft{Title: title, Artists: `["Artist"]`, PublishTarget: target}
+	return putPublished(t, f.drafts, f.versions, draft, id, syncTTML(title, fmt.Sprint(id)))
+}
+
+func (f *pullRequestFixture) state(t *testing.T, id uint) model.PullRequestState {
+	t.Helper()
+	draft, err := f.drafts.GetByID(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return draft.GithubPRState
+}
+
+func TestPullRequestOpen(t *testing.T) {
+	f := newPullRequestFixture()
+	ctx := context.Background()
+	github := f.publish(t, 1, model.PublishTargetGithub)
+	amlx := f.publish(t, 2, model.PublishTargetAMLX)
+
+	if err := f.service.Open(ctx, github); err != nil {
+		t.Fatal(err)
+	}
+	stored, _ := f.drafts.GetByID(ctx, github.ID)
+	if stored.GithubPRNumber != 1 || stored.GithubPRState != model.PullRequestOpen || stored.GithubPRURL == "" {
+		t.Fatalf("draft after open: %+v", stored)
+	}
+	if _, ok := f.server.File("amlx/draft-1", rawPathOf(t, f, github.ID)); !ok {
+		t.Fatal("snapshot not committed to the draft branch")
+	}
+
+	// 已有 PR 或不发布到 GitHub 的稿件跳过
+	if err := f.service.Open(ctx, stored); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.service.Open(ctx, amlx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.server.Provider().GetPullRequest(ctx, 2); err == nil {
+		t.Fatal("a second pull request was opened")
+	}
+
+	if _, err := f.service.OpenByID(ctx, github.ID); !errors.Is(err, ErrPullRequestExists) {
+		t.Fatalf("got %v, want ErrPullRequestExists", err)
+	}
+	if _, err := f.service.OpenByID(ctx, amlx.ID); !errors.Is(err, ErrNotGithubTarget) {
+		t.Fatalf("got %v, want ErrNotGithubTarget", err)
+	}
+	if _, err := f.service.OpenByID(ctx, 99); !errors.Is(err, ErrDraftNotFound) {
+		t.Fatalf("got %v, want ErrDraftNotFound", err)
+	}
+}
+
+// 稿件审核快照在 PR 中的文件路径
+func rawPathOf(t *testing.T, f *pullRequestFixture, id uint) string {
+	t.Helper()
+	draft, err := f.drafts.GetByID(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := snapshotDocument(context.Background(), f.versions, draft)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ttmldb.RawPath(draft.UpdatedAt, f.service.cfg.ContributorID, ttml.Marshal(doc))
+}
+
+func TestPullRequestRefresh(t *testing.T) {
+	f := newPullRequestFixture()
+	ctx := context.Background()
+	for id := uint(1); id <= 3; id++ {
+		if err := f.service.Open(ctx, f.publish(t, id, model.PublishTargetBoth)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 平台上不存在的 PR，查询失败不能阻止其他 PR 刷新
+	missing := f.publish(t, 4, model.PublishTargetGithub)
+	missing.GithubPRNumber, missing.GithubPRState = 99, model.PullRequestOpen
+	f.drafts.Put(*missing)
+
+	changed, err := f.service.Refresh(ctx)
+	if err != nil || changed != 0 {
+		t.Fatalf("refresh without changes: %d, %v", changed, err)
+	}
+
+	if err := f.server.Merge(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.server.Close(2); err != nil {
+		t.Fatal(err)
+	}
+	changed, err = f.service.Refresh(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed != 2 {
+		t.Fatalf("refresh changed %d, want 2", changed)
+	}
+	want := map[uint]model.PullRequestState{
+		1: model.PullRequestMerged,
+		2: model.PullRequestClosed,
+		3: model.PullRequestOpen,
+		4: model.PullRequestOpen,
+	}
+	for id, state := range want {
+		if got := f.state(t, id); got != state {
+			t.Errorf("draft %d: got %s, want %s", id, got, state)
+		}
+	}
+	if _, ok := f.server.File("main", rawPathOf(t, f, 1)); !ok {
+		t.Fatal("merged file missing from base branch")
+	}
+
+	// 被关闭的 PR 可以重新提交
+	if _, err := f.service.OpenByID(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	if got := f.state(t, 2); got != model.PullRequestOpen {
+		t.Fatalf("reopened draft state %s", got)
+	}
+}
+
+func TestPullRequestWebhookMerge(t *testing.T) {
+	f := newPullRequestFixture()
+	ctx := context.Background()
+	if err := f.service.Open(ctx, f.publish(t, 1, model.PublishTargetGithub)); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.server.Merge(1); err != nil {
+		t.Fatal(err)
+	}
+
+	payload := []byte(`{"action":"closed","pull_request":{"number":1},"repository":{"full_name":"owner/repo"}}`)
+	if err := f.service.HandleWebhook(ctx, "pull_request", "sha256=00", payload); !errors.Is(err, ErrWebhookSignature) {
+		t.Fatalf("got %v, want ErrWebhookSignature", err)
+	}
+	if got := f.state(t, 1); got != model.PullRequestOpen {
+		t.Fatalf("unsigned webhook changed state to %s", got)
+	}
+	if err := f.service.HandleWebhook(ctx, "pull_request", sign("secret", payload), payload); err != nil {
+		t.Fatal(err)
+	}
+	if got := f.state(t, 1); got != model.PullRequestMerged {
+		t.Fatalf("got %s, want MERGED", got)
+	}
+}
+
+func sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
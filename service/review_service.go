@@ -0,0 +1,224 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/xiaowumin-mark/AMLX/logx"
+	"github.com/xiaowumin-mark/AMLX/lyrics/patch"
+	"github.com/xiaowumin-mark/AMLX/model"
+	"github.com/xiaowumin-mark/AMLX/store"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrSelfReview        = errors.New("reviewers cannot review their own drafts")
+	ErrSnapshotNotFound  = errors.New("review snapshot not found")
+	ErrSnapshotImmutable = store.ErrSnapshotImmutable
+)
+
+// 发布回调：稿件审核通过且事务提交后调用，失败只记录日志，不影响审核结果
+type PublishHook func(ctx context.Context, draft *model.LyricsDraft) error
+
+type RejectDraftRequest struct {
+	Reason  string
+	ToStage model.WorkflowStage
+}
+
+type ReviewService interface {
+	ListPending(ctx context.Context, page, pageSize int) ([]model.LyricsDraft, int64, error)                  // 待审核稿件
+	GetDraft(ctx context.Context, draftID uint) (*model.LyricsDraft, error)                                   // 获取稿件
+	Approve(ctx context.Context, reviewerID, draftID uint) (*model.LyricsDraft, error)                        // 通过
+	Reject(ctx context.Context, reviewerID, draftID uint, req RejectDraftRequest) (*model.LyricsDraft, error) // 驳回
+	ListReviews(ctx context.Context, draftID uint) ([]model.LyricsReview, error)                              // 审核记录
+	GetSnapshot(ctx context.Context, draftID uint) (*model.LyricsVersion, error)                              // 审核快照
+	ListVersions(ctx context.Context, draftID uint) ([]model.LyricsVersion, error)                            // 版本列表
+	GetVersion(ctx context.Context, draftID, versionID uint) (*model.LyricsVersion, error)                    // 获取版本
+	DiffVersions(ctx context.Context, draftID, fromID, toID uint) (*VersionDiff, error)                       // 版本 diff
+	ExportVersion(ctx context.Context, draftID, versionID uint, format string) (*ExportedLyrics, error)       // 导出版本
+	PatchVersions(ctx context.Context, draftID, fromID, toID uint) (*patch.Patch, error)                      // 结构化补丁
+}
+
+type reviewService struct {
+	drafts   store.DraftStore
+	reviews  store.ReviewStore
+	versions store.VersionStore
+	reader   *versionReader
+	flow     *draftTransitioner
+	hooks    []PublishHook
+}
+
+func NewReviewService(tx store.Transactor, drafts store.DraftStore, transitions store.DraftTransitionStore, reviews store.ReviewStore, versions store.VersionStore, onTransition TransitionHook, hooks ...PublishHook) ReviewService {
+	return &reviewService{
+		drafts:   drafts,
+		reviews:  reviews,
+		versions: versions,
+		reader:   &versionReader{versions: versions},
+		flow:     &draftTransitioner{tx: tx, drafts: drafts, transitions: transitions, hook: onTransition},
+		hooks:    hooks,
+	}
+}
+
+// 待审核稿件
+func (s *reviewService) ListPending(ctx context.Context, page, pageSize int) ([]model.LyricsDraft, int64, error) {
+	offset, limit := pagination(page, pageSize)
+	return s.drafts.List(ctx, store.DraftFilter{
+		Status: model.DraftInReview,
+		Offset: offset,
+		Limit:  limit,
+	})
+}
+
+// 获取稿件
+func (s *reviewService) GetDraft(ctx context.Context, draftID uint) (*model.LyricsDraft, error) {
+	if draftID == 0 {
+		return nil, ErrInvalidInput
+	}
+	draft, err := s.drafts.GetByID(ctx, draftID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrDraftNotFound
+	}
+	return draft, err
+}
+
+// 通过
+func (s *reviewService) Approve(ctx context.Context, reviewerID, draftID uint) (*model.LyricsDraft, error) {
+	draft, err := s.flow.transition(ctx, transitionRequest{
+		DraftID: draftID,
+		ActorID: reviewerID,
+		Action:  model.ActionApprove,
+		Check:   forbidSelfReview(reviewerID),
+		Apply: func(ctx context.Context, draft *model.LyricsDraft) error {
+			return s.reviews.Create(ctx, &model.LyricsReview{
+				DraftID:        draft.ID,
+				ReviewerUserID: reviewerID,
+				Result:         model.ReviewApproved,
+			})
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	runPublishHooks(ctx, s.hooks, draft)
+	return draft, nil
+}
+
+func runPublishHooks(ctx context.Context, hooks []PublishHook, draft *model.LyricsDraft) {
+	for _, hook := range hooks {
+		if err := hook(ctx, draft); err != nil {
+			logx.L().Warn("publish hook failed", "draft_id", draft.ID, "error", err)
+		}
+	}
+}
+
+// 驳回：退回指定阶段，并累计驳回次数
+func (s *reviewService) Reject(ctx context.Context, reviewerID, draftID uint, req RejectDraftRequest) (*model.LyricsDraft, error) {
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" || StageIndex(req.ToStage) < 0 {
+		return nil, ErrInvalidInput
+	}
+	toStage := req.ToStage
+	return s.flow.transition(ctx, transitionRequest{
+		DraftID: draftID,
+		ActorID: reviewerID,
+		Action:  model.ActionReject,
+		Target:  &toStage,
+		Reason:  reason,
+		Check:   forbidSelfReview(reviewerID),
+		Apply: func(ctx context.Context, draft *model.LyricsDraft) error {
+			now := time.Now()
+			draft.RejectCount++
+			draft.LastRejectAt = &now
+			return s.reviews.Create(ctx, &model.LyricsReview{
+				DraftID:        draft.ID,
+				ReviewerUserID: reviewerID,
+				Result:         model.ReviewRejected,
+				RejectReason:   reason,
+				RejectToStage:  &toStage,
+			})
+		},
+	})
+}
+
+// 审核记录
+func (s *reviewService) ListReviews(ctx context.Context, draftID uint) ([]model.LyricsReview, error) {
+	if _, err := s.GetDraft(ctx, draftID); err != nil {
+		return nil, err
+	}
+	return s.reviews.ListByDraft(ctx, draftID)
+}
+
+// 审核快照：稿件提交审核时冻结的版本，不受贡献者后续编辑影响
+func (s *reviewService) GetSnapshot(ctx context.Context, draftID uint) (*model.LyricsVersion, error) {
+	draft, err := s.GetDraft(ctx, draftID)
+	if err != nil {
+		return nil, err
+	}
+	if draft.ReviewSnapshotID == nil {
+		return nil, ErrSnapshotNotFound
+	}
+	snapshot, err := s.versions.GetByID(ctx, *draft.ReviewSnapshotID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrSnapshotNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !snapshot.IsSnapshot || snapshot.DraftID != draft.ID {
+		return nil, ErrSnapshotNotFound
+	}
+	return snapshot, nil
+}
+
+// 版本列表
+func (s *reviewService) ListVersions(ctx context.Context, draftID uint) ([]model.LyricsVersion, error) {
+	if _, err := s.GetDraft(ctx, draftID); err != nil {
+		return nil, err
+	}
+	return s.reader.list(ctx, draftID)
+}
+
+// 获取版本
+func (s *reviewService) GetVersion(ctx context.Context, draftID, versionID uint) (*model.LyricsVersion, error) {
+	if _, err := s.GetDraft(ctx, draftID); err != nil {
+		return nil, err
+	}
+	return s.reader.get(ctx, draftID, versionID)
+}
+
+// 版本 diff，便于对比历次驳回之间的修改
+func (s *reviewService) DiffVersions(ctx context.Context, draftID, fromID, toID uint) (*VersionDiff, error) {
+	if _, err := s.GetDraft(ctx, draftID); err != nil {
+		return nil, err
+	}
+	return s.reader.diff(ctx, draftID, fromID, toID)
+}
+
+// 导出版本
+func (s *reviewService) ExportVersion(ctx context.Context, draftID, versionID uint, format string) (*ExportedLyrics, error) {
+	draft, err := s.GetDraft(ctx, draftID)
+	if err != nil {
+		return nil, err
+	}
+	return s.reader.export(ctx, draft, versionID, format)
+}
+
+// 结构化补丁，按行 / 音节 / 时间轴展示修改
+func (s *reviewService) PatchVersions(ctx context.Context, draftID, fromID, toID uint) (*patch.Patch, error) {
+	if _, err := s.GetDraft(ctx, draftID); err != nil {
+		return nil, err
+	}
+	return s.reader.patch(ctx, draftID, fromID, toID)
+}
+
+// 审核人不能审核自己的稿件
+func forbidSelfReview(reviewerID uint) func(ctx context.Context, draft *model.LyricsDraft) error {
+	return func(ctx context.Context, draft *model.LyricsDraft) error {
+		if draft.OwnerUserID == reviewerID {
+			return ErrSelfReview
+		}
+		return nil
+	}
+}
@@ -0,0 +1,254 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/xiaowumin-mark/AMLX/model"
+	"github.com/xiaowumin-mark/AMLX/store"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrRollbackNotFound   = errors.New("rollback request not found")
+	ErrRollbackDisabled   = errors.New("stage rollback is disabled for this draft")
+	ErrRollbackPending    = errors.New("draft already has a pending rollback request")
+	ErrRollbackNotPending = errors.New("rollback request is not pending")
+	ErrRollbackStale      = errors.New("draft stage changed since the rollback was requested")
+)
+
+type RequestRollbackRequest struct {
+	ToStage model.WorkflowStage
+	Mode    model.RollbackMode
+	Reason  string
+}
+
+type RollbackService interface {
+	Request(ctx context.Context, userID, draftID uint, req RequestRollbackRequest) (*model.StageRollback, error) // 申请回退
+	List(ctx context.Context, userID, draftID uint) ([]model.StageRollback, error)                               // 回退申请列表
+	Approve(ctx context.Context, userID, draftID, rollbackID uint) (*model.StageRollback, error)                 // 批准
+	Deny(ctx context.Context, userID, draftID, rollbackID uint) (*model.StageRollback, error)                    // 拒绝
+}
+
+type rollbackService struct {
+	tx          store.Transactor
+	drafts      store.DraftStore
+	transitions store.DraftTransitionStore
+	versions    store.VersionStore
+	rollbacks   store.RollbackStore
+	flow        *draftTransitioner
+	onChange    DraftChangeHook
+}
+
+func NewRollbackService(tx store.Transactor, drafts store.DraftStore, transitions store.DraftTransitionStore, versions store.VersionStore, rollbacks store.RollbackStore, onChange DraftChangeHook) RollbackService {
+	return &rollbackService{
+		tx:          tx,
+		drafts:      drafts,
+		transitions: transitions,
+		versions:    versions,
+		rollbacks:   rollbacks,
+		flow:        &draftTransitioner{tx: tx, drafts: drafts, transitions: transitions},
+		onChange:    onChange,
+	}
+}
+
+// 申请回退：任意登录用户可发起，由稿件所有者审批
+func (s *rollbackService) Request(ctx context.Context, userID, draftID uint, req RequestRollbackRequest) (*model.StageRollback, error) {
+	if userID == 0 || draftID == 0 || StageIndex(req.ToStage) < 0 {
+		return nil, ErrInvalidInput
+	}
+	if req.Mode == "" {
+		req.Mode = model.RollbackKeepFiles
+	}
+	if req.Mode != model.RollbackKeepFiles && req.Mode != model.RollbackDropFiles {
+		return nil, ErrInvalidInput
+	}
+
+	var rollback *model.StageRollback
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		draft, err := s.lockDraft(ctx, draftID)
+		if err != nil {
+			return err
+		}
+		if !draft.AllowStageRollback {
+			return ErrRollbackDisabled
+		}
+		toStage := req.ToStage
+		if _, err := ApplyTransition(StateOf(draft), model.ActionRollback, &toStage); err != nil {
+			return err
+		}
+		if _, err := s.rollbacks.GetPendingByDraft(ctx, draftID); err == nil {
+			return ErrRollbackPending
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		rollback = &model.StageRollback{
+			DraftID:      draftID,
+			FromStage:    *draft.WorkflowStage,
+			ToStage:      toStage,
+			RequestedBy:  userID,
+			RollbackMode: req.Mode,
+			Reason:       strings.TrimSpace(req.Reason),
+			Status:       model.RollbackPending,
+		}
+		return s.rollbacks.Create(ctx, rollback)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rollback, nil
+}
+
+// 回退申请列表（仅所有者）
+func (s *rollbackService) List(ctx context.Context, userID, draftID uint) ([]model.StageRollback, error) {
+	if userID == 0 || draftID == 0 {
+		return nil, ErrInvalidInput
+	}
+	draft, err := s.drafts.GetByID(ctx, draftID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrDraftNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if draft.OwnerUserID != userID {
+		return nil, ErrDraftForbidden
+	}
+	return s.rollbacks.ListByDraft(ctx, draftID)
+}
+
+// 批准：在同一事务中回退阶段、按模式处理版本并更新申请
+func (s *rollbackService) Approve(ctx context.Context, userID, draftID, rollbackID uint) (*model.StageRollback, error) {
+	if userID == 0 || rollbackID == 0 {
+		return nil, ErrInvalidInput
+	}
+	// 目标阶段在申请创建后不再变化，可在加锁前读取
+	rollback, err := s.getPending(ctx, draftID, rollbackID)
+	if err != nil {
+		return nil, err
+	}
+	toStage := rollback.ToStage
+	draft, err := s.flow.transition(ctx, transitionRequest{
+		DraftID: draftID,
+		ActorID: userID,
+		Action:  model.ActionRollback,
+		Target:  &toStage,
+		Reason:  rollback.Reason,
+		Check: func(ctx context.Context, draft *model.LyricsDraft) error {
+			if draft.OwnerUserID != userID {
+				return ErrDraftForbidden
+			}
+			if !draft.AllowStageRollback {
+				return ErrRollbackDisabled
+			}
+			var err error
+			rollback, err = s.getPending(ctx, draftID, rollbackID)
+			if err != nil {
+				return err
+			}
+			if draft.WorkflowStage == nil || *draft.WorkflowStage != rollback.FromStage {
+				return ErrRollbackStale
+			}
+			return nil
+		},
+		Apply: func(ctx context.Context, draft *model.LyricsDraft) error {
+			if rollback.RollbackMode == model.RollbackDropFiles {
+				if err := s.dropFiles(ctx, draftID, rollback.ToStage); err != nil {
+					return err
+				}
+			}
+			return s.decide(ctx, rollback, userID, model.RollbackApproved)
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	runChangeHook(ctx, s.onChange, draft)
+	return rollback, nil
+}
+
+// 拒绝
+func (s *rollbackService) Deny(ctx context.Context, userID, draftID, rollbackID uint) (*model.StageRollback, error) {
+	if userID == 0 || rollbackID == 0 {
+		return nil, ErrInvalidInput
+	}
+	var rollback *model.StageRollback
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		draft, err := s.lockDraft(ctx, draftID)
+		if err != nil {
+			return err
+		}
+		if draft.OwnerUserID != userID {
+			return ErrDraftForbidden
+		}
+		rollback, err = s.getPending(ctx, draftID, rollbackID)
+		if err != nil {
+			return err
+		}
+		return s.decide(ctx, rollback, userID, model.RollbackDenied)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rollback, nil
+}
+
+// 删除稿件最近一次进入目标阶段以来、后续阶段产生的版本，更早的历史（如被驳回前的工作）保留。
+// 从未进入过目标阶段（创建时即处于该阶段）时从创建起算
+func (s *rollbackService) dropFiles(ctx context.Context, draftID uint, toStage model.WorkflowStage) error {
+	var since *time.Time
+	entered, err := s.transitions.LastEntered(ctx, draftID, toStage)
+	switch {
+	case err == nil:
+		since = &entered.CreatedAt
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return err
+	}
+	_, err = s.versions.DeleteByStages(ctx, draftID, stagesAfter(toStage), since)
+	return err
+}
+
+func (s *rollbackService) lockDraft(ctx context.Context, draftID uint) (*model.LyricsDraft, error) {
+	draft, err := s.drafts.GetByIDForUpdate(ctx, draftID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrDraftNotFound
+	}
+	return draft, err
+}
+
+func (s *rollbackService) getPending(ctx context.Context, draftID, rollbackID uint) (*model.StageRollback, error) {
+	rollback, err := s.rollbacks.GetByID(ctx, rollbackID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrRollbackNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if rollback.DraftID != draftID {
+		return nil, ErrRollbackNotFound
+	}
+	if rollback.Status != model.RollbackPending {
+		return nil, ErrRollbackNotPending
+	}
+	return rollback, nil
+}
+
+func (s *rollbackService) decide(ctx context.Context, rollback *model.StageRollback, userID uint, status model.RollbackStatus) error {
+	now := time.Now()
+	rollback.Status = status
+	rollback.ApprovedBy = userID
+	rollback.DecidedAt = &now
+	return s.rollbacks.Update(ctx, rollback)
+}
+
+// 目标阶段之后的所有阶段
+func stagesAfter(stage model.WorkflowStage) []model.WorkflowStage {
+	idx := StageIndex(stage)
+	if idx < 0 {
+		return nil
+	}
+	return append([]model.WorkflowStage(nil), stageOrder[idx+1:]...)
+}
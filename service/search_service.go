@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/xiaowumin-mark/AMLX/lyrics/ttml"
+	"github.com/xiaowumin-mark/AMLX/model"
+	"github.com/xiaowumin-mark/AMLX/search"
+	"github.com/xiaowumin-mark/AMLX/store"
+	"gorm.io/gorm"
+)
+
+// 重建索引时每批读取的稿件数
+const rebuildBatchSize = 200
+
+type SearchRequest struct {
+	Query    string
+	Language string
+	Artist   string
+	Page     int
+	PageSize int
+}
+
+type SearchService interface {
+	Search(ctx context.Context, req SearchRequest) (*search.Result, error) // 搜索已发布稿件
+	IndexDraft(ctx context.Context, draft *model.LyricsDraft) error        // 更新单个稿件的索引
+	Rebuild(ctx context.Context) (int, error)                              // 从数据库重建索引
+}
+
+type searchService struct {
+	index    *search.Index
+	drafts   store.DraftStore
+	versions store.VersionStore
+}
+
+func NewSearchService(index *search.Index, drafts store.DraftStore, versions store.VersionStore) SearchService {
+	return &searchService{index: index, drafts: drafts, versions: versions}
+}
+
+// 搜索已发布稿件
+func (s *searchService) Search(ctx context.Context, req SearchRequest) (*search.Result, error) {
+	offset, limit := pagination(req.Page, req.PageSize)
+	return s.index.Search(search.Query{
+		Text:     req.Query,
+		Language: req.Language,
+		Artist:   req.Artist,
+		Offset:   offset,
+		Limit:    limit,
+	})
+}
+
+// 更新单个稿件的索引：已发布则写入，否则移除
+func (s *searchService) IndexDraft(ctx context.Context, draft *model.LyricsDraft) error {
+	if !isPublished(draft) {
+		return s.index.Delete(draft.ID)
+	}
+	doc, err := s.document(ctx, draft)
+	if err != nil {
+		return err
+	}
+	return s.index.Put(*doc)
+}
+
+// 从数据库重建索引，并移除不再发布的稿件，返回已索引的稿件数
+func (s *searchService) Rebuild(ctx context.Context) (int, error) {
+	published := map[uint]bool{}
+	for offset := 0; ; offset += rebuildBatchSize {
+		drafts, _, err := s.drafts.List(ctx, store.DraftFilter{
+			Status: model.DraftReviewDone,
+			Offset: offset,
+			Limit:  rebuildBatchSize,
+		})
+		if err != nil {
+			return 0, err
+		}
+		docs := make([]search.Document, 0, len(drafts))
+		for i := range drafts {
+			if !isPublished(&drafts[i]) {
+				continue
+			}
+			doc, err := s.document(ctx, &drafts[i])
+			if err != nil {
+				return 0, err
+			}
+			docs = append(docs, *doc)
+			published[drafts[i].ID] = true
+		}
+		if err := s.index.Put(docs...); err != nil {
+			return 0, err
+		}
+		if len(drafts) < rebuildBatchSize {
+			break
+		}
+	}
+
+	indexed, err := s.index.DraftIDs()
+	if err != nil {
+		return 0, err
+	}
+	var stale []uint
+	for _, id := range indexed {
+		if !published[id] {
+			stale = append(stale, id)
+		}
+	}
+	if err := s.index.Delete(stale...); err != nil {
+		return 0, err
+	}
+	return len(published), nil
+}
+
+func (s *searchService) document(ctx context.Context, draft *model.LyricsDraft) (*search.Document, error) {
+	version, err := s.versions.GetByID(ctx, *draft.ReviewSnapshotID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrSnapshotNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &search.Document{
+		DraftID:     draft.ID,
+		Title:       draft.Title,
+		Artists:     DecodeArtists(draft.Artists),
+		Album:       draft.Album,
+		Language:    draft.Language,
+		Lyrics:      lyricText(version.Content),
+		PublishedAt: draft.UpdatedAt,
+	}, nil
+}
+
+// 已发布：审核通过且有冻结的审核快照
+func isPublished(draft *model.LyricsDraft) bool {
+	return draft.Status == model.DraftReviewDone && draft.ReviewSnapshotID != nil
+}
+
+// 歌词文本，每行一句，背景人声单独成行；无法解析为 TTML 时原样返回
+func lyricText(content string) string {
+	doc, err := ttml.ParseString(content)
+	if err != nil {
+		return content
+	}
+	var b strings.Builder
+	for i := range doc.Lines {
+		line := &doc.Lines[i]
+		b.WriteString(line.Text())
+		b.WriteByte('\n')
+		if line.Background != nil {
+			b.WriteString(line.Background.Text())
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,535 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/xiaowumin-mark/AMLX/logx"
+	"github.com/xiaowumin-mark/AMLX/model"
+	"github.com/xiaowumin-mark/AMLX/store"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrSongNotFound    = errors.New("song not found")
+	ErrExternalIDTaken = errors.New("external id belongs to another song")
+	ErrUnknownPlatform = errors.New("unknown platform")
+)
+
+const maxExternalIDLength = 100
+
+// 歌曲在外部平台上的 ID
+type ExternalID struct {
+	Platform string `json:"platform"` // ncm / qq / spotify / apple / isrc
+	ID       string `json:"id"`
+}
+
+type SongArtistInput struct {
+	Name string
+	Role model.ArtistRole // 为空时为 PRIMARY
+}
+
+type CreateSongRequest struct {
+	Title       string
+	Album       string
+	Language    string
+	Artists     []SongArtistInput
+	ExternalIDs []ExternalID
+}
+
+type UpdateSongRequest struct {
+	Title       *string
+	Album       *string
+	Language    *string
+	Artists     *[]SongArtistInput
+	ExternalIDs *[]ExternalID // 整体替换
+}
+
+type SongArtistInfo struct {
+	ArtistID uint             `json:"artist_id"`
+	Name     string           `json:"name"`
+	Role     model.ArtistRole `json:"role"`
+}
+
+// 歌曲及其专辑、艺术家与平台 ID
+type SongDetail struct {
+	Song        *model.Song
+	Album       *model.Album
+	Artists     []SongArtistInfo
+	ExternalIDs []ExternalID
+}
+
+// 演唱者（PRIMARY 与 FEATURED）的名称
+func (d *SongDetail) Performers() []string {
+	var names []string
+	for _, artist := range d.Artists {
+		if artist.Role == model.ArtistPrimary || artist.Role == model.ArtistFeatured {
+			names = append(names, artist.Name)
+		}
+	}
+	return names
+}
+
+type SongService interface {
+	Create(ctx context.Context, req CreateSongRequest) (*SongDetail, error)                          // 创建歌曲
+	Update(ctx context.Context, id uint, req UpdateSongRequest) (*SongDetail, error)                 // 更新歌曲
+	Get(ctx context.Context, id uint) (*SongDetail, error)                                           // 获取歌曲
+	List(ctx context.Context, query string, page, pageSize int) ([]SongDetail, int64, error)         // 列出歌曲
+	Lookup(ctx context.Context, platform, externalID string) (*SongDetail, error)                    // 按平台 ID 查找
+	ListDrafts(ctx context.Context, id uint, page, pageSize int) ([]model.LyricsDraft, int64, error) // 引用该歌曲的已发布稿件
+	LinkPublished(ctx context.Context, draft *model.LyricsDraft) error                               // 发布钩子：为稿件关联歌曲并登记快照中的平台 ID
+	LinkAll(ctx context.Context) (int, error)                                                        // 为全部未关联歌曲的已发布稿件关联歌曲
+}
+
+type songService struct {
+	tx       store.Transactor
+	songs    store.SongStore
+	artists  store.ArtistStore
+	albums   store.AlbumStore
+	drafts   store.DraftStore
+	versions store.VersionStore
+}
+
+func NewSongService(tx store.Transactor, songs store.SongStore, artists store.ArtistStore, albums store.AlbumStore, drafts store.DraftStore, versions store.VersionStore) SongService {
+	return &songService{tx: tx, songs: songs, artists: artists, albums: albums, drafts: drafts, versions: versions}
+}
+
+func (s *songService) Create(ctx context.Context, req CreateSongRequest) (*SongDetail, error) {
+	title := strings.TrimSpace(req.Title)
+	if title == "" {
+		return nil, ErrInvalidInput
+	}
+	artists, err := normalizeSongArtists(req.Artists)
+	if err != nil {
+		return nil, err
+	}
+	ids, err := normalizeExternalIDs(req.ExternalIDs)
+	if err != nil {
+		return nil, err
+	}
+	song := &model.Song{Title: title, Language: strings.TrimSpace(req.Language)}
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.songs.Create(ctx, song); err != nil {
+			return err
+		}
+		if err := s.saveArtists(ctx, song, artists, strings.TrimSpace(req.Album)); err != nil {
+			return err
+		}
+		return s.replaceExternalIDs(ctx, song.ID, ids)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.Get(ctx, song.ID)
+}
+
+func (s *songService) Update(ctx context.Context, id uint, req UpdateSongRequest) (*SongDetail, error) {
+	if req.Title == nil && req.Album == nil && req.Language == nil && req.Artists == nil && req.ExternalIDs == nil {
+		return nil, ErrInvalidInput
+	}
+	var ids []ExternalID
+	if req.ExternalIDs != nil {
+		var err error
+		if ids, err = normalizeExternalIDs(*req.ExternalIDs); err != nil {
+			return nil, err
+		}
+	}
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		detail, err := s.Get(ctx, id)
+		if err != nil {
+			return err
+		}
+		song := detail.Song
+		if req.Title != nil {
+			if song.Title = strings.TrimSpace(*req.Title); song.Title == "" {
+				return ErrInvalidInput
+			}
+		}
+		if req.Language != nil {
+			song.Language = strings.TrimSpace(*req.Language)
+		}
+		if req.Artists != nil || req.Album != nil {
+			artists := make([]SongArtistInput, 0, len(detail.Artists))
+			for _, artist := range detail.Artists {
+				artists = append(artists, SongArtistInput{Name: artist.Name, Role: artist.Role})
+			}
+			if req.Artists != nil {
+				if artists, err = normalizeSongArtists(*req.Artists); err != nil {
+					return err
+				}
+			}
+			album := ""
+			if detail.Album != nil {
+				album = detail.Album.Title
+			}
+			if req.Album != nil {
+				album = strings.TrimSpace(*req.Album)
+			}
+			if err := s.saveArtists(ctx, song, artists, album); err != nil {
+				return err
+			}
+		} else {
+			song.NormalizedKey = songKey(song.Title, detail.Performers())
+			if err := s.songs.Update(ctx, song); err != nil {
+				return err
+			}
+		}
+		if req.ExternalIDs != nil {
+			return s.replaceExternalIDs(ctx, song.ID, ids)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.Get(ctx, id)
+}
+
+func (s *songService) Get(ctx context.Context, id uint) (*SongDetail, error) {
+	song, err := s.songs.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrSongNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.detail(ctx, song)
+}
+
+func (s *songService) List(ctx context.Context, query string, page, pageSize int) ([]SongDetail, int64, error) {
+	offset, limit := pagination(page, pageSize)
+	songs, total, err := s.songs.List(ctx, store.SongFilter{
+		Query:  strings.TrimSpace(query),
+		Offset: offset,
+		Limit:  limit,
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	details := make([]SongDetail, 0, len(songs))
+	for i := range songs {
+		detail, err := s.detail(ctx, &songs[i])
+		if err != nil {
+			return nil, 0, err
+		}
+		details = append(details, *detail)
+	}
+	return details, total, nil
+}
+
+func (s *songService) Lookup(ctx context.Context, platform, externalID string) (*SongDetail, error) {
+	ref, err := normalizeExternalID(ExternalID{Platform: platform, ID: externalID})
+	if err != nil {
+		return nil, err
+	}
+	row, err := s.songs.GetByExternalID(ctx, ref.Platform, ref.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrSongNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.Get(ctx, row.SongID)
+}
+
+func (s *songService) ListDrafts(ctx context.Context, id uint, page, pageSize int) ([]model.LyricsDraft, int64, error) {
+	if _, err := s.Get(ctx, id); err != nil {
+		return nil, 0, err
+	}
+	offset, limit := pagination(page, pageSize)
+	return s.drafts.List(ctx, store.DraftFilter{SongID: id, Status: model.DraftReviewDone, Offset: offset, Limit: limit})
+}
+
+// 依次按稿件已关联的歌曲、快照中的平台 ID、标题与演唱者查找歌曲，都找不到时创建。
+// 平台 ID 已属于其他歌曲时跳过，不影响发布
+func (s *songService) LinkPublished(ctx context.Context, draft *model.LyricsDraft) error {
+	if !isPublished(draft) {
+		return nil
+	}
+	doc, err := snapshotDocument(ctx, s.versions, draft)
+	if err != nil {
+		return err
+	}
+	var ids []ExternalID
+	for _, ref := range songRefs(doc) {
+		if id, err := normalizeExternalID(ExternalID{Platform: ref.Platform, ID: ref.ID}); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		song, err := s.resolve(ctx, draft, ids)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			if err := s.addExternalID(ctx, song.ID, id); errors.Is(err, ErrExternalIDTaken) {
+				logx.L().Warn("external id already linked", "draft_id", draft.ID, "song_id", song.ID, "error", err)
+			} else if err != nil {
+				return err
+			}
+		}
+		if draft.SongID != nil && *draft.SongID == song.ID {
+			return nil
+		}
+		draft.SongID = &song.ID
+		return s.drafts.UpdateSong(ctx, draft)
+	})
+}
+
+func (s *songService) LinkAll(ctx context.Context) (int, error) {
+	count := 0
+	for offset := 0; ; offset += rebuildBatchSize {
+		drafts, _, err := s.drafts.List(ctx, store.DraftFilter{
+			Status: model.DraftReviewDone,
+			Offset: offset,
+			Limit:  rebuildBatchSize,
+		})
+		if err != nil {
+			return count, err
+		}
+		for i := range drafts {
+			if drafts[i].SongID != nil || !isPublished(&drafts[i]) {
+				continue
+			}
+			if err := s.LinkPublished(ctx, &drafts[i]); err != nil {
+				return count, err
+			}
+			count++
+		}
+		if len(drafts) < rebuildBatchSize {
+			return count, nil
+		}
+	}
+}
+
+func (s *songService) resolve(ctx context.Context, draft *model.LyricsDraft, ids []ExternalID) (*model.Song, error) {
+	if draft.SongID != nil {
+		song, err := s.songs.GetByID(ctx, *draft.SongID)
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return song, err
+		}
+	}
+	for _, id := range ids {
+		row, err := s.songs.GetByExternalID(ctx, id.Platform, id.ID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return s.songs.GetByID(ctx, row.SongID)
+	}
+	artists := DecodeArtists(draft.Artists)
+	song, err := s.songs.GetByKey(ctx, songKey(draft.Title, artists))
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return song, err
+	}
+
+	inputs := make([]SongArtistInput, 0, len(artists))
+	for _, name := range artists {
+		inputs = append(inputs, SongArtistInput{Name: name, Role: model.ArtistPrimary})
+	}
+	normalized, err := normalizeSongArtists(inputs)
+	if err != nil {
+		return nil, err
+	}
+	song = &model.Song{Title: draft.Title, Language: draft.Language}
+	if err := s.songs.Create(ctx, song); err != nil {
+		return nil, err
+	}
+	return song, s.saveArtists(ctx, song, normalized, draft.Album)
+}
+
+// 保存艺术家与专辑，并按演唱者更新查重键
+func (s *songService) saveArtists(ctx context.Context, song *model.Song, artists []SongArtistInput, album string) error {
+	rows := make([]model.SongArtist, 0, len(artists))
+	positions := map[model.ArtistRole]uint{}
+	var performers []string
+	var albumArtist uint
+	for _, input := range artists {
+		artist, err := s.artists.FindOrCreate(ctx, input.Name)
+		if err != nil {
+			return err
+		}
+		if slices.ContainsFunc(rows, func(row model.SongArtist) bool { return row.ArtistID == artist.ID && row.Role == input.Role }) {
+			continue
+		}
+		rows = append(rows, model.SongArtist{SongID: song.ID, ArtistID: artist.ID, Role: input.Role, Position: positions[input.Role]})
+		positions[input.Role]++
+		if input.Role == model.ArtistPrimary || input.Role == model.ArtistFeatured {
+			performers = append(performers, artist.Name)
+		}
+		if input.Role == model.ArtistPrimary && albumArtist == 0 {
+			albumArtist = artist.ID
+		}
+	}
+	if err := s.songs.ReplaceArtists(ctx, song.ID, rows); err != nil {
+		return err
+	}
+
+	song.AlbumID = nil
+	if album != "" {
+		record, err := s.albums.FindOrCreate(ctx, album, albumArtist)
+		if err != nil {
+			return err
+		}
+		song.AlbumID = &record.ID
+	}
+	song.NormalizedKey = songKey(song.Title, performers)
+	return s.songs.Update(ctx, song)
+}
+
+func (s *songService) replaceExternalIDs(ctx context.Context, songID uint, ids []ExternalID) error {
+	if err := s.songs.DeleteExternalIDs(ctx, songID); err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := s.addExternalID(ctx, songID, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 已属于该歌曲时不报错
+func (s *songService) addExternalID(ctx context.Context, songID uint, id ExternalID) error {
+	row, err := s.songs.GetByExternalID(ctx, id.Platform, id.ID)
+	switch {
+	case err == nil && row.SongID == songID:
+		return nil
+	case err == nil:
+		return fmt.Errorf("%w: %s %s (song #%d)", ErrExternalIDTaken, id.Platform, id.ID, row.SongID)
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return err
+	}
+	return s.songs.AddExternalID(ctx, &model.SongExternalID{SongID: songID, Platform: id.Platform, ExternalID: id.ID})
+}
+
+func (s *songService) detail(ctx context.Context, song *model.Song) (*SongDetail, error) {
+	detail := &SongDetail{Song: song, Artists: []SongArtistInfo{}, ExternalIDs: []ExternalID{}}
+	if song.AlbumID != nil {
+		album, err := s.albums.GetByID(ctx, *song.AlbumID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		if err == nil {
+			detail.Album = album
+		}
+	}
+
+	links, err := s.songs.ListArtists(ctx, song.ID)
+	if err != nil {
+		return nil, err
+	}
+	artistIDs := make([]uint, 0, len(links))
+	for _, link := range links {
+		artistIDs = append(artistIDs, link.ArtistID)
+	}
+	artists, err := s.artists.ListByIDs(ctx, artistIDs)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[uint]string, len(artists))
+	for _, artist := range artists {
+		names[artist.ID] = artist.Name
+	}
+	for _, link := range links {
+		detail.Artists = append(detail.Artists, SongArtistInfo{ArtistID: link.ArtistID, Name: names[link.ArtistID], Role: link.Role})
+	}
+
+	ids, err := s.songs.ListExternalIDs(ctx, song.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		detail.ExternalIDs = append(detail.ExternalIDs, ExternalID{Platform: id.Platform, ID: id.ExternalID})
+	}
+	return detail, nil
+}
+
+func normalizeSongArtists(inputs []SongArtistInput) ([]SongArtistInput, error) {
+	artists := make([]SongArtistInput, 0, len(inputs))
+	for _, input := range inputs {
+		name := strings.TrimSpace(input.Name)
+		if name == "" {
+			continue
+		}
+		role := model.ArtistRole(strings.ToUpper(strings.TrimSpace(string(input.Role))))
+		switch role {
+		case "":
+			role = model.ArtistPrimary
+		case model.ArtistPrimary, model.ArtistFeatured, model.ArtistComposer, model.ArtistLyricist:
+		default:
+			return nil, ErrInvalidInput
+		}
+		artists = append(artists, SongArtistInput{Name: name, Role: role})
+	}
+	return artists, nil
+}
+
+func normalizeExternalIDs(ids []ExternalID) ([]ExternalID, error) {
+	result := make([]ExternalID, 0, len(ids))
+	for _, id := range ids {
+		normalized, err := normalizeExternalID(id)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(result, normalized) {
+			result = append(result, normalized)
+		}
+	}
+	return result, nil
+}
+
+// 平台小写；ISRC 大写并去掉连字符
+func normalizeExternalID(id ExternalID) (ExternalID, error) {
+	platform := strings.ToLower(strings.TrimSpace(id.Platform))
+	value := strings.TrimSpace(id.ID)
+	switch platform {
+	case model.PlatformNCM, model.PlatformQQ, model.PlatformSpotify, model.PlatformApple:
+	case model.PlatformISRC:
+		value = strings.ToUpper(strings.ReplaceAll(value, "-", ""))
+	default:
+		return ExternalID{}, ErrUnknownPlatform
+	}
+	if value == "" || len(value) > maxExternalIDLength {
+		return ExternalID{}, ErrInvalidInput
+	}
+	return ExternalID{Platform: platform, ID: value}, nil
+}
+
+// 查重用的键：标题与艺术家归一化后（忽略大小写、全半角、空白与标点，艺术家不计顺序）取哈希
+func songKey(title string, artists []string) string {
+	names := make([]string, 0, len(artists))
+	for _, artist := range artists {
+		if name := normalizeSongText(artist); name != "" {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	names = slices.Compact(names)
+	sum := sha256.Sum256([]byte(normalizeSongText(title) + "\x00" + strings.Join(names, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+func normalizeSongText(value string) string {
+	var b strings.Builder
+	for _, r := range value {
+		switch {
+		case r >= 0xFF01 && r <= 0xFF5E: // 全角 ASCII
+			r -= 0xFEE0
+		case r == 0x3000:
+			continue
+		}
+		if unicode.IsLetter(r) || unicode.IsNumber(r) {
+			b.WriteRune(unicode.ToLower(r))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,319 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/xiaowumin-mark/AMLX/lyrics"
+	"github.com/xiaowumin-mark/AMLX/lyrics/ttml"
+	"github.com/xiaowumin-mark/AMLX/model"
+	"github.com/xiaowumin-mark/AMLX/store"
+	"gorm.io/gorm"
+)
+
+// 统计时间窗口（天数，0 为全部）
+var statsWindows = map[string]int{
+	"7d":   7,
+	"30d":  30,
+	"90d":  90,
+	"365d": 365,
+	"all":  0,
+}
+
+// 可用于排行的指标
+var leaderboardMetrics = []string{"submitted", "approved", "rejected", "lines", "syllables", "reviews"}
+
+const (
+	defaultLeaderboardSize = 20
+	maxLeaderboardSize     = 100
+	statsRebuildBatchSize  = 500
+)
+
+// 用户在一个时间窗口内的贡献统计
+type ContributorStats struct {
+	UserID           uint    `json:"user_id"`
+	Name             string  `json:"name"`
+	Submitted        uint    `json:"submitted"`
+	Approved         uint    `json:"approved"`
+	Rejected         uint    `json:"rejected"`
+	AvgRejections    float64 `json:"avg_rejections"` // 通过的稿件平均被驳回次数
+	Lines            uint    `json:"lines"`
+	Syllables        uint    `json:"syllables"`
+	Reviews          uint    `json:"reviews"`
+	AvgReviewSeconds float64 `json:"avg_review_seconds"` // 作为审核者，从提交到审核的平均等待时间
+}
+
+type LeaderboardRequest struct {
+	Window string
+	Metric string
+	Limit  int
+}
+
+type StatsService interface {
+	RecordTransition(ctx context.Context, draft *model.LyricsDraft, transition *model.DraftTransition) error // 流转回调，累加统计
+	User(ctx context.Context, userID uint, window string) (*ContributorStats, error)                         // 用户统计
+	Leaderboard(ctx context.Context, req LeaderboardRequest) ([]ContributorStats, error)                     // 排行榜
+	Rebuild(ctx context.Context) (int, error)                                                                // 从流转历史重建统计，返回处理的流转数
+}
+
+type statsService struct {
+	tx          store.Transactor
+	stats       store.ContributorStatStore
+	users       store.UserStore
+	drafts      store.DraftStore
+	transitions store.DraftTransitionStore
+	versions    store.VersionStore
+}
+
+func NewStatsService(tx store.Transactor, stats store.ContributorStatStore, users store.UserStore, drafts store.DraftStore, transitions store.DraftTransitionStore, versions store.VersionStore) StatsService {
+	return &statsService{tx: tx, stats: stats, users: users, drafts: drafts, transitions: transitions, versions: versions}
+}
+
+// 提交计入投稿者；通过与驳回计入投稿者，同时计入审核者的审核次数与等待时间
+func (s *statsService) RecordTransition(ctx context.Context, draft *model.LyricsDraft, transition *model.DraftTransition) error {
+	switch transition.Action {
+	case model.ActionSubmit:
+		return s.add(ctx, transition.CreatedAt, &model.ContributorStat{UserID: draft.OwnerUserID, Submitted: 1})
+	case model.ActionApprove, model.ActionReject:
+	default:
+		return nil
+	}
+	if draft.ReviewSnapshotID == nil {
+		return ErrSnapshotNotFound
+	}
+	snapshot, err := s.versions.GetByID(ctx, *draft.ReviewSnapshotID)
+	if err != nil {
+		return err
+	}
+	return s.recordReview(ctx, draft, transition, snapshot.CreatedAt, snapshot.Content, draft.RejectCount)
+}
+
+// rejects 为通过时稿件累计被驳回的次数
+func (s *statsService) recordReview(ctx context.Context, draft *model.LyricsDraft, transition *model.DraftTransition, submittedAt time.Time, content string, rejects uint) error {
+	owner := &model.ContributorStat{UserID: draft.OwnerUserID}
+	if transition.Action == model.ActionApprove {
+		owner.Approved = 1
+		owner.ApprovedRejects = rejects
+		owner.Lines, owner.Syllables = countTimed(content)
+	} else {
+		owner.Rejected = 1
+	}
+	if err := s.add(ctx, transition.CreatedAt, owner); err != nil {
+		return err
+	}
+	reviewer := &model.ContributorStat{UserID: transition.ActorUserID, Reviews: 1}
+	if wait := transition.CreatedAt.Sub(submittedAt); !submittedAt.IsZero() && wait > 0 {
+		reviewer.ReviewSeconds = uint64(wait / time.Second)
+	}
+	return s.add(ctx, transition.CreatedAt, reviewer)
+}
+
+func (s *statsService) add(ctx context.Context, at time.Time, stat *model.ContributorStat) error {
+	if at.IsZero() {
+		at = time.Now()
+	}
+	stat.Day = startOfDay(at)
+	return s.stats.Add(ctx, stat)
+}
+
+func (s *statsService) User(ctx context.Context, userID uint, window string) (*ContributorStats, error) {
+	since, err := windowStart(window)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.users.GetByID(ctx, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	sum, err := s.stats.Sum(ctx, userID, since)
+	if err != nil {
+		return nil, err
+	}
+	stats := toContributorStats(sum)
+	stats.Name = user.Name
+	return &stats, nil
+}
+
+func (s *statsService) Leaderboard(ctx context.Context, req LeaderboardRequest) ([]ContributorStats, error) {
+	since, err := windowStart(req.Window)
+	if err != nil {
+		return nil, err
+	}
+	metric := strings.ToLower(strings.TrimSpace(req.Metric))
+	if metric == "" {
+		metric = "approved"
+	}
+	if !slices.Contains(leaderboardMetrics, metric) {
+		return nil, ErrInvalidInput
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultLeaderboardSize
+	}
+	limit = min(limit, maxLeaderboardSize)
+
+	sums, err := s.stats.Top(ctx, metric, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint, 0, len(sums))
+	for i := range sums {
+		ids = append(ids, sums[i].UserID)
+	}
+	users, err := s.users.ListByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[uint]string, len(users))
+	for _, user := range users {
+		names[user.ID] = user.Name
+	}
+	result := make([]ContributorStats, 0, len(sums))
+	for i := range sums {
+		stats := toContributorStats(&sums[i])
+		stats.Name = names[stats.UserID]
+		result = append(result, stats)
+	}
+	return result, nil
+}
+
+// 按流转历史的顺序重放提交、通过与驳回。驳回时的快照已被替换，等待时间取此前最近一次提交的时间
+func (s *statsService) Rebuild(ctx context.Context) (int, error) {
+	actions := []model.TransitionAction{model.ActionSubmit, model.ActionApprove, model.ActionReject}
+	count := 0
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.stats.Reset(ctx); err != nil {
+			return err
+		}
+		drafts := map[uint]*model.LyricsDraft{}
+		submitted := map[uint]time.Time{}
+		rejects := map[uint]uint{}
+		for after := uint(0); ; {
+			batch, err := s.transitions.ListAfter(ctx, after, actions, statsRebuildBatchSize)
+			if err != nil {
+				return err
+			}
+			if len(batch) == 0 {
+				return nil
+			}
+			for i := range batch {
+				transition := &batch[i]
+				after = transition.ID
+				draft, ok := drafts[transition.DraftID]
+				if !ok {
+					draft, err = s.drafts.GetByID(ctx, transition.DraftID)
+					if errors.Is(err, gorm.ErrRecordNotFound) {
+						continue // 稿件已删除
+					}
+					if err != nil {
+						return err
+					}
+					drafts[transition.DraftID] = draft
+				}
+				if err := s.replay(ctx, draft, transition, submitted, rejects); err != nil {
+					return err
+				}
+				count++
+			}
+		}
+	})
+	return count, err
+}
+
+func (s *statsService) replay(ctx context.Context, draft *model.LyricsDraft, transition *model.DraftTransition, submitted map[uint]time.Time, rejects map[uint]uint) error {
+	switch transition.Action {
+	case model.ActionSubmit:
+		submitted[draft.ID] = transition.CreatedAt
+		return s.add(ctx, transition.CreatedAt, &model.ContributorStat{UserID: draft.OwnerUserID, Submitted: 1})
+	case model.ActionReject:
+		rejects[draft.ID]++
+		return s.recordReview(ctx, draft, transition, submitted[draft.ID], "", 0)
+	}
+	// 只有最终通过的快照仍然保留
+	content := ""
+	if draft.ReviewSnapshotID != nil {
+		snapshot, err := s.versions.GetByID(ctx, *draft.ReviewSnapshotID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err == nil {
+			content = snapshot.Content
+		}
+	}
+	return s.recordReview(ctx, draft, transition, submitted[draft.ID], content, rejects[draft.ID])
+}
+
+// 有时间轴的行数与音节数，背景人声单独计行；无法解析时均为 0
+func countTimed(content string) (uint, uint) {
+	if content == "" {
+		return 0, 0
+	}
+	doc, err := ttml.ParseString(content)
+	if err != nil {
+		return 0, 0
+	}
+	var lines, syllables uint
+	count := func(line *lyrics.Line) {
+		if line.End > line.Begin {
+			lines++
+		}
+		for _, syllable := range line.Syllables {
+			if syllable.End > syllable.Begin {
+				syllables++
+			}
+		}
+	}
+	for i := range doc.Lines {
+		count(&doc.Lines[i])
+		if doc.Lines[i].Background != nil {
+			count(doc.Lines[i].Background)
+		}
+	}
+	return lines, syllables
+}
+
+// 窗口起始日，全部时为 nil
+func windowStart(window string) (*time.Time, error) {
+	window = strings.ToLower(strings.TrimSpace(window))
+	if window == "" {
+		window = "30d"
+	}
+	days, ok := statsWindows[window]
+	if !ok {
+		return nil, ErrInvalidInput
+	}
+	if days == 0 {
+		return nil, nil
+	}
+	since := startOfDay(time.Now()).AddDate(0, 0, 1-days)
+	return &since, nil
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func toContributorStats(sum *model.ContributorStat) ContributorStats {
+	stats := ContributorStats{
+		UserID:    sum.UserID,
+		Submitted: sum.Submitted,
+		Approved:  sum.Approved,
+		Rejected:  sum.Rejected,
+		Lines:     sum.Lines,
+		Syllables: sum.Syllables,
+		Reviews:   sum.Reviews,
+	}
+	if sum.Approved > 0 {
+		stats.AvgRejections = float64(sum.ApprovedRejects) / float64(sum.Approved)
+	}
+	if sum.Reviews > 0 {
+		stats.AvgReviewSeconds = float64(sum.ReviewSeconds) / float64(sum.Reviews)
+	}
+	return stats
+}
@@ -0,0 +1,406 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/xiaowumin-mark/AMLX/config"
+	"github.com/xiaowumin-mark/AMLX/lyrics"
+	"github.com/xiaowumin-mark/AMLX/lyrics/ttml"
+	"github.com/xiaowumin-mark/AMLX/model"
+	"github.com/xiaowumin-mark/AMLX/store"
+	"github.com/xiaowumin-mark/AMLX/ttmldb"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrSyncDisabled       = errors.New("sync is not configured")
+	ErrSyncBranchNotFound = errors.New("sync base branch not found")
+)
+
+// 用于判断是否为同一首歌的平台 ID 元数据
+var songIDMetadata = []string{lyrics.MetaNCMMusicID, lyrics.MetaQQMusicID, lyrics.MetaSpotifyID, lyrics.MetaAppleMusicID}
+
+type SyncItem struct {
+	Path    string `json:"path"`
+	DraftID uint   `json:"draft_id,omitempty"`
+	Title   string `json:"title,omitempty"`
+	Commit  string `json:"commit,omitempty"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// 同步结果。DryRun 时 Synced 为将要导入或导出的条目，不写入任何数据
+type SyncReport struct {
+	DryRun    bool       `json:"dry_run"`
+	Commit    string     `json:"commit"` // 导入读取的提交，或导出后分支所在的提交
+	Synced    []SyncItem `json:"synced"`
+	Skipped   []SyncItem `json:"skipped"`   // 无需处理，如被同一首歌的新文件取代
+	Conflicts []SyncItem `json:"conflicts"` // 需要人工处理
+	Unchanged int        `json:"unchanged"` // 已同步且未变化
+}
+
+type SyncService interface {
+	Import(ctx context.Context, dryRun bool) (*SyncReport, error) // 从仓库导入已发布稿件
+	Export(ctx context.Context, dryRun bool) (*SyncReport, error) // 把审核通过的稿件提交到仓库分支
+}
+
+type syncService struct {
+	cfg         config.SyncConfig
+	repo        *ttmldb.Repo
+	tx          store.Transactor
+	drafts      store.DraftStore
+	versions    store.VersionStore
+	transitions store.DraftTransitionStore
+	records     store.SyncRecordStore
+	hooks       []PublishHook
+}
+
+func NewSyncService(cfg config.SyncConfig, tx store.Transactor, drafts store.DraftStore, versions store.VersionStore, transitions store.DraftTransitionStore, records store.SyncRecordStore, hooks ...PublishHook) (SyncService, error) {
+	s := &syncService{cfg: cfg, tx: tx, drafts: drafts, versions: versions, transitions: transitions, records: records, hooks: hooks}
+	if cfg.RepoPath == "" {
+		return s, nil
+	}
+	repo, err := ttmldb.Open(cfg.RepoPath, cfg.AuthorName, cfg.AuthorEmail)
+	if err != nil {
+		return nil, err
+	}
+	s.repo = repo
+	return s, nil
+}
+
+// 从基础分支的 raw-lyrics 导入：每首歌只取最新的文件，已导入过且未变化的跳过
+func (s *syncService) Import(ctx context.Context, dryRun bool) (*SyncReport, error) {
+	if s.repo == nil {
+		return nil, ErrSyncDisabled
+	}
+	commit, err := s.repo.Resolve(s.cfg.BaseBranch)
+	if err != nil {
+		return nil, err
+	}
+	if commit == "" {
+		return nil, fmt.Errorf("%w: %s", ErrSyncBranchNotFound, s.cfg.BaseBranch)
+	}
+	files, err := s.repo.RawFiles(commit)
+	if err != nil {
+		return nil, err
+	}
+	records, err := s.records.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byPath := map[string]model.LyricsSyncRecord{}
+	for _, record := range records {
+		byPath[record.Path] = record
+	}
+	published, err := s.publishedSongs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := newSyncReport(dryRun, commit)
+	seen := map[string]string{} // 本次已处理的歌曲 → 文件
+	// 文件名以时间戳开头，倒序即从新到旧
+	slices.SortFunc(files, func(a, b ttmldb.File) int { return strings.Compare(b.Path, a.Path) })
+	for _, file := range files {
+		hash := ttmldb.Hash(file.Content)
+		doc, err := ttml.Parse(file.Content)
+		if err != nil {
+			report.conflict(SyncItem{Path: file.Path, Reason: "invalid TTML: " + err.Error()})
+			continue
+		}
+		keys := songKeys(doc)
+		if newer := firstMatch(seen, keys); newer != "" {
+			report.Skipped = append(report.Skipped, SyncItem{Path: file.Path, Reason: "superseded by " + newer})
+			continue
+		}
+		for _, key := range keys {
+			seen[key] = file.Path
+		}
+
+		if record, ok := byPath[file.Path]; ok {
+			if record.ContentHash == hash {
+				report.Unchanged++
+			} else {
+				report.conflict(SyncItem{Path: file.Path, DraftID: record.DraftID, Reason: "file changed in repository since last sync"})
+			}
+			continue
+		}
+		item := SyncItem{Path: file.Path, Title: doc.MetaValue(lyrics.MetaMusicName), Commit: commit}
+		if item.Title == "" {
+			item.Reason = "missing musicName metadata"
+			report.conflict(item)
+			continue
+		}
+		if draftID := firstMatch(published, keys); draftID != 0 {
+			item.DraftID = draftID
+			item.Reason = fmt.Sprintf("song already published as draft #%d", draftID)
+			report.conflict(item)
+			continue
+		}
+
+		if !dryRun {
+			draft, err := s.importFile(ctx, file, doc, hash, commit)
+			if err != nil {
+				return nil, err
+			}
+			item.DraftID = draft.ID
+			runPublishHooks(ctx, s.hooks, draft)
+		}
+		report.Synced = append(report.Synced, item)
+	}
+	return report, nil
+}
+
+// 把未同步过的已发布稿件逐个提交到导出分支，分支不存在时从基础分支创建
+func (s *syncService) Export(ctx context.Context, dryRun bool) (*SyncReport, error) {
+	if s.repo == nil {
+		return nil, ErrSyncDisabled
+	}
+	parent, err := s.repo.Resolve(s.cfg.Branch)
+	if err != nil {
+		return nil, err
+	}
+	if parent == "" {
+		if parent, err = s.repo.Resolve(s.cfg.BaseBranch); err != nil {
+			return nil, err
+		}
+		if parent == "" {
+			return nil, fmt.Errorf("%w: %s", ErrSyncBranchNotFound, s.cfg.BaseBranch)
+		}
+	}
+	files, err := s.repo.RawFiles(parent)
+	if err != nil {
+		return nil, err
+	}
+	existing := map[string]bool{}
+	inRepo := map[string]string{} // 歌曲 → 仓库文件
+	for _, file := range files {
+		existing[file.Path] = true
+		if doc, err := ttml.Parse(file.Content); err == nil {
+			for _, key := range songKeys(doc) {
+				inRepo[key] = file.Path
+			}
+		}
+	}
+	records, err := s.records.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	synced := map[uint]bool{}
+	for _, record := range records {
+		synced[record.DraftID] = true
+	}
+	drafts, err := s.publishedDrafts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := newSyncReport(dryRun, parent)
+	for i := range drafts {
+		draft := &drafts[i]
+		if synced[draft.ID] {
+			report.Unchanged++
+			continue
+		}
+		item := SyncItem{DraftID: draft.ID, Title: draft.Title}
+		doc, err := snapshotDocument(ctx, s.versions, draft)
+		if err != nil {
+			item.Reason = err.Error()
+			report.conflict(item)
+			continue
+		}
+		content := ttml.Marshal(doc)
+		item.Path = ttmldb.RawPath(draft.UpdatedAt, s.cfg.ContributorID, content)
+		keys := songKeys(doc)
+		if path := firstMatch(inRepo, keys); path != "" {
+			item.Reason = "song already in repository as " + path
+			report.conflict(item)
+			continue
+		}
+		if existing[item.Path] {
+			item.Reason = "file already exists"
+			report.conflict(item)
+			continue
+		}
+
+		if !dryRun {
+			message := fmt.Sprintf("Add lyrics: %s - %s\n\nAMLX draft #%d", draft.Title, strings.Join(DecodeArtists(draft.Artists), " / "), draft.ID)
+			commit, err := s.repo.Commit(s.cfg.Branch, parent, message, []ttmldb.Change{{Path: item.Path, Content: content}})
+			if err != nil {
+				return nil, err
+			}
+			parent = commit
+			item.Commit = commit
+			err = s.records.Create(ctx, &model.LyricsSyncRecord{
+				DraftID:     draft.ID,
+				Path:        item.Path,
+				ContentHash: ttmldb.Hash(content),
+				Commit:      commit,
+				Direction:   model.SyncExport,
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+		existing[item.Path] = true
+		for _, key := range keys {
+			inRepo[key] = item.Path
+		}
+		report.Synced = append(report.Synced, item)
+	}
+	report.Commit = parent
+	return report, nil
+}
+
+// 导入一个文件：创建已发布稿件、冻结版本、IMPORT 流转与同步记录
+func (s *syncService) importFile(ctx context.Context, file ttmldb.File, doc *lyrics.Document, hash, commit string) (*model.LyricsDraft, error) {
+	artists, err := EncodeArtists(doc.MetaValues(lyrics.MetaArtists))
+	if err != nil {
+		return nil, err
+	}
+	draft := &model.LyricsDraft{
+		Title:              doc.MetaValue(lyrics.MetaMusicName),
+		Artists:            artists,
+		Album:              doc.MetaValue(lyrics.MetaAlbum),
+		Language:           doc.Language,
+		OwnerUserID:        s.cfg.ImportUserID,
+		Status:             model.DraftReviewDone,
+		AllowStageRollback: true,
+		PublishTarget:      model.PublishTargetAMLX,
+	}
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.drafts.Create(ctx, draft); err != nil {
+			return err
+		}
+		snapshot := &model.LyricsVersion{
+			DraftID:       draft.ID,
+			WorkflowStage: model.StageCheck,
+			Content:       string(file.Content),
+			IsSnapshot:    true,
+			CreatedBy:     s.cfg.ImportUserID,
+		}
+		if err := s.versions.Create(ctx, snapshot); err != nil {
+			return err
+		}
+		draft.ReviewSnapshotID = &snapshot.ID
+		if err := s.drafts.Update(ctx, draft); err != nil {
+			return err
+		}
+		err := s.transitions.Create(ctx, &model.DraftTransition{
+			DraftID:     draft.ID,
+			Action:      model.ActionImport,
+			ToStatus:    draft.Status,
+			ActorUserID: s.cfg.ImportUserID,
+			Reason:      fmt.Sprintf("imported %s at %s", file.Path, commit),
+		})
+		if err != nil {
+			return err
+		}
+		return s.records.Create(ctx, &model.LyricsSyncRecord{
+			DraftID:     draft.ID,
+			Path:        file.Path,
+			ContentHash: hash,
+			Commit:      commit,
+			Direction:   model.SyncImport,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return draft, nil
+}
+
+// 已发布稿件中出现的歌曲 → 稿件 ID
+func (s *syncService) publishedSongs(ctx context.Context) (map[string]uint, error) {
+	drafts, err := s.publishedDrafts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	songs := map[string]uint{}
+	for i := range drafts {
+		doc, err := snapshotDocument(ctx, s.versions, &drafts[i])
+		if err != nil {
+			continue
+		}
+		for _, key := range songKeys(doc) {
+			songs[key] = drafts[i].ID
+		}
+	}
+	return songs, nil
+}
+
+// 全部已发布稿件，按 ID 升序
+func (s *syncService) publishedDrafts(ctx context.Context) ([]model.LyricsDraft, error) {
+	var result []model.LyricsDraft
+	for offset := 0; ; offset += rebuildBatchSize {
+		drafts, _, err := s.drafts.List(ctx, store.DraftFilter{
+			Status: model.DraftReviewDone,
+			Offset: offset,
+			Limit:  rebuildBatchSize,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for i := range drafts {
+			if isPublished(&drafts[i]) {
+				result = append(result, drafts[i])
+			}
+		}
+		if len(drafts) < rebuildBatchSize {
+			break
+		}
+	}
+	slices.SortFunc(result, func(a, b model.LyricsDraft) int { return int(a.ID) - int(b.ID) })
+	return result, nil
+}
+
+// 审核快照解析后的文档，缺失的元数据用稿件信息补齐
+func snapshotDocument(ctx context.Context, versions store.VersionStore, draft *model.LyricsDraft) (*lyrics.Document, error) {
+	version, err := versions.GetByID(ctx, *draft.ReviewSnapshotID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrSnapshotNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	doc, err := ttml.ParseString(version.Content)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidLyrics, err)
+	}
+	fillDocumentMeta(doc, draft)
+	return doc, nil
+}
+
+func newSyncReport(dryRun bool, commit string) *SyncReport {
+	return &SyncReport{DryRun: dryRun, Commit: commit, Synced: []SyncItem{}, Skipped: []SyncItem{}, Conflicts: []SyncItem{}}
+}
+
+func (r *SyncReport) conflict(item SyncItem) {
+	r.Conflicts = append(r.Conflicts, item)
+}
+
+// 歌曲标识：平台 ID 元数据，如 ncmMusicId:123
+func songKeys(doc *lyrics.Document) []string {
+	var keys []string
+	for _, key := range songIDMetadata {
+		for _, value := range doc.MetaValues(key) {
+			if value = strings.TrimSpace(value); value != "" {
+				keys = append(keys, key+":"+value)
+			}
+		}
+	}
+	return keys
+}
+
+func firstMatch[V comparable](m map[string]V, keys []string) V {
+	var zero V
+	for _, key := range keys {
+		if v, ok := m[key]; ok && v != zero {
+			return v
+		}
+	}
+	return zero
+}
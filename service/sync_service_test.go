@@ -0,0 +1,286 @@
+package service
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/xiaowumin-mark/AMLX/config"
+	"github.com/xiaowumin-mark/AMLX/lyrics"
+	"github.com/xiaowumin-mark/AMLX/lyrics/ttml"
+	"github.com/xiaowumin-mark/AMLX/model"
+	"github.com/xiaowumin-mark/AMLX/store/storetest"
+	"github.com/xiaowumin-mark/AMLX/ttmldb"
+)
+
+// 一行歌词的 TTML，title 为空时不写 musicName
+func syncTTML(title, ncmID string) []byte {
+	doc := &lyrics.Document{
+		Timing: lyrics.TimingLine,
+		Lines:  []lyrics.Line{{Begin: 1000, End: 3000, Syllables: []lyrics.Syllable{{Text: title + " lyric", Begin: 1000, End: 3000}}}},
+	}
+	if title != "" {
+		doc.SetMeta(lyrics.MetaMusicName, title)
+	}
+	doc.SetMeta(lyrics.MetaArtists, "Artist")
+	doc.SetMeta(lyrics.MetaNCMMusicID, ncmID)
+	return ttml.Marshal(doc)
+}
+
+// 写入一个已发布稿件及其审核快照
+func putPublished(t *testing.T, drafts *storetest.DraftStore, versions *storetest.VersionStore, draft model.LyricsDraft, id uint, content []byte) *model.LyricsDraft {
+	t.Helper()
+	snapshot := &model.LyricsVersion{DraftID: id, WorkflowStage: model.StageCheck, Content: string(content), IsSnapshot: true}
+	if err := versions.Create(context.Background(), snapshot); err != nil {
+		t.Fatal(err)
+	}
+	draft.ID = id
+	draft.Status = model.DraftReviewDone
+	draft.ReviewSnapshotID = &snapshot.ID
+	drafts.Put(draft)
+	return &draft
+}
+
+type syncFixture struct {
+	repo        *ttmldb.Repo
+	base        string
+	drafts      *storetest.DraftStore
+	versions    *storetest.VersionStore
+	transitions *storetest.TransitionStore
+	records     *storetest.SyncRecordStore
+	service     SyncService
+	hooked      []uint
+}
+
+// 在临时目录初始化仓库，main 分支上有：
+//   - 同一首歌（ncm 1）的新旧两个文件
+//   - 缺少 musicName 的文件（ncm 2）
+//   - 无法解析的文件
+//   - 已作为稿件 #1 发布的歌曲（ncm 3）
+func newSyncFixture(t *testing.T) *syncFixture {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	if out, err := exec.Command("git", "init", "--quiet", dir).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v: %s", err, out)
+	}
+	repo, err := ttmldb.Open(dir, "AMLX", "amlx@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	base, err := repo.Commit("main", "", "Initial", []ttmldb.Change{
+		{Path: "raw-lyrics/1000-7-old.ttml", Content: syncTTML("Song One (old)", "1")},
+		{Path: "raw-lyrics/2000-7-new.ttml", Content: syncTTML("Song One", "1")},
+		{Path: "raw-lyrics/3000-7-untitled.ttml", Content: syncTTML("", "2")},
+		{Path: "raw-lyrics/4000-7-broken.ttml", Content: []byte("<tt")},
+		{Path: "raw-lyrics/5000-7-published.ttml", Content: syncTTML("Song Three", "3")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := &syncFixture{
+		repo:        repo,
+		base:        base,
+		drafts:      storetest.NewDraftStore(),
+		versions:    storetest.NewVersionStore(),
+		transitions: &storetest.TransitionStore{},
+		records:     &storetest.SyncRecordStore{},
+	}
+	f.publish(t, 1, "Song Three", "3")
+
+	cfg := config.SyncConfig{
+		RepoPath:      dir,
+		BaseBranch:    "main",
+		Branch:        "amlx",
+		ImportUserID:  9,
+		ContributorID: "42",
+		AuthorName:    "AMLX",
+		AuthorEmail:   "amlx@example.com",
+	}
+	hook := func(ctx context.Context, draft *model.LyricsDraft) error {
+		f.hooked = append(f.hooked, draft.ID)
+		return nil
+	}
+	f.service, err = NewSyncService(cfg, storetest.Tx{}, f.drafts, f.versions, f.transitions, f.records, hook)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func (f *syncFixture) publish(t *testing.T, id uint, title, ncmID string) {
+	t.Helper()
+	putPublished(t, f.drafts, f.versions, model.LyricsDraft{Title: title, Artists: `["Artist"]`}, id, syncTTML(title, ncmID))
+}
+
+func itemPaths(items []SyncItem) []string {
+	paths := make([]string, len(items))
+	for i, item := range items {
+		paths[i] = item.Path
+	}
+	return paths
+}
+
+func TestSyncImport(t *testing.T) {
+	f := newSyncFixture(t)
+	ctx := context.Background()
+
+	dry, err := f.service.Import(ctx, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !dry.DryRun || dry.Commit != f.base {
+		t.Fatalf("dry run report: dry_run=%v commit=%s", dry.DryRun, dry.Commit)
+	}
+	if got := strings.Join(itemPaths(dry.Synced), ","); got != "raw-lyrics/2000-7-new.ttml" {
+		t.Fatalf("dry run synced %s", got)
+	}
+	if got := strings.Join(itemPaths(dry.Skipped), ","); got != "raw-lyrics/1000-7-old.ttml" {
+		t.Fatalf("dry run skipped %s", got)
+	}
+	wantConflicts := map[string]string{
+		"raw-lyrics/5000-7-published.ttml": "song already published as draft #1",
+		"raw-lyrics/4000-7-broken.ttml":    "invalid TTML",
+		"raw-lyrics/3000-7-untitled.ttml":  "missing musicName metadata",
+	}
+	if len(dry.Conflicts) != len(wantConflicts) {
+		t.Fatalf("dry run conflicts %+v", dry.Conflicts)
+	}
+	for _, item := range dry.Conflicts {
+		if want, ok := wantConflicts[item.Path]; !ok || !strings.HasPrefix(item.Reason, want) {
+			t.Errorf("conflict %s: %q, want %q", item.Path, item.Reason, want)
+		}
+	}
+	if f.drafts.Len() != 1 || f.records.Len() != 0 || len(f.hooked) != 0 {
+		t.Fatalf("dry run wrote data: %d drafts, %d records, %d hooks", f.drafts.Len(), f.records.Len(), len(f.hooked))
+	}
+
+	report, err := f.service.Import(ctx, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Synced) != 1 || report.Synced[0].DraftID == 0 {
+		t.Fatalf("import synced %+v", report.Synced)
+	}
+	draft, err := f.drafts.GetByID(ctx, report.Synced[0].DraftID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isPublished(draft) || draft.Title != "Song One" || draft.OwnerUserID != 9 {
+		t.Fatalf("imported draft %+v", draft)
+	}
+	snapshot, err := f.versions.GetByID(ctx, *draft.ReviewSnapshotID)
+	if err != nil || snapshot.Content != string(syncTTML("Song One", "1")) {
+		t.Fatalf("imported snapshot %+v, %v", snapshot, err)
+	}
+	if len(f.hooked) != 1 || f.hooked[0] != draft.ID {
+		t.Fatalf("publish hooks ran for %v", f.hooked)
+	}
+	transitions, err := f.transitions.ListByDraft(ctx, draft.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transitions) != 1 || transitions[0].Action != model.ActionImport || transitions[0].ToStatus != model.DraftReviewDone ||
+		transitions[0].ActorUserID != 9 || transitions[0].Reason != "imported raw-lyrics/2000-7-new.ttml at "+report.Commit {
+		t.Fatalf("import transitions %+v", transitions)
+	}
+
+	// 再次导入：已导入的文件未变化
+	again, err := f.service.Import(ctx, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(again.Synced) != 0 || again.Unchanged != 1 {
+		t.Fatalf("second import: synced %+v, unchanged %d", again.Synced, again.Unchanged)
+	}
+
+	// 仓库中的文件在上次同步后被修改
+	if _, err := f.repo.Commit("main", f.base, "Edit", []ttmldb.Change{
+		{Path: "raw-lyrics/2000-7-new.ttml", Content: syncTTML("Song One (edited)", "1")},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	edited, err := f.service.Import(ctx, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var changed *SyncItem
+	for i := range edited.Conflicts {
+		if edited.Conflicts[i].Path == "raw-lyrics/2000-7-new.ttml" {
+			changed = &edited.Conflicts[i]
+		}
+	}
+	if changed == nil || changed.DraftID != draft.ID || changed.Reason != "file changed in repository since last sync" {
+		t.Fatalf("edited file not reported as conflict: %+v", edited.Conflicts)
+	}
+}
+
+func TestSyncExport(t *testing.T) {
+	f := newSyncFixture(t)
+	ctx := context.Background()
+	f.publish(t, 2, "Song Four", "4")
+	f.publish(t, 3, "Song Five", "5")
+
+	dry, err := f.service.Export(ctx, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dry.Synced) != 2 || dry.Synced[0].DraftID != 2 || dry.Synced[1].DraftID != 3 {
+		t.Fatalf("dry run synced %+v", dry.Synced)
+	}
+	if len(dry.Conflicts) != 1 || dry.Conflicts[0].DraftID != 1 ||
+		dry.Conflicts[0].Reason != "song already in repository as raw-lyrics/5000-7-published.ttml" {
+		t.Fatalf("dry run conflicts %+v", dry.Conflicts)
+	}
+	if branch, _ := f.repo.Resolve("amlx"); branch != "" || f.records.Len() != 0 {
+		t.Fatalf("dry run wrote data: branch %q, %d records", branch, f.records.Len())
+	}
+
+	report, err := f.service.Export(ctx, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if branch, _ := f.repo.Resolve("amlx"); branch == "" || branch != report.Commit {
+		t.Fatalf("branch at %q, report commit %q", branch, report.Commit)
+	}
+	if len(report.Synced) != 2 || f.records.Len() != 2 {
+		t.Fatalf("export synced %+v, %d records", report.Synced, f.records.Len())
+	}
+	files, err := f.repo.RawFiles(report.Commit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exported := map[string]string{}
+	for _, file := range files {
+		exported[file.Path] = string(file.Content)
+	}
+	for i, item := range report.Synced {
+		if item.Path != dry.Synced[i].Path {
+			t.Errorf("export path %s differs from dry run", item.Path)
+		}
+		content, ok := exported[item.Path]
+		if !ok {
+			t.Fatalf("%s not in exported commit", item.Path)
+		}
+		doc, err := ttml.ParseString(content)
+		if err != nil || doc.MetaValue(lyrics.MetaMusicName) != item.Title {
+			t.Fatalf("exported %s: %v", item.Path, err)
+		}
+	}
+	// 基础分支不受影响
+	if head, _ := f.repo.Resolve("main"); head != f.base {
+		t.Fatalf("main moved to %s", head)
+	}
+
+	again, err := f.service.Export(ctx, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(again.Synced) != 0 || again.Unchanged != 2 || again.Commit != report.Commit {
+		t.Fatalf("second export: synced %+v, unchanged %d, commit %s", again.Synced, again.Unchanged, again.Commit)
+	}
+}
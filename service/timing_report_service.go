@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/xiaowumin-mark/AMLX/config"
+	"github.com/xiaowumin-mark/AMLX/logx"
+	"github.com/xiaowumin-mark/AMLX/model"
+	"github.com/xiaowumin-mark/AMLX/store"
+	"gorm.io/gorm"
+)
+
+const (
+	maxTimingOffsetMs      = 60000
+	maxTimingCommentLength = 500
+)
+
+type TimingReportRequest struct {
+	OffsetMs int  // 正数为歌词偏晚，负数为偏早
+	Line     uint // 从 1 开始，为 0 时反馈整体偏移
+	Comment  string
+}
+
+type TimingLineReports struct {
+	Line    uint `json:"line"`
+	Reports uint `json:"reports"`
+}
+
+// 版本的时间轴反馈汇总
+type TimingSummary struct {
+	VersionID     uint                `json:"version_id"`
+	DraftID       uint                `json:"draft_id"`
+	Reporters     uint                `json:"reporters"`
+	OffsetReports uint                `json:"offset_reports"`
+	AvgOffsetMs   float64             `json:"avg_offset_ms"`
+	LineReports   uint                `json:"line_reports"`
+	Lines         []TimingLineReports `json:"lines"`
+	Threshold     int                 `json:"threshold"`
+	FixDraftID    *uint               `json:"fix_draft_id"`
+}
+
+type TimingReportService interface {
+	Report(ctx context.Context, userID, versionID uint, req TimingReportRequest) (*TimingSummary, error) // 反馈已发布版本的时间轴问题，达到阈值时开启修正稿件
+	Summary(ctx context.Context, versionID uint) (*TimingSummary, error)                                 // 版本的反馈汇总
+}
+
+type timingReportService struct {
+	cfg      config.TimingConfig
+	tx       store.Transactor
+	reports  store.TimingReportStore
+	drafts   store.DraftStore
+	versions store.VersionStore
+	fork     DraftService
+}
+
+func NewTimingReportService(cfg config.TimingConfig, tx store.Transactor, reports store.TimingReportStore, drafts store.DraftStore, versions store.VersionStore, fork DraftService) TimingReportService {
+	return &timingReportService{cfg: cfg, tx: tx, reports: reports, drafts: drafts, versions: versions, fork: fork}
+}
+
+func (s *timingReportService) Report(ctx context.Context, userID, versionID uint, req TimingReportRequest) (*TimingSummary, error) {
+	if userID == 0 || req.OffsetMs < -maxTimingOffsetMs || req.OffsetMs > maxTimingOffsetMs {
+		return nil, ErrInvalidInput
+	}
+	comment := strings.TrimSpace(req.Comment)
+	if utf8.RuneCountInString(comment) > maxTimingCommentLength {
+		return nil, ErrInvalidInput
+	}
+	report := &model.TimingReport{
+		VersionID:  versionID,
+		UserID:     userID,
+		Kind:       model.TimingReportOffset,
+		LineNumber: req.Line,
+		OffsetMs:   req.OffsetMs,
+		Comment:    comment,
+	}
+	if req.Line > 0 {
+		report.Kind = model.TimingReportLine
+	} else if req.OffsetMs == 0 {
+		return nil, ErrInvalidInput
+	}
+
+	draft, err := s.publishedDraft(ctx, versionID)
+	if err != nil {
+		return nil, err
+	}
+	if req.Line > 0 {
+		doc, err := snapshotDocument(ctx, s.versions, draft)
+		if err != nil {
+			return nil, err
+		}
+		if int(req.Line) > len(doc.Lines) {
+			return nil, ErrInvalidInput
+		}
+	}
+	report.DraftID = draft.ID
+
+	var fixDraft *model.LyricsDraft
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		stat, err := s.reports.GetStatForUpdate(ctx, versionID, draft.ID)
+		if err != nil {
+			return err
+		}
+		if err := s.reports.Upsert(ctx, report); err != nil {
+			return err
+		}
+		sum, err := s.reports.Summarize(ctx, versionID)
+		if err != nil {
+			return err
+		}
+		stat.Reporters, stat.OffsetReports, stat.OffsetSumMs, stat.LineReports = sum.Reporters, sum.OffsetReports, sum.OffsetSumMs, sum.LineReports
+		if stat.FixDraftID == nil && s.cfg.Threshold > 0 && stat.Reporters >= uint(s.cfg.Threshold) {
+			reason := fmt.Sprintf("timing reports on version %d reached %d reporters", versionID, stat.Reporters)
+			if fixDraft, err = s.fork.Fork(ctx, draft, model.StageFine, reason); err != nil {
+				return err
+			}
+			stat.FixDraftID = &fixDraft.ID
+		}
+		return s.reports.SaveStat(ctx, stat)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if fixDraft != nil {
+		logx.L().Info("fix draft opened from timing reports", "version_id", versionID, "draft_id", draft.ID, "fix_draft_id", fixDraft.ID)
+	}
+	return s.Summary(ctx, versionID)
+}
+
+func (s *timingReportService) Summary(ctx context.Context, versionID uint) (*TimingSummary, error) {
+	draft, err := s.publishedDraft(ctx, versionID)
+	if err != nil {
+		return nil, err
+	}
+	summary := &TimingSummary{VersionID: versionID, DraftID: draft.ID, Threshold: s.cfg.Threshold, Lines: []TimingLineReports{}}
+	stat, err := s.reports.GetStat(ctx, versionID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return summary, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	summary.Reporters = stat.Reporters
+	summary.OffsetReports = stat.OffsetReports
+	summary.LineReports = stat.LineReports
+	summary.FixDraftID = stat.FixDraftID
+	if stat.OffsetReports > 0 {
+		summary.AvgOffsetMs = float64(stat.OffsetSumMs) / float64(stat.OffsetReports)
+	}
+
+	lines, err := s.reports.CountLines(ctx, versionID)
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range lines {
+		summary.Lines = append(summary.Lines, TimingLineReports{Line: line.LineNumber, Reports: line.Reports})
+	}
+	return summary, nil
+}
+
+// 只接受已发布稿件当前的审核快照
+func (s *timingReportService) publishedDraft(ctx context.Context, versionID uint) (*model.LyricsDraft, error) {
+	if versionID == 0 {
+		return nil, ErrInvalidInput
+	}
+	version, err := s.versions.GetByID(ctx, versionID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrVersionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	draft, err := s.drafts.GetByID(ctx, version.DraftID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrVersionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !isApprovedVersion(draft, version) {
+		return nil, ErrVersionNotApproved
+	}
+	return draft, nil
+}
@@ -0,0 +1,313 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/xiaowumin-mark/AMLX/config"
+	"github.com/xiaowumin-mark/AMLX/logx"
+	"github.com/xiaowumin-mark/AMLX/model"
+	"github.com/xiaowumin-mark/AMLX/store"
+	"gorm.io/gorm"
+)
+
+const (
+	usageCursorName      = "usage_rollup"
+	usageAggregateBatch  = 1000
+	maxUsageFormatLength = 10
+	maxUsageRegionLength = 32
+	usageMaxAge          = 7 * 24 * time.Hour // 更早的发生时间按接收时间计
+	usageMaxSkew         = 5 * time.Minute    // 允许的客户端时钟超前
+	usageCommitLag       = time.Minute        // 汇总时等待仍在提交中的事件
+)
+
+// 上报的一条使用事件
+type UsageEventInput struct {
+	Type     model.UsageEventType `json:"type"`
+	Platform string               `json:"platform"`
+	ID       string               `json:"id"`
+	Format   string               `json:"format"`
+	Region   string               `json:"region"`
+	At       *time.Time           `json:"at"` // 发生时间，可选
+}
+
+type UsageQuery struct {
+	Window string // 24h 按小时汇总，其余与贡献统计相同，按天汇总
+	Type   string // 为空时为 FETCHED 与 PLAYED
+	Format string
+	Region string
+	Limit  int
+}
+
+type SongUsage struct {
+	SongID uint   `json:"song_id"`
+	Title  string `json:"title"`
+	Count  uint64 `json:"count"`
+}
+
+type MissingLyrics struct {
+	Platform string `json:"platform"`
+	ID       string `json:"id"`
+	SongID   *uint  `json:"song_id"` // 歌曲在目录中但没有已发布歌词时不为空
+	Count    uint64 `json:"count"`
+}
+
+type UsageService interface {
+	Ingest(ctx context.Context, events []UsageEventInput) (int, error)      // 追加上报的事件，跳过无效事件，返回接收的数量
+	RecordMiss(ctx context.Context, platform, externalID, format string)    // 公开查询的 404 回调，记录一条 MISSED 事件
+	Aggregate(ctx context.Context) (int, error)                             // 汇总新事件，返回处理的事件数
+	TopSongs(ctx context.Context, query UsageQuery) ([]SongUsage, error)    // 使用最多的歌曲
+	Missing(ctx context.Context, query UsageQuery) ([]MissingLyrics, error) // 查询最多却没有歌词的平台 ID
+}
+
+type usageService struct {
+	cfg   config.UsageConfig
+	tx    store.Transactor
+	usage store.UsageStore
+	songs store.SongStore
+}
+
+func NewUsageService(cfg config.UsageConfig, tx store.Transactor, usage store.UsageStore, songs store.SongStore) UsageService {
+	return &usageService{cfg: cfg, tx: tx, usage: usage, songs: songs}
+}
+
+func (s *usageService) Ingest(ctx context.Context, inputs []UsageEventInput) (int, error) {
+	if len(inputs) == 0 || len(inputs) > s.cfg.MaxBatch {
+		return 0, ErrInvalidInput
+	}
+	now := time.Now()
+	events := make([]model.UsageEvent, 0, len(inputs))
+	for _, input := range inputs {
+		// MISSED 只由公开查询记录，不接受上报
+		if event, ok := newUsageEvent(input, now); ok && event.Type != model.UsageMissed {
+			events = append(events, event)
+		}
+	}
+	if err := s.usage.AddEvents(ctx, events); err != nil {
+		return 0, err
+	}
+	return len(events), nil
+}
+
+// 记录失败只写日志，不影响查询结果
+func (s *usageService) RecordMiss(ctx context.Context, platform, externalID, format string) {
+	event, ok := newUsageEvent(UsageEventInput{Type: model.UsageMissed, Platform: platform, ID: externalID, Format: format}, time.Now())
+	if !ok {
+		return
+	}
+	if err := s.usage.AddEvents(ctx, []model.UsageEvent{event}); err != nil {
+		logx.L().Warn("record lyrics miss failed", "platform", platform, "id", externalID, "error", err)
+	}
+}
+
+// 每批在一个事务中累加汇总并推进进度，进度行加锁，多个实例不会重复汇总
+func (s *usageService) Aggregate(ctx context.Context) (int, error) {
+	count := 0
+	for {
+		var batch int
+		err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+			cursor, err := s.usage.GetCursorForUpdate(ctx, usageCursorName)
+			if err != nil {
+				return err
+			}
+			events, err := s.usage.ListEventsAfter(ctx, cursor.LastEventID, usageAggregateBatch)
+			if err != nil {
+				return err
+			}
+			if events = settledUsageEvents(events, time.Now().Add(-usageCommitLag)); len(events) == 0 {
+				return nil
+			}
+			rollups, err := s.rollups(ctx, events)
+			if err != nil {
+				return err
+			}
+			if err := s.usage.AddRollups(ctx, rollups); err != nil {
+				return err
+			}
+			batch = len(events)
+			cursor.LastEventID = events[len(events)-1].ID
+			return s.usage.SaveCursor(ctx, cursor)
+		})
+		count += batch
+		if err != nil || batch < usageAggregateBatch {
+			return count, err
+		}
+	}
+}
+
+// 自增 ID 在插入时分配，ID 较小的事件可能更晚提交；游标越过后就再也读不到。
+// 只取接收时间早于 cutoff 的连续前缀，其余留到下次汇总
+func settledUsageEvents(events []model.UsageEvent, cutoff time.Time) []model.UsageEvent {
+	for i, event := range events {
+		if !event.CreatedAt.Before(cutoff) {
+			return events[:i]
+		}
+	}
+	return events
+}
+
+func (s *usageService) TopSongs(ctx context.Context, query UsageQuery) ([]SongUsage, error) {
+	filter, err := usageFilter(query, []model.UsageEventType{model.UsageFetched, model.UsagePlayed})
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.usage.TopSongs(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.SongID)
+	}
+	songs, err := s.songs.ListByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	titles := make(map[uint]string, len(songs))
+	for _, song := range songs {
+		titles[song.ID] = song.Title
+	}
+	result := make([]SongUsage, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, SongUsage{SongID: row.SongID, Title: titles[row.SongID], Count: row.Count})
+	}
+	return result, nil
+}
+
+func (s *usageService) Missing(ctx context.Context, query UsageQuery) ([]MissingLyrics, error) {
+	query.Type = string(model.UsageMissed)
+	filter, err := usageFilter(query, nil)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.usage.TopExternalIDs(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]MissingLyrics, 0, len(rows))
+	for _, row := range rows {
+		item := MissingLyrics{Platform: row.Platform, ID: row.ExternalID, Count: row.Count}
+		if row.SongID != 0 {
+			item.SongID = &row.SongID
+		}
+		result = append(result, item)
+	}
+	return result, nil
+}
+
+// 每个事件计入所在小时与所在天。MISSED 与不在目录中的歌曲按平台 ID 计（SongID 为 0），其余按歌曲计
+func (s *usageService) rollups(ctx context.Context, events []model.UsageEvent) ([]model.UsageRollup, error) {
+	songIDs := map[ExternalID]uint{}
+	counts := map[model.UsageRollup]uint64{}
+	for _, event := range events {
+		ref := ExternalID{Platform: event.Platform, ID: event.ExternalID}
+		songID, ok := songIDs[ref]
+		if !ok {
+			row, err := s.songs.GetByExternalID(ctx, ref.Platform, ref.ID)
+			switch {
+			case err == nil:
+				songID = row.SongID
+			case !errors.Is(err, gorm.ErrRecordNotFound):
+				return nil, err
+			}
+			songIDs[ref] = songID
+		}
+
+		key := model.UsageRollup{Type: event.Type, SongID: songID, Format: event.Format, Region: event.Region}
+		if event.Type == model.UsageMissed || songID == 0 {
+			key.Platform, key.ExternalID = event.Platform, event.ExternalID
+		}
+		hourly, daily := key, key
+		hourly.Granularity, hourly.BucketStart = model.UsageHourly, event.OccurredAt.Truncate(time.Hour)
+		daily.Granularity, daily.BucketStart = model.UsageDaily, startOfDay(event.OccurredAt)
+		counts[hourly]++
+		counts[daily]++
+	}
+
+	rollups := make([]model.UsageRollup, 0, len(counts))
+	for key, count := range counts {
+		key.Count = count
+		rollups = append(rollups, key)
+	}
+	return rollups, nil
+}
+
+func newUsageEvent(input UsageEventInput, now time.Time) (model.UsageEvent, bool) {
+	eventType := model.UsageEventType(strings.ToUpper(strings.TrimSpace(string(input.Type))))
+	switch eventType {
+	case model.UsageFetched, model.UsagePlayed, model.UsageOutOfSync, model.UsageMissed:
+	default:
+		return model.UsageEvent{}, false
+	}
+	ref, err := normalizeExternalID(ExternalID{Platform: input.Platform, ID: input.ID})
+	if err != nil {
+		return model.UsageEvent{}, false
+	}
+	format := strings.ToLower(strings.TrimSpace(input.Format))
+	region := strings.ToUpper(strings.TrimSpace(input.Region))
+	if !isUsageLabel(format, maxUsageFormatLength) || !isUsageLabel(region, maxUsageRegionLength) {
+		return model.UsageEvent{}, false
+	}
+	at := now
+	if input.At != nil && input.At.After(now.Add(-usageMaxAge)) && input.At.Before(now.Add(usageMaxSkew)) {
+		at = input.At.In(now.Location())
+	}
+	return model.UsageEvent{
+		Type:       eventType,
+		Platform:   ref.Platform,
+		ExternalID: ref.ID,
+		Format:     format,
+		Region:     region,
+		OccurredAt: at,
+	}, true
+}
+
+// 格式与地区只允许字母、数字与连字符，可以为空
+func isUsageLabel(value string, maxLength int) bool {
+	if len(value) > maxLength {
+		return false
+	}
+	for _, r := range value {
+		if r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+func usageFilter(query UsageQuery, defaultTypes []model.UsageEventType) (store.UsageFilter, error) {
+	filter := store.UsageFilter{
+		Granularity: model.UsageDaily,
+		Types:       defaultTypes,
+		Format:      strings.ToLower(strings.TrimSpace(query.Format)),
+		Region:      strings.ToUpper(strings.TrimSpace(query.Region)),
+		Limit:       query.Limit,
+	}
+	if strings.ToLower(strings.TrimSpace(query.Window)) == "24h" {
+		since := time.Now().Truncate(time.Hour).Add(-23 * time.Hour)
+		filter.Granularity, filter.Since = model.UsageHourly, &since
+	} else {
+		since, err := windowStart(query.Window)
+		if err != nil {
+			return filter, err
+		}
+		filter.Since = since
+	}
+	if query.Type != "" {
+		eventType := model.UsageEventType(strings.ToUpper(strings.TrimSpace(query.Type)))
+		switch eventType {
+		case model.UsageFetched, model.UsagePlayed, model.UsageOutOfSync, model.UsageMissed:
+		default:
+			return filter, ErrInvalidInput
+		}
+		filter.Types = []model.UsageEventType{eventType}
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultLeaderboardSize
+	}
+	filter.Limit = min(filter.Limit, maxLeaderboardSize)
+	return filter, nil
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/xiaowumin-mark/AMLX/model"
+	"github.com/xiaowumin-mark/AMLX/store"
+	"gorm.io/gorm"
+)
+
+// 目录中只有 ncm 1，对应歌曲 7
+type usageSongs struct {
+	store.SongStore
+}
+
+func (usageSongs) GetByExternalID(ctx context.Context, platform, externalID string) (*model.SongExternalID, error) {
+	if platform == "ncm" && externalID == "1" {
+		return &model.SongExternalID{SongID: 7, Platform: platform, ExternalID: externalID}, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func TestSettledUsageEvents(t *testing.T) {
+	cutoff := time.Date(2026, 2, 8, 10, 0, 0, 0, time.UTC)
+	at := func(offset time.Duration) model.UsageEvent {
+		return model.UsageEvent{CreatedAt: cutoff.Add(offset)}
+	}
+	tests := []struct {
+		name   string
+		events []model.UsageEvent
+		want   int
+	}{
+		{"all settled", []model.UsageEvent{at(-3 * time.Minute), at(-time.Minute)}, 2},
+		{"stops at the first recent event", []model.UsageEvent{at(-time.Minute), at(time.Second), at(-time.Minute)}, 1},
+		{"cutoff is not settled", []model.UsageEvent{at(0)}, 0},
+		{"empty", nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := settledUsageEvents(tt.events, cutoff); len(got) != tt.want {
+				t.Fatalf("got %d events, want %d", len(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestUsageRollups(t *testing.T) {
+	s := &usageService{songs: usageSongs{}}
+	at := time.Date(2026, 2, 8, 10, 30, 0, 0, time.UTC)
+	events := []model.UsageEvent{
+		{Type: model.UsagePlayed, Platform: "ncm", ExternalID: "1", OccurredAt: at},
+		{Type: model.UsagePlayed, Platform: "ncm", ExternalID: "1", OccurredAt: at.Add(time.Minute)},
+		{Type: model.UsagePlayed, Platform: "ncm", ExternalID: "2", OccurredAt: at},
+		{Type: model.UsageMissed, Platform: "ncm", ExternalID: "1", OccurredAt: at},
+	}
+	rollups, err := s.rollups(context.Background(), events)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type key struct {
+		eventType  model.UsageEventType
+		songID     uint
+		externalID string
+	}
+	hourly := map[key]uint64{}
+	for _, rollup := range rollups {
+		if rollup.Granularity == model.UsageHourly {
+			if !rollup.BucketStart.Equal(at.Truncate(time.Hour)) {
+				t.Errorf("hourly bucket %s", rollup.BucketStart)
+			}
+			hourly[key{rollup.Type, rollup.SongID, rollup.ExternalID}] = rollup.Count
+		}
+	}
+	want := map[key]uint64{
+		{model.UsagePlayed, 7, ""}:  2, // 目录中的歌曲按歌曲计
+		{model.UsagePlayed, 0, "2"}: 1, // 不在目录中的歌曲按平台 ID 计
+		{model.UsageMissed, 7, "1"}: 1,
+	}
+	if len(hourly) != len(want) {
+		t.Fatalf("hourly rollups %v, want %v", hourly, want)
+	}
+	for k, count := range want {
+		if hourly[k] != count {
+			t.Errorf("%+v: got %d, want %d", k, hourly[k], count)
+		}
+	}
+	if len(rollups) != 2*len(want) {
+		t.Fatalf("got %d rollups, want hourly and daily for each", len(rollups))
+	}
+}
@@ -0,0 +1,289 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/xiaowumin-mark/AMLX/lyrics"
+	"github.com/xiaowumin-mark/AMLX/lyrics/pack"
+	"github.com/xiaowumin-mark/AMLX/lyrics/patch"
+	"github.com/xiaowumin-mark/AMLX/lyrics/ttml"
+	"github.com/xiaowumin-mark/AMLX/model"
+	"github.com/xiaowumin-mark/AMLX/store"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrVersionNotFound    = errors.New("version not found")
+	ErrDraftLocked        = errors.New("draft is locked after review")
+	ErrVersionNotApproved = errors.New("version is not approved")
+	ErrInvalidPatch       = errors.New("invalid patch")
+	ErrPatchConflict      = errors.New("patch does not apply to this version")
+)
+
+type VersionDiff struct {
+	FromVersionID uint       `json:"from_version_id"`
+	ToVersionID   uint       `json:"to_version_id"`
+	Lines         []DiffLine `json:"lines"`
+}
+
+type VersionService interface {
+	Save(ctx context.Context, userID, draftID uint, content string) (*model.LyricsVersion, error)               // 保存内容为新版本
+	List(ctx context.Context, userID, draftID uint) ([]model.LyricsVersion, error)                              // 版本列表
+	Get(ctx context.Context, userID, draftID, versionID uint) (*model.LyricsVersion, error)                     // 获取版本
+	Diff(ctx context.Context, userID, draftID, fromID, toID uint) (*VersionDiff, error)                         // 版本 diff
+	Restore(ctx context.Context, userID, draftID, versionID uint) (*model.LyricsVersion, error)                 // 恢复旧版本为最新版本
+	Export(ctx context.Context, userID, draftID, versionID uint, format string) (*ExportedLyrics, error)        // 导出为其它格式
+	Pack(ctx context.Context, draftID, versionID uint) ([]byte, error)                                          // 已通过审核版本的 Binary Pack
+	Patch(ctx context.Context, userID, draftID, fromID, toID uint) (*patch.Patch, error)                        // 结构化补丁
+	ApplyPatch(ctx context.Context, userID, draftID, baseID uint, p *patch.Patch) (*model.LyricsVersion, error) // 在指定版本上应用补丁生成新版本
+}
+
+type versionService struct {
+	drafts store.DraftStore
+	reader *versionReader
+}
+
+func NewVersionService(drafts store.DraftStore, versions store.VersionStore) VersionService {
+	return &versionService{
+		drafts: drafts,
+		reader: &versionReader{versions: versions},
+	}
+}
+
+// 保存内容为新版本
+func (s *versionService) Save(ctx context.Context, userID, draftID uint, content string) (*model.LyricsVersion, error) {
+	draft, err := s.getOwned(ctx, userID, draftID)
+	if err != nil {
+		return nil, err
+	}
+	return s.reader.create(ctx, draft, userID, content)
+}
+
+// 版本列表
+func (s *versionService) List(ctx context.Context, userID, draftID uint) ([]model.LyricsVersion, error) {
+	if _, err := s.getOwned(ctx, userID, draftID); err != nil {
+		return nil, err
+	}
+	return s.reader.list(ctx, draftID)
+}
+
+// 获取版本
+func (s *versionService) Get(ctx context.Context, userID, draftID, versionID uint) (*model.LyricsVersion, error) {
+	if _, err := s.getOwned(ctx, userID, draftID); err != nil {
+		return nil, err
+	}
+	return s.reader.get(ctx, draftID, versionID)
+}
+
+// 版本 diff
+func (s *versionService) Diff(ctx context.Context, userID, draftID, fromID, toID uint) (*VersionDiff, error) {
+	if _, err := s.getOwned(ctx, userID, draftID); err != nil {
+		return nil, err
+	}
+	return s.reader.diff(ctx, draftID, fromID, toID)
+}
+
+// 恢复旧版本：复制其内容为新的最新版本，历史版本保持不变
+func (s *versionService) Restore(ctx context.Context, userID, draftID, versionID uint) (*model.LyricsVersion, error) {
+	draft, err := s.getOwned(ctx, userID, draftID)
+	if err != nil {
+		return nil, err
+	}
+	source, err := s.reader.get(ctx, draftID, versionID)
+	if err != nil {
+		return nil, err
+	}
+	return s.reader.create(ctx, draft, userID, source.Content)
+}
+
+// 导出为其它格式
+func (s *versionService) Export(ctx context.Context, userID, draftID, versionID uint, format string) (*ExportedLyrics, error) {
+	draft, err := s.getOwned(ctx, userID, draftID)
+	if err != nil {
+		return nil, err
+	}
+	return s.reader.export(ctx, draft, versionID, format)
+}
+
+// 已通过审核版本的 Binary Pack，不限制所有者
+func (s *versionService) Pack(ctx context.Context, draftID, versionID uint) ([]byte, error) {
+	if draftID == 0 {
+		return nil, ErrInvalidInput
+	}
+	draft, err := s.drafts.GetByID(ctx, draftID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrDraftNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	version, err := s.reader.get(ctx, draftID, versionID)
+	if err != nil {
+		return nil, err
+	}
+	if !isApprovedVersion(draft, version) {
+		return nil, ErrVersionNotApproved
+	}
+	doc, err := DecodeLyrics(FormatTTML, version.Content)
+	if err != nil {
+		return nil, err
+	}
+	fillDocumentMeta(doc, draft)
+	return pack.Encode(doc), nil
+}
+
+// 结构化补丁
+func (s *versionService) Patch(ctx context.Context, userID, draftID, fromID, toID uint) (*patch.Patch, error) {
+	if _, err := s.getOwned(ctx, userID, draftID); err != nil {
+		return nil, err
+	}
+	return s.reader.patch(ctx, draftID, fromID, toID)
+}
+
+// 在指定版本上应用补丁，结果保存为新的最新版本
+func (s *versionService) ApplyPatch(ctx context.Context, userID, draftID, baseID uint, p *patch.Patch) (*model.LyricsVersion, error) {
+	draft, err := s.getOwned(ctx, userID, draftID)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, ErrInvalidPatch
+	}
+	base, err := s.reader.document(ctx, draftID, baseID)
+	if err != nil {
+		return nil, err
+	}
+	result, err := patch.Apply(base, p)
+	switch {
+	case errors.Is(err, patch.ErrBaseMismatch), errors.Is(err, patch.ErrTargetMismatch):
+		return nil, fmt.Errorf("%w: %v", ErrPatchConflict, err)
+	case errors.Is(err, patch.ErrInvalidPatch):
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	case err != nil:
+		return nil, err
+	}
+	return s.reader.create(ctx, draft, userID, ttml.MarshalString(result))
+}
+
+func (s *versionService) getOwned(ctx context.Context, userID, draftID uint) (*model.LyricsDraft, error) {
+	if userID == 0 || draftID == 0 {
+		return nil, ErrInvalidInput
+	}
+	draft, err := s.drafts.GetByID(ctx, draftID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrDraftNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if draft.OwnerUserID != userID {
+		return nil, ErrDraftForbidden
+	}
+	return draft, nil
+}
+
+// 审核通过的版本即通过时冻结的审核快照
+func isApprovedVersion(draft *model.LyricsDraft, version *model.LyricsVersion) bool {
+	return draft.Status == model.DraftReviewDone &&
+		draft.ReviewSnapshotID != nil && *draft.ReviewSnapshotID == version.ID
+}
+
+// 版本读写，不做权限校验，由调用方负责
+type versionReader struct {
+	versions store.VersionStore
+}
+
+func (r *versionReader) create(ctx context.Context, draft *model.LyricsDraft, userID uint, content string) (*model.LyricsVersion, error) {
+	if draft.Status == model.DraftReviewDone {
+		return nil, ErrDraftLocked
+	}
+	// 审核中的编辑归入 CHECK 阶段，审核快照不受影响
+	stage := model.StageCheck
+	if draft.WorkflowStage != nil {
+		stage = *draft.WorkflowStage
+	}
+	version := &model.LyricsVersion{
+		DraftID:       draft.ID,
+		WorkflowStage: stage,
+		Content:       content,
+		CreatedBy:     userID,
+	}
+	if err := r.versions.Create(ctx, version); err != nil {
+		return nil, err
+	}
+	return version, nil
+}
+
+func (r *versionReader) list(ctx context.Context, draftID uint) ([]model.LyricsVersion, error) {
+	return r.versions.ListByDraft(ctx, draftID)
+}
+
+func (r *versionReader) get(ctx context.Context, draftID, versionID uint) (*model.LyricsVersion, error) {
+	if versionID == 0 {
+		return nil, ErrInvalidInput
+	}
+	version, err := r.versions.GetByID(ctx, versionID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrVersionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if version.DraftID != draftID {
+		return nil, ErrVersionNotFound
+	}
+	return version, nil
+}
+
+// 版本内容按 TTML 解析后导出，缺少的元数据由稿件信息补齐
+func (r *versionReader) export(ctx context.Context, draft *model.LyricsDraft, versionID uint, format string) (*ExportedLyrics, error) {
+	version, err := r.get(ctx, draft.ID, versionID)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := DecodeLyrics(FormatTTML, version.Content)
+	if err != nil {
+		return nil, err
+	}
+	fillDocumentMeta(doc, draft)
+	return EncodeLyrics(format, doc)
+}
+
+// 解析版本内容为 AST
+func (r *versionReader) document(ctx context.Context, draftID, versionID uint) (*lyrics.Document, error) {
+	version, err := r.get(ctx, draftID, versionID)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeLyrics(FormatTTML, version.Content)
+}
+
+func (r *versionReader) patch(ctx context.Context, draftID, fromID, toID uint) (*patch.Patch, error) {
+	from, err := r.document(ctx, draftID, fromID)
+	if err != nil {
+		return nil, err
+	}
+	to, err := r.document(ctx, draftID, toID)
+	if err != nil {
+		return nil, err
+	}
+	return patch.Diff(from, to), nil
+}
+
+func (r *versionReader) diff(ctx context.Context, draftID, fromID, toID uint) (*VersionDiff, error) {
+	from, err := r.document(ctx, draftID, fromID)
+	if err != nil {
+		return nil, err
+	}
+	to, err := r.document(ctx, draftID, toID)
+	if err != nil {
+		return nil, err
+	}
+	return &VersionDiff{
+		FromVersionID: fromID,
+		ToVersionID:   toID,
+		Lines:         DiffLyrics(from, to),
+	}, nil
+}
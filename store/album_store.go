@@ -0,0 +1,36 @@
+package store
+
+import (
+	"context"
+
+	"github.com/xiaowumin-mark/AMLX/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type AlbumStore interface {
+	GetByID(ctx context.Context, id uint) (*model.Album, error)                          // 获取
+	FindOrCreate(ctx context.Context, title string, artistID uint) (*model.Album, error) // 按标题与专辑艺术家获取，不存在时创建
+}
+
+type albumStore struct {
+	db *gorm.DB
+}
+
+func NewAlbumStore(db *gorm.DB) AlbumStore {
+	return &albumStore{db: db}
+}
+
+func (s *albumStore) GetByID(ctx context.Context, id uint) (*model.Album, error) {
+	var album model.Album
+	return &album, conn(ctx, s.db).First(&album, id).Error
+}
+
+func (s *albumStore) FindOrCreate(ctx context.Context, title string, artistID uint) (*model.Album, error) {
+	db := conn(ctx, s.db)
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.Album{Title: title, ArtistID: artistID}).Error; err != nil {
+		return nil, err
+	}
+	var album model.Album
+	return &album, db.Where("title = ? AND artist_id = ?", title, artistID).First(&album).Error
+}
@@ -0,0 +1,39 @@
+package store
+
+import (
+	"context"
+
+	"github.com/xiaowumin-mark/AMLX/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ArtistStore interface {
+	FindOrCreate(ctx context.Context, name string) (*model.Artist, error) // 按名称获取，不存在时创建
+	ListByIDs(ctx context.Context, ids []uint) ([]model.Artist, error)    // 按 ID 批量获取
+}
+
+type artistStore struct {
+	db *gorm.DB
+}
+
+func NewArtistStore(db *gorm.DB) ArtistStore {
+	return &artistStore{db: db}
+}
+
+func (s *artistStore) FindOrCreate(ctx context.Context, name string) (*model.Artist, error) {
+	db := conn(ctx, s.db)
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.Artist{Name: name}).Error; err != nil {
+		return nil, err
+	}
+	var artist model.Artist
+	return &artist, db.Where("name = ?", name).First(&artist).Error
+}
+
+func (s *artistStore) ListByIDs(ctx context.Context, ids []uint) ([]model.Artist, error) {
+	var artists []model.Artist
+	if len(ids) == 0 {
+		return artists, nil
+	}
+	return artists, conn(ctx, s.db).Where("id IN ?", ids).Find(&artists).Error
+}
@@ -0,0 +1,75 @@
+package store
+
+import (
+	"context"
+
+	"github.com/xiaowumin-mark/AMLX/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CDNNodeStore interface {
+	GetByNodeID(ctx context.Context, nodeID string) (*model.CDNNode, error)               // 按对外 ID 获取
+	GetByNodeIDForUpdate(ctx context.Context, nodeID string) (*model.CDNNode, error)      // 获取并加行锁（需在事务中）
+	GetByName(ctx context.Context, name string) (*model.CDNNode, error)                   // 按名称获取
+	List(ctx context.Context, status model.CDNNodeStatus) ([]model.CDNNode, error)        // 列表，status 为空时不过滤
+	Create(ctx context.Context, node *model.CDNNode) error                                // 创建
+	Update(ctx context.Context, node *model.CDNNode) error                                // 整体保存
+	AddPushError(ctx context.Context, id uint) error                                      // 推送失败计数加一
+	CreateEvent(ctx context.Context, event *model.CDNNodeEvent) error                     // 记录状态变化
+	ListEvents(ctx context.Context, nodeID uint, limit int) ([]model.CDNNodeEvent, error) // 状态变化记录，新的在前
+}
+
+type cdnNodeStore struct {
+	db *gorm.DB
+}
+
+func NewCDNNodeStore(db *gorm.DB) CDNNodeStore {
+	return &cdnNodeStore{db: db}
+}
+
+func (s *cdnNodeStore) GetByNodeID(ctx context.Context, nodeID string) (*model.CDNNode, error) {
+	var node model.CDNNode
+	return &node, conn(ctx, s.db).Where("node_id = ?", nodeID).First(&node).Error
+}
+
+func (s *cdnNodeStore) GetByNodeIDForUpdate(ctx context.Context, nodeID string) (*model.CDNNode, error) {
+	var node model.CDNNode
+	return &node, conn(ctx, s.db).Clauses(clause.Locking{Strength: "UPDATE"}).Where("node_id = ?", nodeID).First(&node).Error
+}
+
+func (s *cdnNodeStore) GetByName(ctx context.Context, name string) (*model.CDNNode, error) {
+	var node model.CDNNode
+	return &node, conn(ctx, s.db).Where("name = ?", name).First(&node).Error
+}
+
+func (s *cdnNodeStore) List(ctx context.Context, status model.CDNNodeStatus) ([]model.CDNNode, error) {
+	query := conn(ctx, s.db).Model(&model.CDNNode{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var nodes []model.CDNNode
+	return nodes, query.Order("name ASC").Find(&nodes).Error
+}
+
+func (s *cdnNodeStore) Create(ctx context.Context, node *model.CDNNode) error {
+	return conn(ctx, s.db).Create(node).Error
+}
+
+func (s *cdnNodeStore) Update(ctx context.Context, node *model.CDNNode) error {
+	return conn(ctx, s.db).Save(node).Error
+}
+
+func (s *cdnNodeStore) AddPushError(ctx context.Context, id uint) error {
+	return conn(ctx, s.db).Model(&model.CDNNode{}).Where("id = ?", id).
+		UpdateColumn("push_errors", gorm.Expr("push_errors + 1")).Error
+}
+
+func (s *cdnNodeStore) CreateEvent(ctx context.Context, event *model.CDNNodeEvent) error {
+	return conn(ctx, s.db).Create(event).Error
+}
+
+func (s *cdnNodeStore) ListEvents(ctx context.Context, nodeID uint, limit int) ([]model.CDNNodeEvent, error) {
+	var events []model.CDNNodeEvent
+	return events, conn(ctx, s.db).Where("node_id = ?", nodeID).Order("id DESC").Limit(limit).Find(&events).Error
+}
@@ -0,0 +1,82 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/xiaowumin-mark/AMLX/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ContributorStatStore interface {
+	Add(ctx context.Context, stat *model.ContributorStat) error                                           // 累加到用户当天的统计
+	Sum(ctx context.Context, userID uint, since *time.Time) (*model.ContributorStat, error)               // 用户自 since 起的合计，since 为空时为全部
+	Top(ctx context.Context, metric string, since *time.Time, limit int) ([]model.ContributorStat, error) // 按字段合计排序的用户，合计为 0 的不返回
+	Reset(ctx context.Context) error                                                                      // 清空全部统计
+}
+
+type contributorStatStore struct {
+	db *gorm.DB
+}
+
+func NewContributorStatStore(db *gorm.DB) ContributorStatStore {
+	return &contributorStatStore{db: db}
+}
+
+// 合计查询的字段，与 model.ContributorStat 的列对应
+const contributorStatSums = "user_id, SUM(submitted) AS submitted, SUM(approved) AS approved, SUM(rejected) AS rejected, " +
+	"SUM(approved_rejects) AS approved_rejects, SUM(`lines`) AS `lines`, SUM(syllables) AS syllables, " +
+	"SUM(reviews) AS reviews, SUM(review_seconds) AS review_seconds"
+
+func (s *contributorStatStore) Add(ctx context.Context, stat *model.ContributorStat) error {
+	return conn(ctx, s.db).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "day"}},
+		DoUpdates: clause.Assignments(map[string]any{
+			"submitted":        gorm.Expr("submitted + ?", stat.Submitted),
+			"approved":         gorm.Expr("approved + ?", stat.Approved),
+			"rejected":         gorm.Expr("rejected + ?", stat.Rejected),
+			"approved_rejects": gorm.Expr("approved_rejects + ?", stat.ApprovedRejects),
+			"lines":            gorm.Expr("`lines` + ?", stat.Lines),
+			"syllables":        gorm.Expr("syllables + ?", stat.Syllables),
+			"reviews":          gorm.Expr("reviews + ?", stat.Reviews),
+			"review_seconds":   gorm.Expr("review_seconds + ?", stat.ReviewSeconds),
+		}),
+	}).Create(stat).Error
+}
+
+func (s *contributorStatStore) Sum(ctx context.Context, userID uint, since *time.Time) (*model.ContributorStat, error) {
+	query := conn(ctx, s.db).Model(&model.ContributorStat{}).Select(contributorStatSums).
+		Where("user_id = ?", userID).Group("user_id")
+	if since != nil {
+		query = query.Where("day >= ?", *since)
+	}
+	var stats []model.ContributorStat
+	if err := query.Scan(&stats).Error; err != nil {
+		return nil, err
+	}
+	if len(stats) == 0 {
+		return &model.ContributorStat{UserID: userID}, nil
+	}
+	return &stats[0], nil
+}
+
+func (s *contributorStatStore) Top(ctx context.Context, metric string, since *time.Time, limit int) ([]model.ContributorStat, error) {
+	query := conn(ctx, s.db).Model(&model.ContributorStat{}).Select(contributorStatSums).Group("user_id")
+	if since != nil {
+		query = query.Where("day >= ?", *since)
+	}
+	column := clause.Column{Name: metric}
+	var stats []model.ContributorStat
+	return stats, query.
+		Having("SUM(?) > 0", column).
+		Order(clause.OrderBy{Columns: []clause.OrderByColumn{
+			{Column: column, Desc: true},
+			{Column: clause.Column{Name: "user_id"}},
+		}}).
+		Limit(limit).Scan(&stats).Error
+}
+
+func (s *contributorStatStore) Reset(ctx context.Context) error {
+	return conn(ctx, s.db).Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&model.ContributorStat{}).Error
+}
@@ -0,0 +1,124 @@
+package store
+
+import (
+	"context"
+
+	"github.com/xiaowumin-mark/AMLX/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 稿件查询条件
+type DraftFilter struct {
+	OwnerUserID uint
+	Status      model.DraftStatus
+	PRState     model.PullRequestState
+	SongID      uint
+	Offset      int
+	Limit       int
+}
+
+type DraftStore interface {
+	GetByID(ctx context.Context, id uint) (*model.LyricsDraft, error)                   // 获取
+	GetByIDForUpdate(ctx context.Context, id uint) (*model.LyricsDraft, error)          // 获取并加行锁（需在事务中）
+	List(ctx context.Context, filter DraftFilter) ([]model.LyricsDraft, int64, error)   // 列表
+	Create(ctx context.Context, draft *model.LyricsDraft) error                         // 创建
+	Update(ctx context.Context, draft *model.LyricsDraft) error                         // 整体保存
+	UpdateInfo(ctx context.Context, draft *model.LyricsDraft) error                     // 仅更新基本信息字段
+	UpdateState(ctx context.Context, draft *model.LyricsDraft) error                    // 仅更新状态流转涉及的字段
+	UpdatePullRequest(ctx context.Context, draft *model.LyricsDraft) error              // 仅更新 PR 字段
+	UpdateSong(ctx context.Context, draft *model.LyricsDraft) error                     // 仅更新关联的歌曲
+	GetByPullRequest(ctx context.Context, number int) (*model.LyricsDraft, error)       // 按 PR 编号获取
+	LatestPublishedBySong(ctx context.Context, songID uint) (*model.LyricsDraft, error) // 歌曲最近通过审核的已发布稿件
+	Delete(ctx context.Context, id uint) error                                          // 删除
+}
+
+type draftStore struct {
+	db *gorm.DB
+}
+
+func NewDraftStore(db *gorm.DB) DraftStore {
+	return &draftStore{db: db}
+}
+
+func (s *draftStore) GetByID(ctx context.Context, id uint) (*model.LyricsDraft, error) {
+	var draft model.LyricsDraft
+	return &draft, conn(ctx, s.db).First(&draft, id).Error
+}
+
+func (s *draftStore) GetByIDForUpdate(ctx context.Context, id uint) (*model.LyricsDraft, error) {
+	var draft model.LyricsDraft
+	return &draft, conn(ctx, s.db).Clauses(clause.Locking{Strength: "UPDATE"}).First(&draft, id).Error
+}
+
+func (s *draftStore) List(ctx context.Context, filter DraftFilter) ([]model.LyricsDraft, int64, error) {
+	query := conn(ctx, s.db).Model(&model.LyricsDraft{})
+	if filter.OwnerUserID != 0 {
+		query = query.Where("owner_user_id = ?", filter.OwnerUserID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.PRState != "" {
+		query = query.Where("github_pr_state = ?", filter.PRState)
+	}
+	if filter.SongID != 0 {
+		query = query.Where("song_id = ?", filter.SongID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var drafts []model.LyricsDraft
+	err := query.Order("id DESC").Offset(filter.Offset).Limit(filter.Limit).Find(&drafts).Error
+	return drafts, total, err
+}
+
+func (s *draftStore) Create(ctx context.Context, draft *model.LyricsDraft) error {
+	return conn(ctx, s.db).Create(draft).Error
+}
+
+func (s *draftStore) Update(ctx context.Context, draft *model.LyricsDraft) error {
+	return conn(ctx, s.db).Save(draft).Error
+}
+
+func (s *draftStore) UpdateInfo(ctx context.Context, draft *model.LyricsDraft) error {
+	return conn(ctx, s.db).Model(draft).
+		Select("title", "artists", "album", "language", "song_id", "allow_stage_rollback", "publish_target").
+		Updates(draft).Error
+}
+
+// 状态流转只写自己负责的字段，避免覆盖并发写入的 PR 与歌曲关联
+func (s *draftStore) UpdateState(ctx context.Context, draft *model.LyricsDraft) error {
+	return conn(ctx, s.db).Model(draft).
+		Select("status", "workflow_stage", "review_snapshot_id", "reject_count", "last_reject_at").
+		Updates(draft).Error
+}
+
+func (s *draftStore) UpdatePullRequest(ctx context.Context, draft *model.LyricsDraft) error {
+	return conn(ctx, s.db).Model(draft).
+		Select("github_pr_url", "github_pr_number", "github_pr_state").
+		Updates(draft).Error
+}
+
+func (s *draftStore) UpdateSong(ctx context.Context, draft *model.LyricsDraft) error {
+	return conn(ctx, s.db).Model(draft).Select("song_id").Updates(draft).Error
+}
+
+// 审核快照 ID 随版本递增，取最大者即最近通过的版本
+func (s *draftStore) LatestPublishedBySong(ctx context.Context, songID uint) (*model.LyricsDraft, error) {
+	var draft model.LyricsDraft
+	return &draft, conn(ctx, s.db).
+		Where("song_id = ? AND status = ? AND review_snapshot_id IS NOT NULL", songID, model.DraftReviewDone).
+		Order("review_snapshot_id DESC").First(&draft).Error
+}
+
+func (s *draftStore) GetByPullRequest(ctx context.Context, number int) (*model.LyricsDraft, error) {
+	var draft model.LyricsDraft
+	return &draft, conn(ctx, s.db).Where("github_pr_number = ?", number).Order("id DESC").First(&draft).Error
+}
+
+func (s *draftStore) Delete(ctx context.Context, id uint) error {
+	return conn(ctx, s.db).Delete(&model.LyricsDraft{}, id).Error
+}
@@ -0,0 +1,45 @@
+package store
+
+import (
+	"context"
+
+	"github.com/xiaowumin-mark/AMLX/model"
+	"gorm.io/gorm"
+)
+
+type DraftTransitionStore interface {
+	Create(ctx context.Context, transition *model.DraftTransition) error                                                       // 记录
+	ListByDraft(ctx context.Context, draftID uint) ([]model.DraftTransition, error)                                            // 列出稿件的流转历史
+	ListAfter(ctx context.Context, afterID uint, actions []model.TransitionAction, limit int) ([]model.DraftTransition, error) // ID 大于 afterID 的指定动作，按 ID 升序
+	LastEntered(ctx context.Context, draftID uint, stage model.WorkflowStage) (*model.DraftTransition, error)                  // 稿件最近一次进入指定预审核阶段的流转
+}
+
+type draftTransitionStore struct {
+	db *gorm.DB
+}
+
+func NewDraftTransitionStore(db *gorm.DB) DraftTransitionStore {
+	return &draftTransitionStore{db: db}
+}
+
+func (s *draftTransitionStore) Create(ctx context.Context, transition *model.DraftTransition) error {
+	return conn(ctx, s.db).Create(transition).Error
+}
+
+func (s *draftTransitionStore) ListByDraft(ctx context.Context, draftID uint) ([]model.DraftTransition, error) {
+	var transitions []model.DraftTransition
+	return transitions, conn(ctx, s.db).Where("draft_id = ?", draftID).Order("id ASC").Find(&transitions).Error
+}
+
+func (s *draftTransitionStore) ListAfter(ctx context.Context, afterID uint, actions []model.TransitionAction, limit int) ([]model.DraftTransition, error) {
+	var transitions []model.DraftTransition
+	return transitions, conn(ctx, s.db).Where("id > ? AND action IN ?", afterID, actions).
+		Order("id ASC").Limit(limit).Find(&transitions).Error
+}
+
+func (s *draftTransitionStore) LastEntered(ctx context.Context, draftID uint, stage model.WorkflowStage) (*model.DraftTransition, error) {
+	var transition model.DraftTransition
+	return &transition, conn(ctx, s.db).
+		Where("draft_id = ? AND to_status = ? AND to_stage = ?", draftID, model.DraftPreReview, stage).
+		Order("id DESC").First(&transition).Error
+}
@@ -0,0 +1,133 @@
+package store
+
+import (
+	"context"
+
+	"github.com/xiaowumin-mark/AMLX/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 求歌词请求查询条件
+type LyricRequestFilter struct {
+	Status model.LyricRequestStatus
+	ByVote bool // 按票数排序，否则按创建时间
+	Offset int
+	Limit  int
+}
+
+type LyricRequestStore interface {
+	GetByID(ctx context.Context, id uint) (*model.LyricRequest, error)                              // 获取
+	GetByIDForUpdate(ctx context.Context, id uint) (*model.LyricRequest, error)                     // 获取并加行锁（需在事务中）
+	GetActiveByKey(ctx context.Context, key string) (*model.LyricRequest, error)                    // 按归一化键获取未关闭的请求
+	ListActiveForDraft(ctx context.Context, draftID uint, key string) ([]model.LyricRequest, error) // 稿件认领的请求及同名的待认领请求
+	List(ctx context.Context, filter LyricRequestFilter) ([]model.LyricRequest, int64, error)       // 列表
+	Create(ctx context.Context, request *model.LyricRequest) (bool, error)                          // 创建，ActiveKey 冲突时不写入并返回 false
+	Update(ctx context.Context, request *model.LyricRequest) error                                  // 整体保存
+	ReopenByDraft(ctx context.Context, draftID uint) error                                          // 重新开放稿件认领中的请求
+	AddVote(ctx context.Context, requestID, userID uint) (bool, error)                              // 投票，已投过时返回 false
+	RemoveVote(ctx context.Context, requestID, userID uint) (bool, error)                           // 取消投票，未投过时返回 false
+	VotedIDs(ctx context.Context, userID uint, requestIDs []uint) ([]uint, error)                   // 用户投过票的请求
+}
+
+type lyricRequestStore struct {
+	db *gorm.DB
+}
+
+func NewLyricRequestStore(db *gorm.DB) LyricRequestStore {
+	return &lyricRequestStore{db: db}
+}
+
+func (s *lyricRequestStore) GetByID(ctx context.Context, id uint) (*model.LyricRequest, error) {
+	var request model.LyricRequest
+	return &request, conn(ctx, s.db).First(&request, id).Error
+}
+
+func (s *lyricRequestStore) GetByIDForUpdate(ctx context.Context, id uint) (*model.LyricRequest, error) {
+	var request model.LyricRequest
+	return &request, conn(ctx, s.db).Clauses(clause.Locking{Strength: "UPDATE"}).First(&request, id).Error
+}
+
+func (s *lyricRequestStore) GetActiveByKey(ctx context.Context, key string) (*model.LyricRequest, error) {
+	var request model.LyricRequest
+	return &request, conn(ctx, s.db).
+		Where("normalized_key = ? AND status <> ?", key, model.LyricRequestClosed).
+		Order("id ASC").First(&request).Error
+}
+
+func (s *lyricRequestStore) ListActiveForDraft(ctx context.Context, draftID uint, key string) ([]model.LyricRequest, error) {
+	var requests []model.LyricRequest
+	return requests, conn(ctx, s.db).
+		Where("(draft_id = ? AND status = ?) OR (normalized_key = ? AND status = ?)",
+			draftID, model.LyricRequestClaimed, key, model.LyricRequestOpen).
+		Order("id ASC").Find(&requests).Error
+}
+
+func (s *lyricRequestStore) List(ctx context.Context, filter LyricRequestFilter) ([]model.LyricRequest, int64, error) {
+	query := conn(ctx, s.db).Model(&model.LyricRequest{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if filter.ByVote {
+		query = query.Order("votes DESC")
+	}
+	var requests []model.LyricRequest
+	err := query.Order("id DESC").Offset(filter.Offset).Limit(filter.Limit).Find(&requests).Error
+	return requests, total, err
+}
+
+func (s *lyricRequestStore) Create(ctx context.Context, request *model.LyricRequest) (bool, error) {
+	result := conn(ctx, s.db).Clauses(clause.OnConflict{DoNothing: true}).Create(request)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (s *lyricRequestStore) Update(ctx context.Context, request *model.LyricRequest) error {
+	return conn(ctx, s.db).Save(request).Error
+}
+
+func (s *lyricRequestStore) ReopenByDraft(ctx context.Context, draftID uint) error {
+	return conn(ctx, s.db).Model(&model.LyricRequest{}).
+		Where("draft_id = ? AND status = ?", draftID, model.LyricRequestClaimed).
+		Updates(map[string]any{
+			"status":     model.LyricRequestOpen,
+			"claimed_by": 0,
+			"claimed_at": nil,
+			"draft_id":   nil,
+		}).Error
+}
+
+func (s *lyricRequestStore) AddVote(ctx context.Context, requestID, userID uint) (bool, error) {
+	db := conn(ctx, s.db)
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&model.LyricRequestVote{RequestID: requestID, UserID: userID})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false, result.Error
+	}
+	return true, db.Model(&model.LyricRequest{}).Where("id = ?", requestID).
+		UpdateColumn("votes", gorm.Expr("votes + 1")).Error
+}
+
+func (s *lyricRequestStore) RemoveVote(ctx context.Context, requestID, userID uint) (bool, error) {
+	db := conn(ctx, s.db)
+	result := db.Where("request_id = ? AND user_id = ?", requestID, userID).Delete(&model.LyricRequestVote{})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false, result.Error
+	}
+	return true, db.Model(&model.LyricRequest{}).Where("id = ? AND votes > 0", requestID).
+		UpdateColumn("votes", gorm.Expr("votes - 1")).Error
+}
+
+func (s *lyricRequestStore) VotedIDs(ctx context.Context, userID uint, requestIDs []uint) ([]uint, error) {
+	var ids []uint
+	if len(requestIDs) == 0 {
+		return ids, nil
+	}
+	return ids, conn(ctx, s.db).Model(&model.LyricRequestVote{}).
+		Where("user_id = ? AND request_id IN ?", userID, requestIDs).
+		Pluck("request_id", &ids).Error
+}
@@ -11,7 +11,10 @@ type PermissionStore interface {
 	GetByID(ctx context.Context, id uint) (*model.Permissions, error)
 	GetByName(ctx context.Context, name string) (*model.Permissions, error)
 	ListByIDs(ctx context.Context, ids []uint) ([]model.Permissions, error)
+	List(ctx context.Context) ([]model.Permissions, error)
 	Create(ctx context.Context, permission *model.Permissions) error
+	Update(ctx context.Context, permission *model.Permissions) error
+	Delete(ctx context.Context, id uint) error // 硬删除，名称可以重新使用
 }
 
 type permissionStore struct {
@@ -24,20 +27,31 @@ func NewPermissionStore(db *gorm.DB) PermissionStore {
 
 func (s *permissionStore) GetByID(ctx context.Context, id uint) (*model.Permissions, error) {
 	var permission model.Permissions
-	err := s.db.WithContext(ctx).Where("id = ?", id).First(&permission).Error
+	err := conn(ctx, s.db).Where("id = ?", id).First(&permission).Error
 	return &permission, err
 }
 func (s *permissionStore) GetByName(ctx context.Context, name string) (*model.Permissions, error) {
 	var permission model.Permissions
-	err := s.db.WithContext(ctx).Where("name = ?", name).First(&permission).Error
+	err := conn(ctx, s.db).Where("name = ?", name).First(&permission).Error
 	return &permission, err
 }
 func (s *permissionStore) ListByIDs(ctx context.Context, ids []uint) ([]model.Permissions, error) {
 	var permissions []model.Permissions
-	err := s.db.WithContext(ctx).Where("id IN ?", ids).Find(&permissions).Error
+	err := conn(ctx, s.db).Where("id IN ?", ids).Find(&permissions).Error
 	return permissions, err
 }
 
 func (s *permissionStore) Create(ctx context.Context, permission *model.Permissions) error {
-	return s.db.WithContext(ctx).Create(permission).Error
+	return conn(ctx, s.db).Create(permission).Error
+}
+func (s *permissionStore) List(ctx context.Context) ([]model.Permissions, error) {
+	var permissions []model.Permissions
+	err := conn(ctx, s.db).Order("id ASC").Find(&permissions).Error
+	return permissions, err
+}
+func (s *permissionStore) Update(ctx context.Context, permission *model.Permissions) error {
+	return conn(ctx, s.db).Select("name", "description").Updates(permission).Error
+}
+func (s *permissionStore) Delete(ctx context.Context, id uint) error {
+	return conn(ctx, s.db).Unscoped().Delete(&model.Permissions{}, id).Error
 }
@@ -0,0 +1,90 @@
+package store
+
+import (
+	"context"
+
+	"github.com/xiaowumin-mark/AMLX/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PublishStore interface {
+	CreateManifest(ctx context.Context, manifest *model.PublishManifest, songs []model.PublishManifestSong) error // 创建清单及其歌曲
+	ListManifests(ctx context.Context, after uint, limit int) ([]model.PublishManifest, error)                    // ID 大于 after 的清单，升序
+	ListSongs(ctx context.Context, manifestIDs []uint) ([]model.PublishManifestSong, error)                       // 清单涉及的歌曲
+	LatestBySongs(ctx context.Context, songs []model.PublishManifestSong) (*model.PublishManifest, error)         // 涉及任一歌曲的最新清单
+	LatestByDraft(ctx context.Context, draftID uint) (*model.PublishManifest, error)                              // 稿件的最新清单
+	LatestID(ctx context.Context) (uint, error)                                                                   // 最新清单 ID，没有清单时为 0
+	PutObject(ctx context.Context, object *model.PublishObject) error                                             // 保存对象，已存在时忽略
+	GetObject(ctx context.Context, hash string) (*model.PublishObject, error)                                     // 获取对象
+}
+
+type publishStore struct {
+	db *gorm.DB
+}
+
+func NewPublishStore(db *gorm.DB) PublishStore {
+	return &publishStore{db: db}
+}
+
+func (s *publishStore) CreateManifest(ctx context.Context, manifest *model.PublishManifest, songs []model.PublishManifestSong) error {
+	db := conn(ctx, s.db)
+	if err := db.Create(manifest).Error; err != nil {
+		return err
+	}
+	if len(songs) == 0 {
+		return nil
+	}
+	for i := range songs {
+		songs[i].ManifestID = manifest.ID
+	}
+	return db.Create(&songs).Error
+}
+
+func (s *publishStore) ListManifests(ctx context.Context, after uint, limit int) ([]model.PublishManifest, error) {
+	var manifests []model.PublishManifest
+	return manifests, conn(ctx, s.db).Where("id > ?", after).Order("id ASC").Limit(limit).Find(&manifests).Error
+}
+
+func (s *publishStore) ListSongs(ctx context.Context, manifestIDs []uint) ([]model.PublishManifestSong, error) {
+	var songs []model.PublishManifestSong
+	if len(manifestIDs) == 0 {
+		return songs, nil
+	}
+	return songs, conn(ctx, s.db).Where("manifest_id IN ?", manifestIDs).Order("id ASC").Find(&songs).Error
+}
+
+func (s *publishStore) LatestBySongs(ctx context.Context, songs []model.PublishManifestSong) (*model.PublishManifest, error) {
+	if len(songs) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	db := conn(ctx, s.db)
+	match := db.Where("platform = ? AND song_id = ?", songs[0].Platform, songs[0].SongID)
+	for _, song := range songs[1:] {
+		match = match.Or("platform = ? AND song_id = ?", song.Platform, song.SongID)
+	}
+	var manifest model.PublishManifest
+	return &manifest, db.
+		Where("id IN (?)", db.Model(&model.PublishManifestSong{}).Select("manifest_id").Where(match)).
+		Order("id DESC").
+		First(&manifest).Error
+}
+
+func (s *publishStore) LatestByDraft(ctx context.Context, draftID uint) (*model.PublishManifest, error) {
+	var manifest model.PublishManifest
+	return &manifest, conn(ctx, s.db).Where("draft_id = ?", draftID).Order("id DESC").First(&manifest).Error
+}
+
+func (s *publishStore) LatestID(ctx context.Context) (uint, error) {
+	var id uint
+	return id, conn(ctx, s.db).Model(&model.PublishManifest{}).Select("COALESCE(MAX(id), 0)").Scan(&id).Error
+}
+
+func (s *publishStore) PutObject(ctx context.Context, object *model.PublishObject) error {
+	return conn(ctx, s.db).Clauses(clause.OnConflict{DoNothing: true}).Create(object).Error
+}
+
+func (s *publishStore) GetObject(ctx context.Context, hash string) (*model.PublishObject, error) {
+	var object model.PublishObject
+	return &object, conn(ctx, s.db).Where("hash = ?", hash).First(&object).Error
+}
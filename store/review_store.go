@@ -0,0 +1,30 @@
+package store
+
+import (
+	"context"
+
+	"github.com/xiaowumin-mark/AMLX/model"
+	"gorm.io/gorm"
+)
+
+type ReviewStore interface {
+	Create(ctx context.Context, review *model.LyricsReview) error                // 创建
+	ListByDraft(ctx context.Context, draftID uint) ([]model.LyricsReview, error) // 列出稿件的审核记录
+}
+
+type reviewStore struct {
+	db *gorm.DB
+}
+
+func NewReviewStore(db *gorm.DB) ReviewStore {
+	return &reviewStore{db: db}
+}
+
+func (s *reviewStore) Create(ctx context.Context, review *model.LyricsReview) error {
+	return conn(ctx, s.db).Create(review).Error
+}
+
+func (s *reviewStore) ListByDraft(ctx context.Context, draftID uint) ([]model.LyricsReview, error) {
+	var reviews []model.LyricsReview
+	return reviews, conn(ctx, s.db).Where("draft_id = ?", draftID).Order("id ASC").Find(&reviews).Error
+}
@@ -12,6 +12,8 @@ type RolePermissionStore interface {
 	RemovePermission(ctx context.Context, roleID, permID uint) error
 	ListPermissionIDsByRole(ctx context.Context, roleID uint) ([]uint, error)
 	HasPermission(ctx context.Context, roleID uint, permName string) (bool, error)
+	DeleteByRole(ctx context.Context, roleID uint) error       // 删除角色的全部授权
+	DeleteByPermission(ctx context.Context, permID uint) error // 删除权限的全部授权
 }
 
 type rolePermissionStore struct {
@@ -42,3 +44,9 @@ func (s *rolePermissionStore) HasPermission(ctx context.Context, roleID uint, pe
 		Count(&count).Error
 	return count > 0, err
 }
+func (s *rolePermissionStore) DeleteByRole(ctx context.Context, roleID uint) error {
+	return conn(ctx, s.db).Unscoped().Where("role_id = ?", roleID).Delete(&model.RolePermissions{}).Error
+}
+func (s *rolePermissionStore) DeleteByPermission(ctx context.Context, permID uint) error {
+	return conn(ctx, s.db).Unscoped().Where("permission_id = ?", permID).Delete(&model.RolePermissions{}).Error
+}
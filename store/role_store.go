@@ -5,12 +5,17 @@ import (
 
 	"github.com/xiaowumin-mark/AMLX/model"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type RoleStore interface {
 	GetByID(ctx context.Context, id uint) (*model.Roles, error)
+	GetByIDForUpdate(ctx context.Context, id uint) (*model.Roles, error) // 获取并加行锁（需在事务中）
 	GetByName(ctx context.Context, name string) (*model.Roles, error)
+	List(ctx context.Context) ([]model.Roles, error)
 	Create(ctx context.Context, role *model.Roles) error
+	Update(ctx context.Context, role *model.Roles) error
+	Delete(ctx context.Context, id uint) error // 硬删除，名称可以重新使用
 }
 
 type roleStore struct {
@@ -23,12 +28,26 @@ func NewRoleStore(db *gorm.DB) RoleStore {
 
 func (s *roleStore) GetByID(ctx context.Context, id uint) (*model.Roles, error) {
 	var role model.Roles
-	return &role, s.db.WithContext(ctx).First(&role, id).Error
+	return &role, conn(ctx, s.db).First(&role, id).Error
+}
+func (s *roleStore) GetByIDForUpdate(ctx context.Context, id uint) (*model.Roles, error) {
+	var role model.Roles
+	return &role, conn(ctx, s.db).Clauses(clause.Locking{Strength: "UPDATE"}).First(&role, id).Error
 }
 func (s *roleStore) GetByName(ctx context.Context, name string) (*model.Roles, error) {
 	var role model.Roles
-	return &role, s.db.WithContext(ctx).Where("name = ?", name).First(&role).Error
+	return &role, conn(ctx, s.db).Where("name = ?", name).First(&role).Error
 }
 func (s *roleStore) Create(ctx context.Context, role *model.Roles) error {
-	return s.db.WithContext(ctx).Create(role).Error
+	return conn(ctx, s.db).Create(role).Error
+}
+func (s *roleStore) List(ctx context.Context) ([]model.Roles, error) {
+	var roles []model.Roles
+	return roles, conn(ctx, s.db).Order("id ASC").Find(&roles).Error
+}
+func (s *roleStore) Update(ctx context.Context, role *model.Roles) error {
+	return conn(ctx, s.db).Select("name", "description").Updates(role).Error
+}
+func (s *roleStore) Delete(ctx context.Context, id uint) error {
+	return conn(ctx, s.db).Unscoped().Delete(&model.Roles{}, id).Error
 }
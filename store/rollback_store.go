@@ -0,0 +1,49 @@
+package store
+
+import (
+	"context"
+
+	"github.com/xiaowumin-mark/AMLX/model"
+	"gorm.io/gorm"
+)
+
+type RollbackStore interface {
+	GetByID(ctx context.Context, id uint) (*model.StageRollback, error)                // 获取
+	GetPendingByDraft(ctx context.Context, draftID uint) (*model.StageRollback, error) // 稿件待处理的回退申请
+	ListByDraft(ctx context.Context, draftID uint) ([]model.StageRollback, error)      // 列出稿件的回退申请
+	Create(ctx context.Context, rollback *model.StageRollback) error                   // 创建
+	Update(ctx context.Context, rollback *model.StageRollback) error                   // 更新
+}
+
+type rollbackStore struct {
+	db *gorm.DB
+}
+
+func NewRollbackStore(db *gorm.DB) RollbackStore {
+	return &rollbackStore{db: db}
+}
+
+func (s *rollbackStore) GetByID(ctx context.Context, id uint) (*model.StageRollback, error) {
+	var rollback model.StageRollback
+	return &rollback, conn(ctx, s.db).First(&rollback, id).Error
+}
+
+func (s *rollbackStore) GetPendingByDraft(ctx context.Context, draftID uint) (*model.StageRollback, error) {
+	var rollback model.StageRollback
+	return &rollback, conn(ctx, s.db).
+		Where("draft_id = ? AND status = ?", draftID, model.RollbackPending).
+		First(&rollback).Error
+}
+
+func (s *rollbackStore) ListByDraft(ctx context.Context, draftID uint) ([]model.StageRollback, error) {
+	var rollbacks []model.StageRollback
+	return rollbacks, conn(ctx, s.db).Where("draft_id = ?", draftID).Order("id DESC").Find(&rollbacks).Error
+}
+
+func (s *rollbackStore) Create(ctx context.Context, rollback *model.StageRollback) error {
+	return conn(ctx, s.db).Create(rollback).Error
+}
+
+func (s *rollbackStore) Update(ctx context.Context, rollback *model.StageRollback) error {
+	return conn(ctx, s.db).Save(rollback).Error
+}
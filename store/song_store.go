@@ -0,0 +1,120 @@
+package store
+
+import (
+	"context"
+	"strings"
+
+	"github.com/xiaowumin-mark/AMLX/model"
+	"gorm.io/gorm"
+)
+
+// 歌曲查询条件
+type SongFilter struct {
+	Query  string // 标题包含
+	Offset int
+	Limit  int
+}
+
+type SongStore interface {
+	GetByID(ctx context.Context, id uint) (*model.Song, error)                                       // 获取
+	GetByKey(ctx context.Context, key string) (*model.Song, error)                                   // 按归一化键获取最早的一首
+	ListByIDs(ctx context.Context, ids []uint) ([]model.Song, error)                                 // 按 ID 批量获取
+	List(ctx context.Context, filter SongFilter) ([]model.Song, int64, error)                        // 列表
+	Create(ctx context.Context, song *model.Song) error                                              // 创建
+	Update(ctx context.Context, song *model.Song) error                                              // 整体保存
+	ListArtists(ctx context.Context, songID uint) ([]model.SongArtist, error)                        // 歌曲的艺术家，按角色与顺序排列
+	ReplaceArtists(ctx context.Context, songID uint, artists []model.SongArtist) error               // 替换歌曲的艺术家（需在事务中）
+	GetByExternalID(ctx context.Context, platform, externalID string) (*model.SongExternalID, error) // 按平台 ID 获取
+	ListExternalIDs(ctx context.Context, songID uint) ([]model.SongExternalID, error)                // 歌曲的平台 ID
+	AddExternalID(ctx context.Context, id *model.SongExternalID) error                               // 添加平台 ID
+	DeleteExternalIDs(ctx context.Context, songID uint) error                                        // 删除歌曲的全部平台 ID
+}
+
+type songStore struct {
+	db *gorm.DB
+}
+
+func NewSongStore(db *gorm.DB) SongStore {
+	return &songStore{db: db}
+}
+
+func (s *songStore) GetByID(ctx context.Context, id uint) (*model.Song, error) {
+	var song model.Song
+	return &song, conn(ctx, s.db).First(&song, id).Error
+}
+
+func (s *songStore) GetByKey(ctx context.Context, key string) (*model.Song, error) {
+	var song model.Song
+	return &song, conn(ctx, s.db).Where("normalized_key = ?", key).Order("id ASC").First(&song).Error
+}
+
+func (s *songStore) ListByIDs(ctx context.Context, ids []uint) ([]model.Song, error) {
+	var songs []model.Song
+	if len(ids) == 0 {
+		return songs, nil
+	}
+	return songs, conn(ctx, s.db).Where("id IN ?", ids).Find(&songs).Error
+}
+
+func (s *songStore) List(ctx context.Context, filter SongFilter) ([]model.Song, int64, error) {
+	query := conn(ctx, s.db).Model(&model.Song{})
+	if filter.Query != "" {
+		query = query.Where("title LIKE ?", "%"+escapeLike(filter.Query)+"%")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var songs []model.Song
+	err := query.Order("id DESC").Offset(filter.Offset).Limit(filter.Limit).Find(&songs).Error
+	return songs, total, err
+}
+
+func (s *songStore) Create(ctx context.Context, song *model.Song) error {
+	return conn(ctx, s.db).Create(song).Error
+}
+
+func (s *songStore) Update(ctx context.Context, song *model.Song) error {
+	return conn(ctx, s.db).Save(song).Error
+}
+
+func (s *songStore) ListArtists(ctx context.Context, songID uint) ([]model.SongArtist, error) {
+	var artists []model.SongArtist
+	return artists, conn(ctx, s.db).Where("song_id = ?", songID).
+		Order("FIELD(role, 'PRIMARY', 'FEATURED', 'COMPOSER', 'LYRICIST'), position ASC").Find(&artists).Error
+}
+
+func (s *songStore) ReplaceArtists(ctx context.Context, songID uint, artists []model.SongArtist) error {
+	db := conn(ctx, s.db)
+	if err := db.Where("song_id = ?", songID).Delete(&model.SongArtist{}).Error; err != nil {
+		return err
+	}
+	if len(artists) == 0 {
+		return nil
+	}
+	return db.Create(&artists).Error
+}
+
+func (s *songStore) GetByExternalID(ctx context.Context, platform, externalID string) (*model.SongExternalID, error) {
+	var id model.SongExternalID
+	return &id, conn(ctx, s.db).Where("platform = ? AND external_id = ?", platform, externalID).First(&id).Error
+}
+
+func (s *songStore) ListExternalIDs(ctx context.Context, songID uint) ([]model.SongExternalID, error) {
+	var ids []model.SongExternalID
+	return ids, conn(ctx, s.db).Where("song_id = ?", songID).Order("id ASC").Find(&ids).Error
+}
+
+func (s *songStore) AddExternalID(ctx context.Context, id *model.SongExternalID) error {
+	return conn(ctx, s.db).Create(id).Error
+}
+
+func (s *songStore) DeleteExternalIDs(ctx context.Context, songID uint) error {
+	return conn(ctx, s.db).Where("song_id = ?", songID).Delete(&model.SongExternalID{}).Error
+}
+
+// 转义 LIKE 通配符
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
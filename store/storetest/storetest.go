@@ -0,0 +1,319 @@
+// Package storetest 提供内存中的 store 实现，供服务与处理器的测试使用。
+//
+// 只实现测试用到的方法，未实现的方法由内嵌的 nil 接口触发 panic；没有事务，Tx 直接执行。
+package storetest
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/xiaowumin-mark/AMLX/model"
+	"github.com/xiaowumin-mark/AMLX/store"
+	"gorm.io/gorm"
+)
+
+// 直接执行，不提供回滚
+type Tx struct{}
+
+func (Tx) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+type DraftStore struct {
+	store.DraftStore
+	mu     sync.Mutex
+	nextID uint
+	drafts map[uint]model.LyricsDraft
+}
+
+func NewDraftStore() *DraftStore {
+	return &DraftStore{drafts: map[uint]model.LyricsDraft{}}
+}
+
+func (s *DraftStore) GetByID(ctx context.Context, id uint) (*model.LyricsDraft, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	draft, ok := s.drafts[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &draft, nil
+}
+
+func (s *DraftStore) GetByIDForUpdate(ctx context.Context, id uint) (*model.LyricsDraft, error) {
+	return s.GetByID(ctx, id)
+}
+
+// 按 ID 降序
+func (s *DraftStore) List(ctx context.Context, filter store.DraftFilter) ([]model.LyricsDraft, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var result []model.LyricsDraft
+	for _, draft := range s.drafts {
+		if filter.Status != "" && draft.Status != filter.Status ||
+			filter.OwnerUserID != 0 && draft.OwnerUserID != filter.OwnerUserID ||
+			filter.PRState != "" && draft.GithubPRState != filter.PRState {
+			continue
+		}
+		result = append(result, draft)
+	}
+	slices.SortFunc(result, func(a, b model.LyricsDraft) int { return int(b.ID) - int(a.ID) })
+	total := int64(len(result))
+	result = result[min(filter.Offset, len(result)):]
+	if filter.Limit > 0 && len(result) > filter.Limit {
+		result = result[:filter.Limit]
+	}
+	return result, total, nil
+}
+
+func (s *DraftStore) Create(ctx context.Context, draft *model.LyricsDraft) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	draft.ID = s.nextID
+	draft.CreatedAt, draft.UpdatedAt = time.Now(), time.Now()
+	s.drafts[draft.ID] = *draft
+	return nil
+}
+
+func (s *DraftStore) Update(ctx context.Context, draft *model.LyricsDraft) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	draft.UpdatedAt = time.Now()
+	s.drafts[draft.ID] = *draft
+	return nil
+}
+
+func (s *DraftStore) UpdateState(ctx context.Context, draft *model.LyricsDraft) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored := s.drafts[draft.ID]
+	stored.Status, stored.WorkflowStage, stored.ReviewSnapshotID = draft.Status, draft.WorkflowStage, draft.ReviewSnapshotID
+	stored.RejectCount, stored.LastRejectAt = draft.RejectCount, draft.LastRejectAt
+	stored.UpdatedAt = time.Now()
+	s.drafts[draft.ID] = stored
+	return nil
+}
+
+func (s *DraftStore) UpdatePullRequest(ctx context.Context, draft *model.LyricsDraft) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored := s.drafts[draft.ID]
+	stored.GithubPRURL, stored.GithubPRNumber, stored.GithubPRState = draft.GithubPRURL, draft.GithubPRNumber, draft.GithubPRState
+	s.drafts[draft.ID] = stored
+	return nil
+}
+
+func (s *DraftStore) GetByPullRequest(ctx context.Context, number int) (*model.LyricsDraft, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, draft := range s.drafts {
+		if draft.GithubPRNumber == number {
+			return &draft, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+// 直接写入稿件，ID 由调用方指定
+func (s *DraftStore) Put(draft model.LyricsDraft) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID = max(s.nextID, draft.ID)
+	s.drafts[draft.ID] = draft
+}
+
+func (s *DraftStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.drafts)
+}
+
+type VersionStore struct {
+	store.VersionStore
+	mu       sync.Mutex
+	nextID   uint
+	versions map[uint]model.LyricsVersion
+}
+
+func NewVersionStore() *VersionStore {
+	return &VersionStore{versions: map[uint]model.LyricsVersion{}}
+}
+
+func (s *VersionStore) GetByID(ctx context.Context, id uint) (*model.LyricsVersion, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	version, ok := s.versions[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &version, nil
+}
+
+func (s *VersionStore) Create(ctx context.Context, version *model.LyricsVersion) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	version.ID = s.nextID
+	version.CreatedAt = time.Now()
+	s.versions[version.ID] = *version
+	return nil
+}
+
+type SyncRecordStore struct {
+	mu      sync.Mutex
+	records []model.LyricsSyncRecord
+}
+
+func (s *SyncRecordStore) List(ctx context.Context) ([]model.LyricsSyncRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.records), nil
+}
+
+func (s *SyncRecordStore) Create(ctx context.Context, record *model.LyricsSyncRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record.ID = uint(len(s.records) + 1)
+	s.records = append(s.records, *record)
+	return nil
+}
+
+func (s *SyncRecordStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.records)
+}
+
+type TransitionStore struct {
+	store.DraftTransitionStore
+	mu          sync.Mutex
+	transitions []model.DraftTransition
+}
+
+func (s *TransitionStore) Create(ctx context.Context, transition *model.DraftTransition) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	transition.ID = uint(len(s.transitions) + 1)
+	transition.CreatedAt, transition.UpdatedAt = time.Now(), time.Now()
+	s.transitions = append(s.transitions, *transition)
+	return nil
+}
+
+func (s *TransitionStore) ListByDraft(ctx context.Context, draftID uint) ([]model.DraftTransition, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var result []model.DraftTransition
+	for _, transition := range s.transitions {
+		if transition.DraftID == draftID {
+			result = append(result, transition)
+		}
+	}
+	return result, nil
+}
+
+type PublishStore struct {
+	mu        sync.Mutex
+	manifests []model.PublishManifest // ID 即下标加一
+	songs     []model.PublishManifestSong
+	objects   map[string]model.PublishObject
+}
+
+func NewPublishStore() *PublishStore {
+	return &PublishStore{objects: map[string]model.PublishObject{}}
+}
+
+func (s *PublishStore) CreateManifest(ctx context.Context, manifest *model.PublishManifest, songs []model.PublishManifestSong) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	manifest.ID = uint(len(s.manifests) + 1)
+	manifest.CreatedAt, manifest.UpdatedAt = time.Now(), time.Now()
+	s.manifests = append(s.manifests, *manifest)
+	for i := range songs {
+		songs[i].ID = uint(len(s.songs) + 1)
+		songs[i].ManifestID = manifest.ID
+		s.songs = append(s.songs, songs[i])
+	}
+	return nil
+}
+
+func (s *PublishStore) ListManifests(ctx context.Context, after uint, limit int) ([]model.PublishManifest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := slices.Clone(s.manifests[min(int(after), len(s.manifests)):])
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+func (s *PublishStore) ListSongs(ctx context.Context, manifestIDs []uint) ([]model.PublishManifestSong, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var result []model.PublishManifestSong
+	for _, song := range s.songs {
+		if slices.Contains(manifestIDs, song.ManifestID) {
+			result = append(result, song)
+		}
+	}
+	return result, nil
+}
+
+func (s *PublishStore) LatestBySongs(ctx context.Context, songs []model.PublishManifestSong) (*model.PublishManifest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var latest uint
+	for _, row := range s.songs {
+		for _, song := range songs {
+			if row.Platform == song.Platform && row.SongID == song.SongID {
+				latest = max(latest, row.ManifestID)
+			}
+		}
+	}
+	if latest == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	manifest := s.manifests[latest-1]
+	return &manifest, nil
+}
+
+func (s *PublishStore) LatestByDraft(ctx context.Context, draftID uint) (*model.PublishManifest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := len(s.manifests) - 1; i >= 0; i-- {
+		if s.manifests[i].DraftID == draftID {
+			manifest := s.manifests[i]
+			return &manifest, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (s *PublishStore) LatestID(ctx context.Context) (uint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return uint(len(s.manifests)), nil
+}
+
+// 已存在时忽略
+func (s *PublishStore) PutObject(ctx context.Context, object *model.PublishObject) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.objects[object.Hash]; !ok {
+		object.CreatedAt = time.Now()
+		s.objects[object.Hash] = *object
+	}
+	return nil
+}
+
+func (s *PublishStore) GetObject(ctx context.Context, hash string) (*model.PublishObject, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	object, ok := s.objects[hash]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &object, nil
+}
@@ -0,0 +1,30 @@
+package store
+
+import (
+	"context"
+
+	"github.com/xiaowumin-mark/AMLX/model"
+	"gorm.io/gorm"
+)
+
+type SyncRecordStore interface {
+	List(ctx context.Context) ([]model.LyricsSyncRecord, error)       // 全部同步记录
+	Create(ctx context.Context, record *model.LyricsSyncRecord) error // 创建
+}
+
+type syncRecordStore struct {
+	db *gorm.DB
+}
+
+func NewSyncRecordStore(db *gorm.DB) SyncRecordStore {
+	return &syncRecordStore{db: db}
+}
+
+func (s *syncRecordStore) List(ctx context.Context) ([]model.LyricsSyncRecord, error) {
+	var records []model.LyricsSyncRecord
+	return records, conn(ctx, s.db).Order("id ASC").Find(&records).Error
+}
+
+func (s *syncRecordStore) Create(ctx context.Context, record *model.LyricsSyncRecord) error {
+	return conn(ctx, s.db).Create(record).Error
+}
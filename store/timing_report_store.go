@@ -0,0 +1,80 @@
+package store
+
+import (
+	"context"
+
+	"github.com/xiaowumin-mark/AMLX/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 某一行的反馈数
+type TimingLineCount struct {
+	LineNumber uint
+	Reports    uint
+}
+
+type TimingReportStore interface {
+	Upsert(ctx context.Context, report *model.TimingReport) error                                    // 创建，已存在时更新偏移与说明
+	Summarize(ctx context.Context, versionID uint) (*model.VersionTimingStat, error)                 // 按反馈重新计算版本汇总（不含 FixDraftID）
+	CountLines(ctx context.Context, versionID uint) ([]TimingLineCount, error)                       // 各行的反馈数，按行号排列
+	GetStat(ctx context.Context, versionID uint) (*model.VersionTimingStat, error)                   // 获取版本汇总
+	GetStatForUpdate(ctx context.Context, versionID, draftID uint) (*model.VersionTimingStat, error) // 加锁读取版本汇总，不存在时创建（需在事务中）
+	SaveStat(ctx context.Context, stat *model.VersionTimingStat) error                               // 保存版本汇总
+}
+
+type timingReportStore struct {
+	db *gorm.DB
+}
+
+func NewTimingReportStore(db *gorm.DB) TimingReportStore {
+	return &timingReportStore{db: db}
+}
+
+func (s *timingReportStore) Upsert(ctx context.Context, report *model.TimingReport) error {
+	return conn(ctx, s.db).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "version_id"}, {Name: "user_id"}, {Name: "kind"}, {Name: "line_number"}},
+		DoUpdates: clause.AssignmentColumns([]string{"offset_ms", "comment", "updated_at"}),
+	}).Create(report).Error
+}
+
+func (s *timingReportStore) Summarize(ctx context.Context, versionID uint) (*model.VersionTimingStat, error) {
+	var stat model.VersionTimingStat
+	err := conn(ctx, s.db).Model(&model.TimingReport{}).
+		Select("COUNT(DISTINCT user_id) AS reporters, "+
+			"COALESCE(SUM(CASE WHEN kind = ? THEN 1 ELSE 0 END), 0) AS offset_reports, "+
+			"COALESCE(SUM(CASE WHEN kind = ? THEN offset_ms ELSE 0 END), 0) AS offset_sum_ms, "+
+			"COALESCE(SUM(CASE WHEN kind = ? THEN 1 ELSE 0 END), 0) AS line_reports",
+			model.TimingReportOffset, model.TimingReportOffset, model.TimingReportLine).
+		Where("version_id = ?", versionID).
+		Scan(&stat).Error
+	stat.VersionID = versionID
+	return &stat, err
+}
+
+func (s *timingReportStore) CountLines(ctx context.Context, versionID uint) ([]TimingLineCount, error) {
+	var counts []TimingLineCount
+	return counts, conn(ctx, s.db).Model(&model.TimingReport{}).
+		Select("line_number, COUNT(*) AS reports").
+		Where("version_id = ? AND kind = ?", versionID, model.TimingReportLine).
+		Group("line_number").Order("line_number ASC").
+		Scan(&counts).Error
+}
+
+func (s *timingReportStore) GetStat(ctx context.Context, versionID uint) (*model.VersionTimingStat, error) {
+	var stat model.VersionTimingStat
+	return &stat, conn(ctx, s.db).Where("version_id = ?", versionID).First(&stat).Error
+}
+
+func (s *timingReportStore) GetStatForUpdate(ctx context.Context, versionID, draftID uint) (*model.VersionTimingStat, error) {
+	db := conn(ctx, s.db)
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.VersionTimingStat{VersionID: versionID, DraftID: draftID}).Error; err != nil {
+		return nil, err
+	}
+	var stat model.VersionTimingStat
+	return &stat, db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("version_id = ?", versionID).First(&stat).Error
+}
+
+func (s *timingReportStore) SaveStat(ctx context.Context, stat *model.VersionTimingStat) error {
+	return conn(ctx, s.db).Save(stat).Error
+}
@@ -0,0 +1,113 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/xiaowumin-mark/AMLX/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 汇总查询条件
+type UsageFilter struct {
+	Granularity model.UsageGranularity
+	Since       *time.Time // 为空时为全部
+	Types       []model.UsageEventType
+	Format      string
+	Region      string
+	Limit       int
+}
+
+type UsageStore interface {
+	AddEvents(ctx context.Context, events []model.UsageEvent) error                           // 追加事件
+	ListEventsAfter(ctx context.Context, afterID uint, limit int) ([]model.UsageEvent, error) // ID 大于 afterID 的事件，按 ID 升序
+	AddRollups(ctx context.Context, rollups []model.UsageRollup) error                        // 累加到汇总
+	GetCursorForUpdate(ctx context.Context, name string) (*model.UsageCursor, error)          // 加锁读取汇总进度，不存在时创建（需在事务中）
+	SaveCursor(ctx context.Context, cursor *model.UsageCursor) error                          // 保存汇总进度
+	TopSongs(ctx context.Context, filter UsageFilter) ([]model.UsageRollup, error)            // 按歌曲合计排序，只返回 SongID 与 Count
+	TopExternalIDs(ctx context.Context, filter UsageFilter) ([]model.UsageRollup, error)      // 按平台 ID 合计排序，只返回 Platform、ExternalID、SongID 与 Count
+}
+
+type usageStore struct {
+	db *gorm.DB
+}
+
+func NewUsageStore(db *gorm.DB) UsageStore {
+	return &usageStore{db: db}
+}
+
+func (s *usageStore) AddEvents(ctx context.Context, events []model.UsageEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	return conn(ctx, s.db).Create(&events).Error
+}
+
+func (s *usageStore) ListEventsAfter(ctx context.Context, afterID uint, limit int) ([]model.UsageEvent, error) {
+	var events []model.UsageEvent
+	return events, conn(ctx, s.db).Where("id > ?", afterID).Order("id ASC").Limit(limit).Find(&events).Error
+}
+
+func (s *usageStore) AddRollups(ctx context.Context, rollups []model.UsageRollup) error {
+	if len(rollups) == 0 {
+		return nil
+	}
+	return conn(ctx, s.db).Clauses(clause.OnConflict{
+		Columns: []clause.Column{
+			{Name: "granularity"}, {Name: "bucket_start"}, {Name: "type"}, {Name: "song_id"},
+			{Name: "platform"}, {Name: "external_id"}, {Name: "format"}, {Name: "region"},
+		},
+		DoUpdates: clause.Assignments(map[string]any{"count": gorm.Expr("count + VALUES(count)")}),
+	}).Create(&rollups).Error
+}
+
+func (s *usageStore) GetCursorForUpdate(ctx context.Context, name string) (*model.UsageCursor, error) {
+	db := conn(ctx, s.db)
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.UsageCursor{Name: name}).Error; err != nil {
+		return nil, err
+	}
+	var cursor model.UsageCursor
+	return &cursor, db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("name = ?", name).First(&cursor).Error
+}
+
+func (s *usageStore) SaveCursor(ctx context.Context, cursor *model.UsageCursor) error {
+	return conn(ctx, s.db).Save(cursor).Error
+}
+
+func (s *usageStore) TopSongs(ctx context.Context, filter UsageFilter) ([]model.UsageRollup, error) {
+	var rollups []model.UsageRollup
+	return rollups, s.filtered(ctx, filter).
+		Select("song_id, SUM(count) AS count").
+		Where("song_id <> 0").
+		Group("song_id").
+		Order("count DESC, song_id ASC").
+		Limit(filter.Limit).Scan(&rollups).Error
+}
+
+func (s *usageStore) TopExternalIDs(ctx context.Context, filter UsageFilter) ([]model.UsageRollup, error) {
+	var rollups []model.UsageRollup
+	return rollups, s.filtered(ctx, filter).
+		Select("platform, external_id, MAX(song_id) AS song_id, SUM(count) AS count").
+		Where("external_id <> ''").
+		Group("platform, external_id").
+		Order("count DESC, platform ASC, external_id ASC").
+		Limit(filter.Limit).Scan(&rollups).Error
+}
+
+func (s *usageStore) filtered(ctx context.Context, filter UsageFilter) *gorm.DB {
+	query := conn(ctx, s.db).Model(&model.UsageRollup{}).Where("granularity = ?", filter.Granularity)
+	if filter.Since != nil {
+		query = query.Where("bucket_start >= ?", *filter.Since)
+	}
+	if len(filter.Types) > 0 {
+		query = query.Where("type IN ?", filter.Types)
+	}
+	if filter.Format != "" {
+		query = query.Where("format = ?", filter.Format)
+	}
+	if filter.Region != "" {
+		query = query.Where("region = ?", filter.Region)
+	}
+	return query
+}
@@ -14,6 +14,9 @@ type UserStore interface {
 	Update(ctx context.Context, user *model.Users) error
 	SetBan(ctx context.Context, id uint, ban bool) error
 	Count(ctx context.Context) (int64, error)
+	ListByIDs(ctx context.Context, ids []uint) ([]model.Users, error)
+	CountByRole(ctx context.Context, roleID uint) (int64, error)
+	ReassignRole(ctx context.Context, fromRoleID, toRoleID uint) (int64, error) // 返回转移的用户数
 }
 
 type userStore struct {
@@ -47,3 +50,22 @@ func (s *userStore) Count(ctx context.Context) (int64, error) {
 	err := s.db.WithContext(ctx).Model(&model.Users{}).Count(&count).Error
 	return count, err
 }
+
+func (s *userStore) ListByIDs(ctx context.Context, ids []uint) ([]model.Users, error) {
+	var users []model.Users
+	if len(ids) == 0 {
+		return users, nil
+	}
+	return users, s.db.WithContext(ctx).Where("id IN ?", ids).Find(&users).Error
+}
+
+func (s *userStore) CountByRole(ctx context.Context, roleID uint) (int64, error) {
+	var count int64
+	err := conn(ctx, s.db).Model(&model.Users{}).Where("role_id = ?", roleID).Count(&count).Error
+	return count, err
+}
+
+func (s *userStore) ReassignRole(ctx context.Context, fromRoleID, toRoleID uint) (int64, error) {
+	result := conn(ctx, s.db).Model(&model.Users{}).Where("role_id = ?", fromRoleID).Update("role_id", toRoleID)
+	return result.RowsAffected, result.Error
+}
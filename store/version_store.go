@@ -0,0 +1,94 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/xiaowumin-mark/AMLX/model"
+	"gorm.io/gorm"
+)
+
+var ErrSnapshotImmutable = errors.New("snapshot version is immutable")
+
+type VersionStore interface {
+	GetByID(ctx context.Context, id uint) (*model.LyricsVersion, error)                                              // 获取
+	ListByDraft(ctx context.Context, draftID uint) ([]model.LyricsVersion, error)                                    // 列出稿件版本（不含内容）
+	GetHead(ctx context.Context, draftID uint) (*model.LyricsVersion, error)                                         // 最新的非快照版本
+	Create(ctx context.Context, version *model.LyricsVersion) error                                                  // 创建
+	Update(ctx context.Context, version *model.LyricsVersion) error                                                  // 更新（快照拒绝）
+	Delete(ctx context.Context, id uint) error                                                                       // 删除（快照拒绝）
+	DeleteByStages(ctx context.Context, draftID uint, stages []model.WorkflowStage, since *time.Time) (int64, error) // 删除指定阶段的非快照版本，since 不为空时只删除此后创建的版本
+}
+
+type versionStore struct {
+	db *gorm.DB
+}
+
+func NewVersionStore(db *gorm.DB) VersionStore {
+	return &versionStore{db: db}
+}
+
+func (s *versionStore) GetByID(ctx context.Context, id uint) (*model.LyricsVersion, error) {
+	var version model.LyricsVersion
+	return &version, conn(ctx, s.db).First(&version, id).Error
+}
+
+func (s *versionStore) GetHead(ctx context.Context, draftID uint) (*model.LyricsVersion, error) {
+	var version model.LyricsVersion
+	return &version, conn(ctx, s.db).
+		Where("draft_id = ? AND is_snapshot = ?", draftID, false).
+		Order("id DESC").
+		First(&version).Error
+}
+
+func (s *versionStore) ListByDraft(ctx context.Context, draftID uint) ([]model.LyricsVersion, error) {
+	var versions []model.LyricsVersion
+	return versions, conn(ctx, s.db).Omit("content").Where("draft_id = ?", draftID).Order("id DESC").Find(&versions).Error
+}
+
+func (s *versionStore) Create(ctx context.Context, version *model.LyricsVersion) error {
+	return conn(ctx, s.db).Create(version).Error
+}
+
+func (s *versionStore) Update(ctx context.Context, version *model.LyricsVersion) error {
+	if err := s.ensureMutable(ctx, version.ID); err != nil {
+		return err
+	}
+	return conn(ctx, s.db).Model(version).
+		Where("is_snapshot = ?", false).
+		Select("workflow_stage", "content").
+		Updates(version).Error
+}
+
+func (s *versionStore) Delete(ctx context.Context, id uint) error {
+	if err := s.ensureMutable(ctx, id); err != nil {
+		return err
+	}
+	return conn(ctx, s.db).Where("is_snapshot = ?", false).Delete(&model.LyricsVersion{}, id).Error
+}
+
+func (s *versionStore) DeleteByStages(ctx context.Context, draftID uint, stages []model.WorkflowStage, since *time.Time) (int64, error) {
+	if len(stages) == 0 {
+		return 0, nil
+	}
+	db := conn(ctx, s.db).Where("draft_id = ? AND is_snapshot = ? AND workflow_stage IN ?", draftID, false, stages)
+	if since != nil {
+		db = db.Where("created_at >= ?", *since)
+	}
+	result := db.Delete(&model.LyricsVersion{})
+	return result.RowsAffected, result.Error
+}
+
+// 快照版本一经创建不可修改或删除
+func (s *versionStore) ensureMutable(ctx context.Context, id uint) error {
+	var isSnapshot bool
+	err := conn(ctx, s.db).Model(&model.LyricsVersion{}).Where("id = ?", id).Select("is_snapshot").Scan(&isSnapshot).Error
+	if err != nil {
+		return err
+	}
+	if isSnapshot {
+		return ErrSnapshotImmutable
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+// Package ttmldb 读写 amll-ttml-db 仓库的本地克隆。
+//
+// 只通过 git 底层命令读取分支内容和提交（临时暂存区 + commit-tree + update-ref），
+// 不切换分支也不改动工作区，克隆可以同时被人工使用。
+package ttmldb
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 投稿原始文件目录，各平台目录由仓库自身的工具生成
+const RawDir = "raw-lyrics"
+
+var (
+	ErrNotRepository = errors.New("ttmldb: not a git repository")
+	ErrBranchMoved   = errors.New("ttmldb: branch moved during commit")
+)
+
+// 本地克隆
+type Repo struct {
+	Dir         string
+	AuthorName  string
+	AuthorEmail string
+}
+
+// 仓库中的一个文件
+type File struct {
+	Path    string // 仓库内相对路径，使用 /
+	Content []byte
+}
+
+// 文件写入
+type Change struct {
+	Path    string
+	Content []byte
+}
+
+func Open(dir, authorName, authorEmail string) (*Repo, error) {
+	repo := &Repo{Dir: dir, AuthorName: authorName, AuthorEmail: authorEmail}
+	if _, err := repo.git(nil, nil, "rev-parse", "--git-dir"); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrNotRepository, dir)
+	}
+	return repo, nil
+}
+
+// 解析提交，引用不存在时返回空串
+func (r *Repo) Resolve(ref string) (string, error) {
+	out, err := r.git(nil, nil, "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return "", nil
+		}
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// 列出提交中 RawDir 下的全部 .ttml 文件，内容由一次 cat-file --batch 读取
+func (r *Repo) RawFiles(commit string) ([]File, error) {
+	out, err := r.git(nil, nil, "ls-tree", "-r", "-z", commit, "--", RawDir+"/")
+	if err != nil {
+		return nil, err
+	}
+	var files []File
+	var objects strings.Builder
+	for _, entry := range strings.Split(out, "\x00") {
+		// <mode> SP <type> SP <object> TAB <path>
+		info, name, ok := strings.Cut(entry, "\t")
+		fields := strings.Fields(info)
+		if !ok || len(fields) != 3 || fields[1] != "blob" || !strings.HasSuffix(name, ".ttml") {
+			continue
+		}
+		files = append(files, File{Path: name})
+		objects.WriteString(fields[2] + "\n")
+	}
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	out, err = r.git(nil, []byte(objects.String()), "cat-file", "--batch")
+	if err != nil {
+		return nil, err
+	}
+	// 每个对象为 <object> SP <type> SP <size> LF <content> LF
+	for i := range files {
+		header, rest, ok := strings.Cut(out, "\n")
+		fields := strings.Fields(header)
+		if !ok || len(fields) != 3 {
+			return nil, fmt.Errorf("ttmldb: unexpected cat-file output for %s: %q", files[i].Path, header)
+		}
+		size, err := strconv.Atoi(fields[2])
+		if err != nil || size+1 > len(rest) {
+			return nil, fmt.Errorf("ttmldb: unexpected cat-file output for %s: %q", files[i].Path, header)
+		}
+		files[i].Content = []byte(rest[:size])
+		out = rest[size+1:]
+	}
+	return files, nil
+}
+
+// 在 parent 之上提交一组文件并把分支指向新提交，parent 为空时创建无父提交。
+// 分支不存在时创建；已存在时必须指向 parent，否则返回 ErrBranchMoved
+func (r *Repo) Commit(branch, parent, message string, changes []Change) (string, error) {
+	ref := "refs/heads/" + branch
+	old, err := r.Resolve(ref)
+	if err != nil {
+		return "", err
+	}
+	if old != "" && old != parent {
+		return "", ErrBranchMoved
+	}
+	if old == "" {
+		old = strings.Repeat("0", 40) // 要求分支仍不存在
+	}
+
+	index, err := os.CreateTemp("", "amlx-index-*")
+	if err != nil {
+		return "", err
+	}
+	index.Close()
+	os.Remove(index.Name()) // git 需要自己创建暂存区文件
+	defer os.Remove(index.Name())
+	env := []string{"GIT_INDEX_FILE=" + index.Name()}
+
+	if parent != "" {
+		if _, err := r.git(env, nil, "read-tree", parent); err != nil {
+			return "", err
+		}
+	}
+	for _, change := range changes {
+		blob, err := r.git(env, change.Content, "hash-object", "-w", "--stdin")
+		if err != nil {
+			return "", err
+		}
+		info := "100644," + strings.TrimSpace(blob) + "," + change.Path
+		if _, err := r.git(env, nil, "update-index", "--add", "--cacheinfo", info); err != nil {
+			return "", err
+		}
+	}
+	tree, err := r.git(env, nil, "write-tree")
+	if err != nil {
+		return "", err
+	}
+
+	args := []string{"commit-tree", strings.TrimSpace(tree), "-m", message}
+	if parent != "" {
+		args = append(args, "-p", parent)
+	}
+	commit, err := r.git(r.authorEnv(), nil, args...)
+	if err != nil {
+		return "", err
+	}
+	commit = strings.TrimSpace(commit)
+
+	if _, err := r.git(nil, nil, "update-ref", ref, commit, old); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrBranchMoved, err)
+	}
+	return commit, nil
+}
+
+// 按仓库约定生成投稿文件路径：raw-lyrics/<毫秒时间戳>-<投稿人 ID>-<8 位随机串>.ttml。
+// 随机串取内容哈希前 8 位，同一内容得到相同路径
+func RawPath(at time.Time, contributorID string, content []byte) string {
+	return path.Join(RawDir, fmt.Sprintf("%d-%s-%s.ttml", at.UnixMilli(), contributorID, Hash(content)[:8]))
+}
+
+// 文件内容的 SHA-256
+func Hash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+func (r *Repo) authorEnv() []string {
+	return []string{
+		"GIT_AUTHOR_NAME=" + r.AuthorName, "GIT_AUTHOR_EMAIL=" + r.AuthorEmail,
+		"GIT_COMMITTER_NAME=" + r.AuthorName, "GIT_COMMITTER_EMAIL=" + r.AuthorEmail,
+	}
+}
+
+func (r *Repo) git(env []string, stdin []byte, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", filepath.Clean(r.Dir)}, args...)...)
+	cmd.Env = append(os.Environ(), env...)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %w: %s", args[0], err, msg)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,125 @@
+package ttmldb
+
+import (
+	"errors"
+	"os/exec"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// 在临时目录中初始化空仓库
+func initRepo(t *testing.T) *Repo {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	if out, err := exec.Command("git", "init", "--quiet", dir).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v: %s", err, out)
+	}
+	repo, err := Open(dir, "AMLX", "amlx@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return repo
+}
+
+func TestOpenRejectsNonRepository(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	if _, err := Open(t.TempDir(), "", ""); !errors.Is(err, ErrNotRepository) {
+		t.Fatalf("got %v, want ErrNotRepository", err)
+	}
+}
+
+func TestCommitAndRawFiles(t *testing.T) {
+	repo := initRepo(t)
+	if commit, err := repo.Resolve("main"); err != nil || commit != "" {
+		t.Fatalf("missing branch resolved to %q, %v", commit, err)
+	}
+
+	first := []Change{
+		{Path: RawDir + "/1700000000000-1-aaaaaaaa.ttml", Content: []byte("<tt>first</tt>")},
+		{Path: RawDir + "/README.md", Content: []byte("not lyrics")},
+		{Path: "ncm-lyrics/1.ttml", Content: []byte("<tt>generated</tt>")},
+	}
+	base, err := repo.Commit("main", "", "Initial", first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second := []Change{
+		{Path: RawDir + "/1700000000001-2-bbbbbbbb.ttml", Content: []byte("<tt>second\nwith\x00binary</tt>")},
+		{Path: RawDir + "/nested/with space.ttml", Content: []byte{}},
+	}
+	head, err := repo.Commit("main", base, "Add lyrics", second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolved, err := repo.Resolve("main"); err != nil || resolved != head {
+		t.Fatalf("main resolved to %q, %v; want %q", resolved, err, head)
+	}
+
+	files, err := repo.RawFiles(head)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []File{
+		{Path: first[0].Path, Content: first[0].Content},
+		{Path: second[0].Path, Content: second[0].Content},
+		{Path: second[1].Path, Content: []byte{}},
+	}
+	if !reflect.DeepEqual(files, want) {
+		t.Fatalf("got %q, want %q", files, want)
+	}
+
+	// 旧提交的内容不受新提交影响
+	files, err = repo.RawFiles(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0].Path != first[0].Path {
+		t.Fatalf("got %q, want only %s", files, first[0].Path)
+	}
+}
+
+func TestRawFilesEmpty(t *testing.T) {
+	repo := initRepo(t)
+	commit, err := repo.Commit("main", "", "Initial", []Change{{Path: "README.md", Content: []byte("readme")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := repo.RawFiles(commit)
+	if err != nil || len(files) != 0 {
+		t.Fatalf("got %q, %v; want no files", files, err)
+	}
+}
+
+func TestCommitRejectsMovedBranch(t *testing.T) {
+	repo := initRepo(t)
+	base, err := repo.Commit("main", "", "Initial", []Change{{Path: "README.md", Content: []byte("readme")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.Commit("main", base, "Second", []Change{{Path: "a.txt", Content: []byte("a")}}); err != nil {
+		t.Fatal(err)
+	}
+	// 基于过期的 parent 提交
+	if _, err := repo.Commit("main", base, "Stale", []Change{{Path: "b.txt", Content: []byte("b")}}); !errors.Is(err, ErrBranchMoved) {
+		t.Fatalf("got %v, want ErrBranchMoved", err)
+	}
+	// 分支已存在时不能再创建无父提交
+	if _, err := repo.Commit("main", "", "Orphan", nil); !errors.Is(err, ErrBranchMoved) {
+		t.Fatalf("got %v, want ErrBranchMoved", err)
+	}
+}
+
+func TestRawPath(t *testing.T) {
+	at := time.UnixMilli(1700000000123)
+	got := RawPath(at, "42", []byte("content"))
+	want := RawDir + "/1700000000123-42-" + Hash([]byte("content"))[:8] + ".ttml"
+	if got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+}